@@ -0,0 +1,234 @@
+package alert
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"financialApp/api/resource/bank"
+	"financialApp/api/resource/loan"
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Notifiers set in the ALERT_ env variables, by name
+var notifiers map[string]Notifier
+
+// Create the notifiers set in the ALERT_ env variables. Rules can only use these ones
+func Init() {
+
+	var err error
+	notifiers, err = NewNotifiers(config.Conf.Alert)
+	if err != nil {
+		config.Logger.Fatal().Err(err).Msg("Cannot create alert notifiers")
+	}
+
+	config.Logger.Info().Strs("notifiers", slices.Sorted(maps.Keys(notifiers))).Msg("Alert notifiers ready")
+}
+
+// Data of a sync checked by the rules
+type syncState struct {
+	now      time.Time
+	accounts []bank.BankAccountWebhook
+	loans    []loan.Loan
+	// Last unit value of a position on or before a date (YYYY-MM-DD), from its history
+	unitValueAt func(investId int, date string) (money.Amount, bool)
+}
+
+// Rule triggered by a sync. The key tells what triggered it, so that it is notified once
+type trigger struct {
+	key     string
+	message string
+}
+
+// Check the enabled rules against the accounts of a sync, and notify the ones triggered for the first time
+func CheckSync(accounts []bank.BankAccountWebhook) {
+
+	rules, err := readRules(true)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read alert rules")
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	loans, err := loan.ReadLoans()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read loans")
+		return
+	}
+
+	state := syncState{now: time.Now(), accounts: accounts, loans: loans, unitValueAt: readUnitValue}
+
+	for _, rule := range rules {
+		for _, t := range rule.evaluate(state) {
+			if err := dispatch(rule, t, state.now); err != nil {
+				config.Logger.Error().Err(err).Int("rule_id", rule.Rule_id).Str("key", t.key).Msg("Cannot record alert")
+			}
+		}
+	}
+}
+
+// Triggers of a rule for the data of a sync
+func (r Rule) evaluate(s syncState) []trigger {
+
+	var triggers []trigger
+	today := time.Date(s.now.Year(), s.now.Month(), s.now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch r.Rule_type {
+	case TypeBalanceBelow:
+		for _, account := range s.accounts {
+			if !r.concerns(account.Account_id) || account.Balance >= r.Amount {
+				continue
+			}
+			triggers = append(triggers, trigger{
+				key:     fmt.Sprintf("balance:%d:%s", account.Account_id, today.Format("2006-01-02")),
+				message: fmt.Sprintf("The balance of %s is %s %s, below %s", account.Original_name, account.Balance.StringFixed(2), account.Currency.Id, r.Amount.StringFixed(2)),
+			})
+		}
+
+	case TypeTransactionAbove, TypeTransactionWording:
+		for _, account := range s.accounts {
+			if !r.concerns(account.Account_id) {
+				continue
+			}
+			for _, tx := range account.Transactions {
+				if r.Rule_type == TypeTransactionAbove && tx.Value.Abs() < r.Amount {
+					continue
+				}
+				if r.Rule_type == TypeTransactionWording && !strings.Contains(strings.ToLower(tx.Original_wording), strings.ToLower(r.Wording)) {
+					continue
+				}
+				triggers = append(triggers, trigger{
+					key:     fmt.Sprintf("tx:%d", tx.Id),
+					message: fmt.Sprintf("Transaction of %s %s on %s the %s: %s", tx.Value.StringFixed(2), account.Currency.Id, account.Original_name, tx.Date, tx.Original_wording),
+				})
+			}
+		}
+
+	case TypeLoanPayment:
+		for _, l := range s.loans {
+			if !r.concerns(l.Loan_account_id) || l.IsRevolving() {
+				continue
+			}
+			date, err := parseDay(l.Next_payment_date)
+			if err != nil {
+				continue
+			}
+			days := int(date.Sub(today).Hours() / 24)
+			if days < 0 || days > r.Days {
+				continue
+			}
+			triggers = append(triggers, trigger{
+				key:     fmt.Sprintf("loan:%d:%s", l.Loan_account_id, date.Format("2006-01-02")),
+				message: fmt.Sprintf("Payment of %s for %s due the %s", l.Next_payment_amount.StringFixed(2), l.Account_label, date.Format("2006-01-02")),
+			})
+		}
+
+	case TypePositionDrop:
+		since := today.AddDate(0, 0, -r.Days).Format("2006-01-02")
+		for _, account := range s.accounts {
+			if !r.concerns(account.Account_id) {
+				continue
+			}
+			for _, invest := range account.Investments {
+				reference, ok := s.unitValueAt(invest.Invest_id, since)
+				if !ok || reference <= 0 {
+					continue
+				}
+				drop := reference.Sub(invest.Unit_value).Div(reference) * 100
+				if drop < r.Percent {
+					continue
+				}
+				triggers = append(triggers, trigger{
+					key:     fmt.Sprintf("position:%d:%s", invest.Invest_id, today.Format("2006-01-02")),
+					message: fmt.Sprintf("%s lost %.2f %% since the %s: %s -> %s", invest.Label, drop, since, reference.StringFixed(2), invest.Unit_value.StringFixed(2)),
+				})
+			}
+		}
+	}
+
+	return triggers
+}
+
+// True if the rule checks the account. A rule without account checks all of them
+func (r Rule) concerns(accountId int) bool {
+	return r.Account_id == 0 || r.Account_id == accountId
+}
+
+// Record the alert of a trigger, then notify it. A trigger already recorded for the rule is ignored
+func dispatch(rule Rule, t trigger, now time.Time) error {
+
+	var query string = "INSERT IGNORE INTO alertHistory (rule_id, alert_key, alert_date, title, message, notifier, alert_status) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	result, err := config.DB.Exec(query, rule.Rule_id, t.key, now.Format("2006-01-02 15:04:05"), rule.Name, t.message, rule.Notifier, StatusPending)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return nil
+	}
+
+	alertId, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	status, errorMessage := StatusSent, ""
+	if err := notify(rule, Notification{Title: rule.Name, Message: t.message, Target: rule.Target}); err != nil {
+		config.Logger.Error().Err(err).Int("rule_id", rule.Rule_id).Str("notifier", rule.Notifier).Msg("Cannot send alert")
+		status, errorMessage = StatusFailed, err.Error()
+	}
+
+	query = "UPDATE alertHistory SET alert_status=?, error_message=? WHERE alert_id=?"
+	_, err = config.DB.Exec(query, status, errorMessage, alertId)
+	return err
+}
+
+// Send a notification with the notifier of a rule
+func notify(rule Rule, notification Notification) error {
+
+	notifier, ok := notifiers[rule.Notifier]
+	if !ok {
+		return fmt.Errorf("notifier '%s' is not configured", rule.Notifier)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Conf.Alert.Timeout)
+	defer cancel()
+
+	return notifier.Notify(ctx, notification)
+}
+
+// Last unit value of a position on or before a date
+func readUnitValue(investId int, date string) (money.Amount, bool) {
+
+	var unitValue money.Amount
+
+	var query string = "SELECT unit_value FROM investHistory WHERE invest_id=? AND history_date<=? ORDER BY history_date DESC LIMIT 1"
+	if err := config.DB.QueryRow(query, investId, date).Scan(&unitValue); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			config.Logger.Error().Err(err).Msg(query)
+		}
+		return 0, false
+	}
+	return unitValue, true
+}
+
+// Day of a Powens date, "YYYY-MM-DD" or "YYYY-MM-DD HH:MM:SS"
+func parseDay(value string) (time.Time, error) {
+
+	if date, err := time.Parse("2006-01-02 15:04:05", value); err == nil {
+		return date.Truncate(24 * time.Hour), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,138 @@
+package alert
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"financialApp/api/resource/bank"
+	"financialApp/api/resource/investment"
+	"financialApp/api/resource/loan"
+	"financialApp/api/resource/transaction"
+	"financialApp/money"
+)
+
+func TestEvaluate(t *testing.T) {
+
+	state := syncState{
+		now: time.Date(2024, 3, 10, 14, 30, 0, 0, time.UTC),
+		accounts: []bank.BankAccountWebhook{
+			{
+				Account_id:    1,
+				Original_name: "Checking",
+				Balance:       money.MustParse("150"),
+				Transactions: []transaction.Transaction{
+					{Id: 10, Value: money.MustParse("-1200"), Original_wording: "PRLV LOYER MARS", Date: "2024-03-09"},
+					{Id: 11, Value: money.MustParse("-25.5"), Original_wording: "NETFLIX", Date: "2024-03-09"},
+				},
+			},
+			{
+				Account_id:    2,
+				Original_name: "PEA",
+				Balance:       money.MustParse("10000"),
+				Investments: []investment.Investment{
+					{Invest_id: 100, Label: "World ETF", Unit_value: money.MustParse("90")},
+					{Invest_id: 101, Label: "Bond ETF", Unit_value: money.MustParse("99")},
+				},
+			},
+		},
+		loans: []loan.Loan{
+			{Loan_account_id: 3, Account_label: "Mortgage", Next_payment_date: "2024-03-12 00:00:00", Next_payment_amount: money.MustParse("850")},
+			{Loan_account_id: 4, Account_label: "Car", Next_payment_date: "2024-03-25", Next_payment_amount: money.MustParse("300")},
+			{Loan_account_id: 5, Account_label: "Revolving", Next_payment_date: "2024-03-11", Loan_type: loan.TypeRevolving},
+		},
+		unitValueAt: func(investId int, date string) (money.Amount, bool) {
+			if date != "2024-03-03" {
+				t.Errorf("Wrong reference date %s", date)
+			}
+			values := map[int]money.Amount{100: money.MustParse("100"), 101: money.MustParse("100")}
+			value, ok := values[investId]
+			return value, ok
+		},
+	}
+
+	tests := []struct {
+		name string
+		rule Rule
+		keys []string
+	}{
+		{"balance", Rule{Rule_type: TypeBalanceBelow, Amount: money.MustParse("200")}, []string{"balance:1:2024-03-10"}},
+		{"balance of another account", Rule{Rule_type: TypeBalanceBelow, Amount: money.MustParse("200"), Account_id: 2}, nil},
+		{"debit above", Rule{Rule_type: TypeTransactionAbove, Amount: money.MustParse("1000")}, []string{"tx:10"}},
+		{"wording", Rule{Rule_type: TypeTransactionWording, Wording: "netflix"}, []string{"tx:11"}},
+		{"loan payment", Rule{Rule_type: TypeLoanPayment, Days: 3}, []string{"loan:3:2024-03-12"}},
+		{"position drop", Rule{Rule_type: TypePositionDrop, Percent: 5, Days: 7}, []string{"position:100:2024-03-10"}},
+	}
+
+	for _, test := range tests {
+		triggers := test.rule.evaluate(state)
+		if len(triggers) != len(test.keys) {
+			t.Errorf("%s: got %+v, want %v", test.name, triggers, test.keys)
+			continue
+		}
+		for i, trigger := range triggers {
+			if trigger.key != test.keys[i] || trigger.message == "" {
+				t.Errorf("%s: got %+v, want %s", test.name, trigger, test.keys[i])
+			}
+		}
+	}
+}
+
+// Notifier which keeps what it is asked to send
+type recorder struct {
+	sent []Notification
+}
+
+func (n *recorder) Name() string {
+	return "recorder"
+}
+
+func (n *recorder) Notify(ctx context.Context, notification Notification) error {
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func TestValidateRule(t *testing.T) {
+
+	notifiers = map[string]Notifier{NotifierNtfy: &recorder{}}
+	defer func() { notifiers = nil }()
+
+	rule := Rule{Name: " Drop ", Rule_type: TypePositionDrop, Percent: 10, Notifier: NotifierNtfy}
+	if err := validateRule(&rule); err != nil {
+		t.Fatal(err)
+	}
+	if rule.Name != "Drop" || rule.Days != 1 {
+		t.Errorf("Wrong defaults: %+v", rule)
+	}
+
+	for _, rule := range []Rule{
+		{Name: "No type", Notifier: NotifierNtfy},
+		{Name: "No amount", Rule_type: TypeTransactionAbove, Notifier: NotifierNtfy},
+		{Name: "No wording", Rule_type: TypeTransactionWording, Wording: " ", Notifier: NotifierNtfy},
+		{Name: "Not configured", Rule_type: TypeBalanceBelow, Notifier: NotifierSmtp},
+	} {
+		if err := validateRule(&rule); err == nil {
+			t.Errorf("%s: expected an error", rule.Name)
+		}
+	}
+}
+
+func TestNotify(t *testing.T) {
+
+	sent := &recorder{}
+	notifiers = map[string]Notifier{NotifierWebhook: sent}
+	defer func() { notifiers = nil }()
+
+	rule := Rule{Name: "Rent", Notifier: NotifierWebhook, Target: "http://localhost/rent"}
+	if err := notify(rule, Notification{Title: rule.Name, Message: "Rent paid", Target: rule.Target}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent.sent) != 1 || sent.sent[0].Target != "http://localhost/rent" {
+		t.Errorf("Wrong notifications: %+v", sent.sent)
+	}
+
+	rule.Notifier = NotifierGotify
+	if err := notify(rule, Notification{}); err == nil {
+		t.Error("Expected an error for a notifier which is not configured")
+	}
+}
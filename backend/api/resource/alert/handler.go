@@ -0,0 +1,330 @@
+package alert
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"financialApp/config"
+)
+
+const defaultHistoryLimit = 100
+
+func CreateRule(w http.ResponseWriter, r *http.Request) {
+
+	// A rule is enabled unless told otherwise
+	rule := Rule{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var query string = "INSERT INTO alertRule (rule_name, rule_type, account_id, amount, wording, days, percent, notifier, target, enabled) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := config.DB.Exec(query, rule.Name, rule.Rule_type, rule.Account_id, rule.Amount, rule.Wording, rule.Days, rule.Percent, rule.Notifier, rule.Target, rule.Enabled)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get rule id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	rule.Rule_id = int(id)
+
+	jsonBody, err := json.Marshal(rule)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal alert rule")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+func GetRules(w http.ResponseWriter, r *http.Request) {
+
+	rules, err := readRules(false)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read alert rules")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if len(rules) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	jsonBody, err := json.Marshal(rules)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal alert rules")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+func UpdateRule(w http.ResponseWriter, r *http.Request) {
+
+	ruleId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := readRule(ruleId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Rule does not exist", http.StatusNotFound)
+			return
+		}
+		config.Logger.Error().Err(err).Int("rule_id", ruleId).Msg("Cannot read alert rule")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	rule := Rule{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var query string = "UPDATE alertRule SET rule_name=?, rule_type=?, account_id=?, amount=?, wording=?, days=?, percent=?, notifier=?, target=?, enabled=? WHERE rule_id=?"
+	_, err = config.DB.Exec(query, rule.Name, rule.Rule_type, rule.Account_id, rule.Amount, rule.Wording, rule.Days, rule.Percent, rule.Notifier, rule.Target, rule.Enabled, ruleId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete a rule and its alerts
+func DeleteRule(w http.ResponseWriter, r *http.Request) {
+
+	ruleId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var query string = "DELETE FROM alertRule WHERE rule_id=?"
+	result, err := config.DB.Exec(query, ruleId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		http.Error(w, "Rule does not exist", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Send a test notification with the notifier of a rule, to check its settings. Nothing is recorded
+func TestRule(w http.ResponseWriter, r *http.Request) {
+
+	ruleId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := readRule(ruleId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Rule does not exist", http.StatusNotFound)
+			return
+		}
+		config.Logger.Error().Err(err).Int("rule_id", ruleId).Msg("Cannot read alert rule")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	notification := Notification{
+		Title:   rule.Name,
+		Message: fmt.Sprintf("Test of the alert rule '%s'", rule.Name),
+		Target:  rule.Target,
+	}
+	if err := notify(rule, notification); err != nil {
+		config.Logger.Error().Err(err).Int("rule_id", ruleId).Str("notifier", rule.Notifier).Msg("Cannot send test alert")
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Get the last alerts, most recent first. Optional query parameters: ?rule=<rule id>&limit=<number of alerts, 100 by default>
+func GetHistory(w http.ResponseWriter, r *http.Request) {
+
+	ruleId := 0
+	if value := r.URL.Query().Get("rule"); value != "" {
+		var err error
+		if ruleId, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "Wrong rule", http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := defaultHistoryLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			http.Error(w, "Wrong limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var alerts []Alert
+
+	var query string = "SELECT alert_id, rule_id, alert_key, alert_date, title, message, notifier, alert_status, error_message FROM alertHistory WHERE (?=0 OR rule_id=?) ORDER BY alert_date DESC, alert_id DESC LIMIT ?"
+	rows, err := config.DB.Query(query, ruleId, ruleId, limit)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var alert Alert
+		if err := rows.Scan(&alert.Alert_id, &alert.Rule_id, &alert.Key, &alert.Date, &alert.Title, &alert.Message, &alert.Notifier, &alert.Status, &alert.Error); err != nil {
+			config.Logger.Error().Err(err).Msg("Cannot scan row")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		config.Logger.Error().Err(err).Msg("Error in rows")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if len(alerts) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	jsonBody, err := json.Marshal(alerts)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal alerts")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Read every rule, or only the enabled ones
+func readRules(onlyEnabled bool) ([]Rule, error) {
+
+	var rules []Rule
+
+	var query string = "SELECT rule_id, rule_name, rule_type, account_id, amount, wording, days, percent, notifier, target, enabled FROM alertRule WHERE (?=FALSE OR enabled) ORDER BY rule_id"
+	rows, err := config.DB.Query(query, onlyEnabled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func readRule(ruleId int) (Rule, error) {
+
+	var query string = "SELECT rule_id, rule_name, rule_type, account_id, amount, wording, days, percent, notifier, target, enabled FROM alertRule WHERE rule_id=?"
+	return scanRule(config.DB.QueryRow(query, ruleId))
+}
+
+func scanRule(row interface{ Scan(...any) error }) (Rule, error) {
+
+	var rule Rule
+	err := row.Scan(&rule.Rule_id, &rule.Name, &rule.Rule_type, &rule.Account_id, &rule.Amount, &rule.Wording, &rule.Days, &rule.Percent, &rule.Notifier, &rule.Target, &rule.Enabled)
+	return rule, err
+}
+
+// Check the user input and set the default number of days
+func validateRule(rule *Rule) error {
+
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if !slices.Contains(ruleTypes, rule.Rule_type) {
+		return errors.New("type must be " + strings.Join(ruleTypes, ", "))
+	}
+
+	switch rule.Rule_type {
+	case TypeTransactionAbove:
+		if rule.Amount <= 0 {
+			return errors.New("amount must be positive")
+		}
+	case TypeTransactionWording:
+		rule.Wording = strings.TrimSpace(rule.Wording)
+		if rule.Wording == "" {
+			return errors.New("wording is required")
+		}
+	case TypeLoanPayment:
+		if rule.Days < 0 {
+			return errors.New("days must be positive")
+		}
+		if rule.Days == 0 {
+			rule.Days = 3
+		}
+	case TypePositionDrop:
+		if rule.Percent <= 0 || rule.Percent > 100 {
+			return errors.New("percent must be between 0 and 100")
+		}
+		if rule.Days < 0 {
+			return errors.New("days must be positive")
+		}
+		if rule.Days == 0 {
+			rule.Days = 1
+		}
+	}
+
+	rule.Target = strings.TrimSpace(rule.Target)
+	if _, ok := notifiers[rule.Notifier]; !ok {
+		configured := slices.Sorted(maps.Keys(notifiers))
+		if len(configured) == 0 {
+			return errors.New("no notifier is configured, see the ALERT_ env variables")
+		}
+		return errors.New("notifier must be " + strings.Join(configured, ", "))
+	}
+
+	return nil
+}
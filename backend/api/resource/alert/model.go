@@ -0,0 +1,65 @@
+package alert
+
+import "financialApp/money"
+
+// Kinds of rules, checked after each sync
+const (
+	TypeBalanceBelow       = "balance_below"       // balance of an account under Amount
+	TypeTransactionAbove   = "transaction_above"   // debit or credit of at least Amount
+	TypeTransactionWording = "transaction_wording" // wording containing Wording, case insensitive
+	TypeLoanPayment        = "loan_payment"        // payment of a loan due in Days days or less
+	TypePositionDrop       = "position_drop"       // unit value of a position down by Percent % in Days days
+)
+
+var ruleTypes = []string{TypeBalanceBelow, TypeTransactionAbove, TypeTransactionWording, TypeLoanPayment, TypePositionDrop}
+
+// Names of the notifiers
+const (
+	NotifierSmtp    = "smtp"
+	NotifierWebhook = "webhook"
+	NotifierNtfy    = "ntfy"
+	NotifierGotify  = "gotify"
+)
+
+// Delivery of an alert
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+// Condition checked after each sync, and the way to notify it.
+// Ex: {"name": "Low balance", "type": "balance_below", "account_id": 12, "amount": 200, "notifier": "ntfy"}
+type Rule struct {
+	Rule_id    int          `json:"id"`
+	Name       string       `json:"name"`
+	Rule_type  string       `json:"type"`
+	Account_id int          `json:"account_id"` // 0 for every account
+	Amount     money.Amount `json:"amount"`     // balance_below and transaction_above
+	Wording    string       `json:"wording"`    // transaction_wording
+	Days       int          `json:"days"`       // loan_payment: 3 by default. position_drop: 1 by default
+	Percent    float64      `json:"percent"`    // position_drop
+	Notifier   string       `json:"notifier"`   // smtp, webhook, ntfy or gotify
+	Target     string       `json:"target"`     // replaces the default recipient of the notifier: email, URL or ntfy topic
+	Enabled    bool         `json:"enabled"`    // true if absent at creation
+}
+
+// Notification of a triggered rule. A same key is only notified once for a rule
+type Alert struct {
+	Alert_id int    `json:"id"`
+	Rule_id  int    `json:"rule_id"`
+	Key      string `json:"key"`  // what triggered the rule. Ex: "tx:1234", "balance:12:2024-01-31"
+	Date     string `json:"date"` // YYYY-MM-DD HH:MM:SS
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Notifier string `json:"notifier"`
+	Status   string `json:"status"` // pending, sent or failed
+	Error    string `json:"error,omitempty"`
+}
+
+// Content given to a notifier
+type Notification struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	Target  string `json:"-"`
+}
@@ -0,0 +1,225 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"financialApp/config"
+)
+
+// Send the notification of a triggered rule
+type Notifier interface {
+	// Name used by the rules to choose their notifier
+	Name() string
+	// Deliver the notification. An empty target sends it to the default recipient of the notifier
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// Create every notifier with settings in the ALERT_ env variables, by name
+func NewNotifiers(conf config.ConfAlert) (map[string]Notifier, error) {
+
+	client := &http.Client{Timeout: conf.Timeout}
+	notifiers := make(map[string]Notifier)
+
+	if conf.SmtpHost != "" {
+		if conf.SmtpFrom == "" {
+			return nil, errors.New("ALERT_SMTP_FROM is required by the smtp notifier")
+		}
+		notifiers[NotifierSmtp] = &SmtpNotifier{
+			Host:     conf.SmtpHost,
+			Port:     conf.SmtpPort,
+			Username: conf.SmtpUsername,
+			Password: conf.SmtpPassword,
+			From:     conf.SmtpFrom,
+			To:       conf.SmtpTo,
+		}
+	}
+	if conf.WebhookUrl != "" {
+		notifiers[NotifierWebhook] = &WebhookNotifier{Url: conf.WebhookUrl, Client: client}
+	}
+	if conf.NtfyTopic != "" {
+		notifiers[NotifierNtfy] = &NtfyNotifier{Url: conf.NtfyUrl, Topic: conf.NtfyTopic, Token: conf.NtfyToken, Client: client}
+	}
+	if conf.GotifyUrl != "" {
+		if conf.GotifyToken == "" {
+			return nil, errors.New("ALERT_GOTIFY_TOKEN is required by the gotify notifier")
+		}
+		notifiers[NotifierGotify] = &GotifyNotifier{Url: conf.GotifyUrl, Token: conf.GotifyToken, Client: client}
+	}
+
+	return notifiers, nil
+}
+
+// Plain text email. Without username, the server is used without authentication
+type SmtpNotifier struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (n *SmtpNotifier) Name() string {
+	return NotifierSmtp
+}
+
+func (n *SmtpNotifier) Notify(ctx context.Context, notification Notification) error {
+
+	to := n.To
+	if notification.Target != "" {
+		to = []string{notification.Target}
+	}
+	if len(to) == 0 {
+		return errors.New("no recipient: set ALERT_SMTP_TO or the target of the rule")
+	}
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(notification.Message, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	// net/smtp has no context: the send is abandoned, not interrupted, when the context ends
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(n.Host, strconv.Itoa(n.Port)), auth, n.From, to, msg.Bytes())
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// JSON notification posted to any URL: {"title": "...", "message": "..."}
+type WebhookNotifier struct {
+	Url    string
+	Client *http.Client
+}
+
+func (n *WebhookNotifier) Name() string {
+	return NotifierWebhook
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+
+	url := n.Url
+	if notification.Target != "" {
+		url = notification.Target
+	}
+
+	jsonBody, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return send(n.Client, req)
+}
+
+// Push notification published on a topic of a ntfy server
+// https://docs.ntfy.sh/publish/
+type NtfyNotifier struct {
+	Url    string
+	Topic  string
+	Token  string // access token of a protected topic, optional
+	Client *http.Client
+}
+
+func (n *NtfyNotifier) Name() string {
+	return NotifierNtfy
+}
+
+func (n *NtfyNotifier) Notify(ctx context.Context, notification Notification) error {
+
+	topic := n.Topic
+	if notification.Target != "" {
+		topic = notification.Target
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(n.Url, "/")+"/"+topic, strings.NewReader(notification.Message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", notification.Title)
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+
+	return send(n.Client, req)
+}
+
+// Push notification sent to a Gotify server, with the token of an application
+// https://gotify.net/docs/pushmsg
+type GotifyNotifier struct {
+	Url    string
+	Token  string
+	Client *http.Client
+}
+
+func (n *GotifyNotifier) Name() string {
+	return NotifierGotify
+}
+
+func (n *GotifyNotifier) Notify(ctx context.Context, notification Notification) error {
+
+	jsonBody, err := json.Marshal(map[string]any{
+		"title":    notification.Title,
+		"message":  notification.Message,
+		"priority": 5,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(n.Url, "/")+"/message", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", n.Token)
+
+	return send(n.Client, req)
+}
+
+// Do the request, any status other than 2xx is an error
+func send(client *http.Client, req *http.Request) error {
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New(req.URL.Host + " returned " + resp.Status)
+	}
+	return nil
+}
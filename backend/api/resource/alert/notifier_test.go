@@ -0,0 +1,166 @@
+package alert
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"financialApp/config"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+
+	var received Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Wrong request: %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	notifier := &WebhookNotifier{Url: "http://127.0.0.1:1/unused", Client: server.Client()}
+
+	// The target of the rule replaces the URL of the notifier
+	err := notifier.Notify(context.Background(), Notification{Title: "Low balance", Message: "Balance is 10.00", Target: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.Title != "Low balance" || received.Message != "Balance is 10.00" {
+		t.Errorf("Wrong notification: %+v", received)
+	}
+}
+
+func TestNtfyNotifier(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path != "/finance" || r.Header.Get("Title") != "Loan" || string(body) != "Payment due" || r.Header.Get("Authorization") != "Bearer tk_secret" {
+			t.Errorf("Wrong request: %s %v %s", r.URL.Path, r.Header, body)
+		}
+	}))
+	defer server.Close()
+
+	notifier := &NtfyNotifier{Url: server.URL + "/", Topic: "finance", Token: "tk_secret", Client: server.Client()}
+	if err := notifier.Notify(context.Background(), Notification{Title: "Loan", Message: "Payment due"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGotifyNotifier(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/message" || r.Header.Get("X-Gotify-Key") != "app-token" {
+			t.Errorf("Wrong request: %s %v", r.URL.Path, r.Header)
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["title"] != "Drop" || body["message"] != "Down 10 %" {
+			t.Errorf("Wrong body: %v", body)
+		}
+		http.Error(w, "", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	notifier := &GotifyNotifier{Url: server.URL, Token: "app-token", Client: server.Client()}
+
+	// A refused message is an error
+	if err := notifier.Notify(context.Background(), Notification{Title: "Drop", Message: "Down 10 %"}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected the status in the error, got %v", err)
+	}
+}
+
+func TestSmtpNotifier(t *testing.T) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// Minimal SMTP server, without extensions, which keeps the data of the mail
+	data := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 localhost ready")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "DATA"):
+				reply("354 go ahead")
+				var mail strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					mail.WriteString(line)
+				}
+				data <- mail.String()
+				reply("250 queued")
+			case strings.HasPrefix(command, "QUIT"):
+				reply("221 bye")
+				return
+			default: // MAIL FROM, RCPT TO
+				reply("250 ok")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	notifier := &SmtpNotifier{Host: host, Port: portNumber, From: "freenahi@example.com", To: []string{"me@example.com"}}
+
+	if err := notifier.Notify(context.Background(), Notification{Title: "Solde bas", Message: "Le solde est de 10.00"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case mail := <-data:
+		for _, want := range []string{"To: me@example.com\r\n", "Subject: Solde bas\r\n", "\r\n\r\nLe solde est de 10.00\r\n"} {
+			if !strings.Contains(mail, want) {
+				t.Errorf("%q not in mail %q", want, mail)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("No mail received")
+	}
+}
+
+func TestNewNotifiers(t *testing.T) {
+
+	notifiers, err := NewNotifiers(config.ConfAlert{NtfyUrl: "https://ntfy.sh", NtfyTopic: "finance", WebhookUrl: "http://localhost/alert"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifiers) != 2 || notifiers[NotifierNtfy] == nil || notifiers[NotifierWebhook] == nil {
+		t.Errorf("Wrong notifiers: %v", notifiers)
+	}
+
+	if _, err := NewNotifiers(config.ConfAlert{SmtpHost: "localhost"}); err == nil {
+		t.Error("Expected an error without sender")
+	}
+}
@@ -0,0 +1,467 @@
+package allocation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"financialApp/api/resource/fx"
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Bucket of values without allocation
+const unknown = "Unknown"
+
+func GetSecurities(w http.ResponseWriter, r *http.Request) {
+
+	allocations, err := readAllocations()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read security allocations")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	var securities []Security
+	for code, codeAllocations := range allocations {
+		securities = append(securities, Security{Code: code, Allocations: codeAllocations})
+	}
+	sort.Slice(securities, func(i, j int) bool {
+		return securities[i].Code < securities[j].Code
+	})
+
+	jsonBody, err := json.Marshal(securities)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal securities")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Replace every allocation of a security
+func UpdateSecurity(w http.ResponseWriter, r *http.Request) {
+
+	var security Security
+	if err := json.NewDecoder(r.Body).Decode(&security); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	security.Code = r.PathValue("code")
+
+	if err := validateAllocations(security.Allocations); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := saveSecurities([]Security{security}, true); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot save security allocations")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func DeleteSecurity(w http.ResponseWriter, r *http.Request) {
+
+	var query string = "DELETE FROM securityAllocation WHERE invest_code=?"
+	result, err := config.DB.Exec(query, r.PathValue("code"))
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		http.Error(w, "Security does not exist", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Import allocations, as a JSON list of securities or, with the text/csv content type, as "code,dimension,name[,weight]" lines.
+// The allocations of a security along an imported dimension are replaced, other dimensions are kept
+func ImportSecurities(w http.ResponseWriter, r *http.Request) {
+
+	var securities []Security
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		securities, err = parseSecuritiesCsv(r.Body)
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&securities)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, security := range securities {
+		if security.Code == "" {
+			http.Error(w, "code is required", http.StatusBadRequest)
+			return
+		}
+		if err := validateAllocations(security.Allocations); err != nil {
+			http.Error(w, security.Code+": "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := saveSecurities(securities, false); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot save security allocations")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// Break down the wealth along a dimension (?dimension=asset_class, region or sector), in the base currency.
+// Invests are split with the allocations of their security. Cash, crypto and real estate are their own asset class.
+// Debts are not deducted
+func GetAllocation(w http.ResponseWriter, r *http.Request) {
+
+	dimension := r.URL.Query().Get("dimension")
+	if dimension == "" {
+		dimension = "asset_class"
+	}
+	if !slices.Contains(dimensions, dimension) {
+		http.Error(w, "dimension must be asset_class, region or sector", http.StatusBadRequest)
+		return
+	}
+
+	converter, err := fx.LoadConverter()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot load fx rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	holdings, err := readHoldings(converter)
+	if errors.Is(err, fx.ErrNoRate) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read holdings")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	allocations, err := readAllocations()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read security allocations")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	breakdown := split(holdings, allocations, dimension)
+	if len(breakdown) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	jsonBody, err := json.Marshal(breakdown)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal allocation")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Sum the holdings by bucket of the dimension, largest first
+func split(holdings []holding, allocations map[string][]Allocation, dimension string) []Slice {
+
+	buckets := make(map[string]money.Amount)
+	var total money.Amount
+
+	for _, holding := range holdings {
+		if holding.value.Sign() <= 0 {
+			continue
+		}
+		total += holding.value
+
+		var weights []Allocation
+		for _, allocation := range allocations[holding.code] {
+			if allocation.Dimension == dimension {
+				weights = append(weights, allocation)
+			}
+		}
+
+		if len(weights) == 0 {
+			if dimension == "asset_class" && holding.class != "" {
+				buckets[holding.class] += holding.value
+			} else {
+				buckets[unknown] += holding.value
+			}
+			continue
+		}
+
+		var sum float64
+		for _, weight := range weights {
+			sum += weight.Weight
+		}
+
+		rest := holding.value
+		for index, weight := range weights {
+			// Give the rounding remainder to the last bucket when the weights cover the whole value
+			if index == len(weights)-1 && math.Abs(sum-1) < 1e-6 {
+				buckets[weight.Name] += rest
+				rest = 0
+				break
+			}
+			part := holding.value.Mul(weight.Weight)
+			buckets[weight.Name] += part
+			rest -= part
+		}
+		if rest.Sign() > 0 {
+			buckets[unknown] += rest
+		}
+	}
+
+	var breakdown []Slice
+	for name, value := range buckets {
+		breakdown = append(breakdown, Slice{Name: name, Value: value, Share: value.Div(total)})
+	}
+	sort.Slice(breakdown, func(i, j int) bool {
+		if breakdown[i].Value != breakdown[j].Value {
+			return breakdown[i].Value > breakdown[j].Value
+		}
+		return breakdown[i].Name < breakdown[j].Name
+	})
+
+	return breakdown
+}
+
+func validateAllocations(allocations []Allocation) error {
+
+	sums := make(map[string]float64)
+	for _, allocation := range allocations {
+		if !slices.Contains(dimensions, allocation.Dimension) {
+			return errors.New("dimension must be asset_class, region or sector")
+		}
+		if strings.TrimSpace(allocation.Name) == "" {
+			return errors.New("name is required")
+		}
+		if allocation.Weight <= 0 || allocation.Weight > 1 {
+			return errors.New("weight must be greater than 0 and at most 1")
+		}
+		sums[allocation.Dimension] += allocation.Weight
+	}
+
+	for dimension, sum := range sums {
+		if sum > 1+1e-6 {
+			return fmt.Errorf("weights of %s sum to more than 1", dimension)
+		}
+	}
+
+	return nil
+}
+
+// Read "code,dimension,name[,weight]" lines, the weight is 1 if absent. A header line and lines starting with # are ignored
+func parseSecuritiesCsv(r io.Reader) ([]Security, error) {
+
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var securities []Security
+	indexes := make(map[string]int)
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "code") {
+			continue // header
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: expected code,dimension,name[,weight]", line)
+		}
+
+		allocation := Allocation{
+			Dimension: strings.TrimSpace(record[1]),
+			Name:      strings.TrimSpace(record[2]),
+			Weight:    1,
+		}
+		if len(record) >= 4 && strings.TrimSpace(record[3]) != "" {
+			allocation.Weight, err = strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: wrong weight '%s'", line, record[3])
+			}
+		}
+
+		code := strings.TrimSpace(record[0])
+		index, ok := indexes[code]
+		if !ok {
+			index = len(securities)
+			indexes[code] = index
+			securities = append(securities, Security{Code: code})
+		}
+		securities[index].Allocations = append(securities[index].Allocations, allocation)
+	}
+
+	return securities, nil
+}
+
+// Save the allocations of securities. If replaceAll, every previous allocation of a security is removed,
+// otherwise only the ones of the dimensions being saved
+func saveSecurities(securities []Security, replaceAll bool) error {
+
+	dbTx, err := config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+
+	for _, security := range securities {
+
+		if replaceAll {
+			if _, err := dbTx.Exec("DELETE FROM securityAllocation WHERE invest_code=?", security.Code); err != nil {
+				return err
+			}
+		} else {
+			var cleared []string
+			for _, allocation := range security.Allocations {
+				if slices.Contains(cleared, allocation.Dimension) {
+					continue
+				}
+				if _, err := dbTx.Exec("DELETE FROM securityAllocation WHERE invest_code=? AND dimension=?", security.Code, allocation.Dimension); err != nil {
+					return err
+				}
+				cleared = append(cleared, allocation.Dimension)
+			}
+		}
+
+		for _, allocation := range security.Allocations {
+			var query string = "INSERT INTO securityAllocation (invest_code, dimension, bucket, weight) VALUES (?, ?, ?, ?)"
+			if _, err := dbTx.Exec(query, security.Code, allocation.Dimension, strings.TrimSpace(allocation.Name), allocation.Weight); err != nil {
+				return err
+			}
+		}
+	}
+
+	return dbTx.Commit()
+}
+
+// Allocations by security code
+func readAllocations() (map[string][]Allocation, error) {
+
+	allocations := make(map[string][]Allocation)
+
+	var query string = "SELECT invest_code, dimension, bucket, weight FROM securityAllocation ORDER BY invest_code, dimension, weight DESC"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var code string
+		var allocation Allocation
+		if err := rows.Scan(&code, &allocation.Dimension, &allocation.Name, &allocation.Weight); err != nil {
+			return nil, err
+		}
+		allocations[code] = append(allocations[code], allocation)
+	}
+
+	return allocations, rows.Err()
+}
+
+// Read every open invest, the cash of accounts (of investment accounts too), crypto wallets and the owned share of properties, in the base currency
+func readHoldings(converter *fx.Converter) ([]holding, error) {
+
+	var holdings []holding
+	now := time.Now()
+
+	var query string = "SELECT invest.invest_code, invest.valuation, bankAccount.currency FROM invest INNER JOIN bankAccount ON invest.account_id = bankAccount.account_id WHERE invest.closed_date IS NULL"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var code, currency string
+		var valuation money.Amount
+		if err := rows.Scan(&code, &valuation, &currency); err != nil {
+			return nil, err
+		}
+		value, err := converter.ToBase(valuation, currency, now)
+		if err != nil {
+			return nil, err
+		}
+		holdings = append(holdings, holding{code: code, value: value})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The cash of an investment account is its balance minus its invests
+	query = "SELECT bankAccount.account_type, bankAccount.currency, bankAccount.balance - COALESCE((SELECT SUM(invest.valuation) FROM invest WHERE invest.account_id = bankAccount.account_id AND invest.closed_date IS NULL), 0) FROM bankAccount"
+	accountRows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer accountRows.Close()
+
+	for accountRows.Next() {
+		var accountType, currency string
+		var cash money.Amount
+		if err := accountRows.Scan(&accountType, &currency, &cash); err != nil {
+			return nil, err
+		}
+
+		class := "Cash"
+		switch accountType {
+		case "crypto":
+			class = "Crypto"
+		case "loan", "real_estate":
+			continue // debts are not deducted, properties are read below
+		}
+		value, err := converter.ToBase(cash, currency, now)
+		if err != nil {
+			return nil, err
+		}
+		holdings = append(holdings, holding{class: class, value: value})
+	}
+	if err := accountRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The value of a property is its last valuation
+	query = `SELECT p.ownership_share,
+		COALESCE((SELECT v.valuation FROM realEstateValue v WHERE v.property_id = p.property_id ORDER BY v.date_valuation DESC, v.value_id DESC LIMIT 1), p.purchase_price)
+		FROM realEstate p`
+	propertyRows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer propertyRows.Close()
+
+	for propertyRows.Next() {
+		var share float32
+		var value money.Amount
+		if err := propertyRows.Scan(&share, &value); err != nil {
+			return nil, err
+		}
+		holdings = append(holdings, holding{class: "Real estate", value: value.Mul(float64(share) / 100)})
+	}
+
+	return holdings, propertyRows.Err()
+}
@@ -0,0 +1,86 @@
+package allocation
+
+import (
+	"strings"
+	"testing"
+
+	"financialApp/money"
+)
+
+func TestSplit(t *testing.T) {
+
+	holdings := []holding{
+		{code: "IE00B4L5Y983", value: money.MustParse("1000")},     // world fund
+		{code: "FR0000120271", value: money.MustParse("500")},      // single stock
+		{code: "FR0000000000", value: money.MustParse("250")},      // no allocation
+		{class: "Cash", value: money.MustParse("250")},             // savings book
+		{class: "Cash", value: money.MustParse("-100")},            // overdraft, not an asset
+		{class: "Real estate", value: money.MustParse("2000.005")}, // owned share
+	}
+	allocations := map[string][]Allocation{
+		"IE00B4L5Y983": {
+			{Dimension: "asset_class", Name: "Equity", Weight: 1},
+			{Dimension: "region", Name: "North America", Weight: 0.7},
+			{Dimension: "region", Name: "Europe", Weight: 0.2},
+		},
+		"FR0000120271": {
+			{Dimension: "asset_class", Name: "Equity", Weight: 1},
+			{Dimension: "region", Name: "Europe", Weight: 1},
+		},
+	}
+
+	tests := []struct {
+		dimension string
+		want      map[string]string
+	}{
+		{dimension: "asset_class", want: map[string]string{"Equity": "1500.00", "Real estate": "2000.01", "Unknown": "250.00", "Cash": "250.00"}},
+		// 10% of the world fund is not covered by its weights
+		{dimension: "region", want: map[string]string{"North America": "700.00", "Europe": "700.00", "Unknown": "2600.01"}},
+	}
+
+	for _, tt := range tests {
+		breakdown := split(holdings, allocations, tt.dimension)
+
+		if len(breakdown) != len(tt.want) {
+			t.Fatalf("%s: breakdown = %+v", tt.dimension, breakdown)
+		}
+		var shares float64
+		for _, slice := range breakdown {
+			if got := slice.Value.StringFixed(2); got != tt.want[slice.Name] {
+				t.Errorf("%s: %s = %s, want %s", tt.dimension, slice.Name, got, tt.want[slice.Name])
+			}
+			shares += slice.Share
+		}
+		if shares < 0.999999 || shares > 1.000001 {
+			t.Errorf("%s: shares sum to %v", tt.dimension, shares)
+		}
+	}
+}
+
+func TestParseSecuritiesCsv(t *testing.T) {
+
+	securities, err := parseSecuritiesCsv(strings.NewReader("code,dimension,name,weight\nIE00B4L5Y983,asset_class,Equity\nIE00B4L5Y983,region,North America,0.7\nFR0000120271,sector,Energy,1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(securities) != 2 || len(securities[0].Allocations) != 2 || securities[0].Allocations[0].Weight != 1 || securities[0].Allocations[1].Weight != 0.7 {
+		t.Errorf("securities = %+v", securities)
+	}
+
+	if _, err := parseSecuritiesCsv(strings.NewReader("IE00B4L5Y983,region,Europe,half\n")); err == nil {
+		t.Error("a wrong weight is accepted")
+	}
+}
+
+func TestValidateAllocations(t *testing.T) {
+
+	if err := validateAllocations([]Allocation{{Dimension: "region", Name: "Europe", Weight: 0.6}, {Dimension: "region", Name: "Asia", Weight: 0.4}}); err != nil {
+		t.Error(err)
+	}
+	if err := validateAllocations([]Allocation{{Dimension: "region", Name: "Europe", Weight: 0.6}, {Dimension: "region", Name: "Asia", Weight: 0.5}}); err == nil {
+		t.Error("weights over 1 are accepted")
+	}
+	if err := validateAllocations([]Allocation{{Dimension: "currency", Name: "EUR", Weight: 1}}); err == nil {
+		t.Error("an unknown dimension is accepted")
+	}
+}
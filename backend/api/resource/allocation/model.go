@@ -0,0 +1,69 @@
+package allocation
+
+import "financialApp/money"
+
+// Dimensions along which wealth can be broken down
+var dimensions = []string{"asset_class", "region", "sector"}
+
+// Share of a security in a bucket of a dimension. A stock is 100% in a single region and sector,
+// a fund is split with its look-through weights. Ex: {region, North America, 0.7}
+type Allocation struct {
+	Dimension string  `json:"dimension"` // asset_class, region or sector
+	Name      string  `json:"name"`      // Ex: Equity, Europe, Technology
+	Weight    float64 `json:"weight"`    // between 0 and 1
+}
+
+// Allocations of a security, keyed by the ISIN of the invests (invest_code)
+type Security struct {
+	Code        string       `json:"code"`
+	Allocations []Allocation `json:"allocations"`
+}
+
+// Part of the wealth in a bucket
+type Slice struct {
+	Name  string       `json:"name"`
+	Value money.Amount `json:"value"` // in the base currency
+	Share float64      `json:"share"` // ratio of the total, 0.25 for 25%
+}
+
+// Something owned: an invest, the cash of an account, a crypto wallet or a property
+type holding struct {
+	code  string       // ISIN of an invest, empty otherwise
+	class string       // asset class used when the security has no allocation
+	value money.Amount // in the base currency
+}
+
+// Scopes in which target weights are set: the invests grouped by ISIN, by asset class or by type of their account
+var scopes = []string{"position", "asset_class", "account_type"}
+
+// Weight wanted for a position (ISIN), an asset class or an account type. The weights of a scope sum to 1
+type Target struct {
+	Key    string  `json:"key"`    // Ex: IE00B4L5Y983, Equity, pea
+	Weight float64 `json:"weight"` // between 0 and 1
+}
+
+// Drift of a bucket and the order bringing it back to its target. Weights are ratios
+type RebalanceLine struct {
+	Key    string       `json:"key"`
+	Name   string       `json:"name"`   // label of the position, the key otherwise
+	Value  money.Amount `json:"value"`  // current value, in the base currency
+	Weight float64      `json:"weight"` // current weight
+	Target float64      `json:"target"` // target weight
+	Drift  float64      `json:"drift"`  // current minus target weight
+	Order  money.Amount `json:"order"`  // amount to buy if positive, to sell if negative
+}
+
+type Rebalance struct {
+	Scope    string          `json:"scope"`
+	Cash     money.Amount    `json:"cash"`     // new cash to invest
+	Buy_only bool            `json:"buy_only"` // only buy with the new cash, never sell
+	Total    money.Amount    `json:"total"`    // invests and new cash
+	Lines    []RebalanceLine `json:"lines"`
+}
+
+// Value of an invest in a rebalancing bucket
+type position struct {
+	key   string
+	name  string
+	value money.Amount
+}
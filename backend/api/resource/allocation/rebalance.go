@@ -0,0 +1,347 @@
+package allocation
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"financialApp/api/resource/fx"
+	"financialApp/config"
+	"financialApp/money"
+)
+
+func GetTargets(w http.ResponseWriter, r *http.Request) {
+
+	scope := r.PathValue("scope")
+	if !slices.Contains(scopes, scope) {
+		http.Error(w, "scope must be position, asset_class or account_type", http.StatusBadRequest)
+		return
+	}
+
+	targets, err := readTargets(scope)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read rebalance targets")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(targets)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal rebalance targets")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Replace the targets of a scope. Weights must sum to 1, an empty list removes the targets
+func UpdateTargets(w http.ResponseWriter, r *http.Request) {
+
+	scope := r.PathValue("scope")
+	if !slices.Contains(scopes, scope) {
+		http.Error(w, "scope must be position, asset_class or account_type", http.StatusBadRequest)
+		return
+	}
+
+	var targets []Target
+	if err := json.NewDecoder(r.Body).Decode(&targets); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateTargets(targets); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dbTx, err := config.DB.Begin()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot begin transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer dbTx.Rollback()
+
+	var query string = "DELETE FROM rebalanceTarget WHERE scope=?"
+	if _, err := dbTx.Exec(query, scope); err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	for _, target := range targets {
+		query = "INSERT INTO rebalanceTarget (scope, target_key, weight) VALUES (?, ?, ?)"
+		if _, err := dbTx.Exec(query, scope, target.Key, target.Weight); err != nil {
+			config.Logger.Error().Err(err).Msg(query)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot commit transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Compute the drift of the invests from the targets of a scope (?scope=position, asset_class or account_type)
+// and the orders to rebalance them. New cash can be added (?cash=1000), and sells avoided (?buy_only=true)
+func GetRebalance(w http.ResponseWriter, r *http.Request) {
+
+	scope := r.URL.Query().Get("scope")
+	if !slices.Contains(scopes, scope) {
+		http.Error(w, "scope must be position, asset_class or account_type", http.StatusBadRequest)
+		return
+	}
+
+	var cash money.Amount
+	if value := r.URL.Query().Get("cash"); value != "" {
+		var err error
+		cash, err = money.Parse(value)
+		if err != nil || cash.Sign() < 0 {
+			http.Error(w, "cash must be a positive amount", http.StatusBadRequest)
+			return
+		}
+	}
+
+	buyOnly := false
+	if value := r.URL.Query().Get("buy_only"); value != "" {
+		var err error
+		buyOnly, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "buy_only must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	targets, err := readTargets(scope)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read rebalance targets")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if len(targets) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	converter, err := fx.LoadConverter()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot load fx rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	positions, err := readPositions(converter, scope)
+	if errors.Is(err, fx.ErrNoRate) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read positions")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	result := rebalance(positions, targets, cash, buyOnly)
+	result.Scope = scope
+
+	jsonBody, err := json.Marshal(result)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal rebalance")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Positions without target are sold, targets without position are bought.
+// In buy only mode, the new cash is shared between the underweight buckets, in proportion to what they miss.
+// A bucket never gets more than it misses: cash left once every gap is filled is spread by target weight
+func rebalance(positions []position, targets []Target, cash money.Amount, buyOnly bool) Rebalance {
+
+	result := Rebalance{Cash: cash, Buy_only: buyOnly}
+
+	// Group the positions and add the targets without position
+	var lines []RebalanceLine
+	indexes := make(map[string]int)
+	for _, position := range positions {
+		index, ok := indexes[position.key]
+		if !ok {
+			index = len(lines)
+			indexes[position.key] = index
+			lines = append(lines, RebalanceLine{Key: position.key, Name: position.name})
+		}
+		lines[index].Value += position.value
+	}
+	for _, target := range targets {
+		index, ok := indexes[target.Key]
+		if !ok {
+			index = len(lines)
+			indexes[target.Key] = index
+			lines = append(lines, RebalanceLine{Key: target.Key, Name: target.Key})
+		}
+		lines[index].Target = target.Weight
+	}
+
+	var invested money.Amount
+	for _, line := range lines {
+		invested += line.Value
+	}
+	result.Total = invested + cash
+
+	var missing money.Amount // sum of what underweight buckets miss
+	for i := range lines {
+		lines[i].Weight = lines[i].Value.Div(invested)
+		lines[i].Drift = lines[i].Weight - lines[i].Target
+		lines[i].Order = result.Total.Mul(lines[i].Target) - lines[i].Value
+		if lines[i].Order.Sign() > 0 {
+			missing += lines[i].Order
+		}
+	}
+
+	if buyOnly {
+		var targetSum float64
+		for _, line := range lines {
+			targetSum += line.Target
+		}
+		remainder := max(cash-missing, 0)
+
+		for i := range lines {
+			gap := max(lines[i].Order, 0)
+			if gap.IsZero() || missing.IsZero() {
+				lines[i].Order = 0
+			} else {
+				lines[i].Order = min(cash.Mul(gap.Div(missing)), gap)
+			}
+			if remainder.Sign() > 0 && targetSum > 0 {
+				lines[i].Order += remainder.Mul(lines[i].Target / targetSum)
+			}
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].Target != lines[j].Target {
+			return lines[i].Target > lines[j].Target
+		}
+		return lines[i].Value > lines[j].Value
+	})
+	result.Lines = lines
+
+	return result
+}
+
+func validateTargets(targets []Target) error {
+
+	if len(targets) == 0 {
+		return nil
+	}
+
+	var sum float64
+	var keys []string
+	for _, target := range targets {
+		if strings.TrimSpace(target.Key) == "" {
+			return errors.New("key is required")
+		}
+		if slices.Contains(keys, target.Key) {
+			return errors.New("duplicate key " + target.Key)
+		}
+		keys = append(keys, target.Key)
+		if target.Weight < 0 || target.Weight > 1 {
+			return errors.New("weight must be between 0 and 1")
+		}
+		sum += target.Weight
+	}
+
+	if math.Abs(sum-1) > 1e-6 {
+		return errors.New("weights must sum to 1")
+	}
+
+	return nil
+}
+
+func readTargets(scope string) ([]Target, error) {
+
+	var targets []Target
+
+	var query string = "SELECT target_key, weight FROM rebalanceTarget WHERE scope=? ORDER BY weight DESC"
+	rows, err := config.DB.Query(query, scope)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var target Target
+		if err := rows.Scan(&target.Key, &target.Weight); err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, rows.Err()
+}
+
+// Read the open invests in the base currency, keyed by ISIN, by asset class or by account type
+func readPositions(converter *fx.Converter, scope string) ([]position, error) {
+
+	var positions []position
+	var holdings []holding
+	now := time.Now()
+
+	var query string = "SELECT invest.invest_code, invest.invest_label, invest.valuation, bankAccount.currency, bankAccount.account_type FROM invest INNER JOIN bankAccount ON invest.account_id = bankAccount.account_id WHERE invest.closed_date IS NULL"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var code, label, currency, accountType string
+		var valuation money.Amount
+		if err := rows.Scan(&code, &label, &valuation, &currency, &accountType); err != nil {
+			return nil, err
+		}
+		value, err := converter.ToBase(valuation, currency, now)
+		if err != nil {
+			return nil, err
+		}
+
+		switch scope {
+		case "position":
+			key := code
+			if key == "" {
+				key = label
+			}
+			positions = append(positions, position{key: key, name: label, value: value})
+		case "account_type":
+			positions = append(positions, position{key: accountType, name: accountType, value: value})
+		case "asset_class":
+			holdings = append(holdings, holding{code: code, value: value})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if scope == "asset_class" {
+		allocations, err := readAllocations()
+		if err != nil {
+			return nil, err
+		}
+		for _, slice := range split(holdings, allocations, scope) {
+			positions = append(positions, position{key: slice.Name, name: slice.Name, value: slice.Value})
+		}
+	}
+
+	return positions, nil
+}
@@ -0,0 +1,83 @@
+package allocation
+
+import (
+	"testing"
+
+	"financialApp/money"
+)
+
+func TestRebalance(t *testing.T) {
+
+	positions := []position{
+		{key: "Equity", name: "Equity", value: money.MustParse("7000")},
+		{key: "Bond", name: "Bond", value: money.MustParse("2000")},
+		{key: "Gold", name: "Gold", value: money.MustParse("1000")}, // no target: sold
+	}
+	targets := []Target{
+		{Key: "Equity", Weight: 0.6},
+		{Key: "Bond", Weight: 0.3},
+		{Key: "Cash", Weight: 0.1}, // no position: bought
+	}
+
+	tests := []struct {
+		name    string
+		cash    string
+		buyOnly bool
+		want    map[string]string
+	}{
+		{name: "sell and buy", cash: "0", want: map[string]string{"Equity": "-1000.00", "Bond": "1000.00", "Cash": "1000.00", "Gold": "-1000.00"}},
+		{name: "with new cash", cash: "2000", want: map[string]string{"Equity": "200.00", "Bond": "1600.00", "Cash": "1200.00", "Gold": "-1000.00"}},
+		// 3000 are missing for 2000 of new cash
+		{name: "buy only", cash: "2000", buyOnly: true, want: map[string]string{"Equity": "133.33", "Bond": "1066.67", "Cash": "800.00", "Gold": "0.00"}},
+	}
+
+	for _, tt := range tests {
+		result := rebalance(positions, targets, money.MustParse(tt.cash), tt.buyOnly)
+
+		if len(result.Lines) != 4 {
+			t.Fatalf("%s: lines = %+v", tt.name, result.Lines)
+		}
+		for _, line := range result.Lines {
+			if got := line.Order.StringFixed(2); got != tt.want[line.Key] {
+				t.Errorf("%s: order of %s = %s, want %s", tt.name, line.Key, got, tt.want[line.Key])
+			}
+		}
+	}
+
+	result := rebalance(positions, targets, 0, false)
+	if result.Lines[0].Key != "Equity" || result.Lines[0].Drift < 0.0999 || result.Lines[0].Drift > 0.1001 {
+		t.Errorf("first line = %+v, want Equity with a 10%% drift", result.Lines[0])
+	}
+}
+
+func TestValidateTargets(t *testing.T) {
+
+	if err := validateTargets([]Target{{Key: "pea", Weight: 0.5}, {Key: "market", Weight: 0.5}}); err != nil {
+		t.Error(err)
+	}
+	if err := validateTargets([]Target{{Key: "pea", Weight: 0.5}, {Key: "market", Weight: 0.4}}); err == nil {
+		t.Error("weights not summing to 1 are accepted")
+	}
+	if err := validateTargets([]Target{{Key: "pea", Weight: 0.5}, {Key: "pea", Weight: 0.5}}); err == nil {
+		t.Error("a duplicate key is accepted")
+	}
+}
+
+func TestRebalanceBuyOnlyCapped(t *testing.T) {
+
+	// Nothing is missing for the targets, the new cash is spread by target weight
+	positions := []position{
+		{key: "Equity", name: "Equity", value: money.MustParse("5000")},
+		{key: "Bond", name: "Bond", value: money.MustParse("3000")},
+	}
+	targets := []Target{{Key: "Equity", Weight: 0.5}, {Key: "Bond", Weight: 0.3}}
+
+	result := rebalance(positions, targets, money.MustParse("2000"), true)
+
+	want := map[string]string{"Equity": "1250.00", "Bond": "750.00"}
+	for _, line := range result.Lines {
+		if got := line.Order.StringFixed(2); got != want[line.Key] {
+			t.Errorf("order of %s = %s, want %s", line.Key, got, want[line.Key])
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"net/http"
+	"time"
 
+	"financialApp/api/resource/fx"
 	"financialApp/config"
 )
 
@@ -26,7 +28,7 @@ func GetAccounts(w http.ResponseWriter, r *http.Request) {
 
 		switch accountType { // https://docs.powens.com/api-reference/products/data-aggregation/bank-account-types#accounttypename-values
 		case "article83", "capitalisation", "card", "checking",
-			"crowdlending", "deposit", "ldds", "lifeinsurance",
+			"crowdlending", "crypto", "deposit", "ldds", "lifeinsurance",
 			"loan", "madelin", "market", "pea", "pee", "per",
 			"perco", "perp", "real_estate", "rsp", "savings", "unknown":
 
@@ -37,7 +39,7 @@ func GetAccounts(w http.ResponseWriter, r *http.Request) {
 			config.Logger.Warn().Str("type", accountType).Msg("Unsupported Powens account type")
 			http.Error(w,
 				"Unsupported account type. Must be: article83, capitalisation, card, checking,"+
-					"crowdlending, deposit, ldds, lifeinsurance,"+
+					"crowdlending, crypto, deposit, ldds, lifeinsurance,"+
 					"loan, madelin, market, pea, pee, per,"+
 					"perco, perp, real_estate, rsp, savings, unknown",
 				http.StatusBadRequest)
@@ -67,6 +69,22 @@ func GetAccounts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	converter, err := fx.LoadConverter()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot load fx rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	// Balances stay in the account currency, the converted value is given alongside
+	for i := range accounts {
+		accounts[i].Balance_base, err = converter.ToBase(accounts[i].Balance, accounts[i].Currency, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+	}
+
 	jsonBody, err := json.Marshal(accounts)
 	if err != nil {
 		config.Logger.Error().Err(err).Msg("Cannot marshal accounts")
@@ -84,7 +102,7 @@ func GetAccountSum(w http.ResponseWriter, r *http.Request) {
 	var rows *sql.Rows
 	var err error
 
-	query := "SELECT account_type, SUM(balance) FROM bankAccount GROUP BY account_type"
+	query := "SELECT account_type, currency, SUM(balance) FROM bankAccount GROUP BY account_type, currency"
 	rows, err = config.DB.Query(query)
 
 	if err != nil {
@@ -94,13 +112,28 @@ func GetAccountSum(w http.ResponseWriter, r *http.Request) {
 	}
 	defer rows.Close()
 
+	converter, err := fx.LoadConverter()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot load fx rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
 	for rows.Next() {
 		var account BankAccountSum
-		if err := rows.Scan(&account.Account_type, &account.Value); err != nil {
+		var currency string
+		if err := rows.Scan(&account.Account_type, &currency, &account.Value); err != nil {
 			config.Logger.Error().Err(err).Msg("Cannot scan row")
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
+
+		// Sum in the base currency
+		account.Value, err = converter.ToBase(account.Value, currency, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		accountSums = append(accountSums, account)
 	}
 	if err := rows.Err(); err != nil {
@@ -111,23 +144,21 @@ func GetAccountSum(w http.ResponseWriter, r *http.Request) {
 
 	// Some types are identical for market, group them
 	groupedAccountSums := []BankAccountSum{
-		{Account_type: "Stocks and funds"},
-		{Account_type: "Bank accounts"},
-		{Account_type: "Savings books"},
+		{Account_type: GroupStocks, Currency: converter.Base()},
+		{Account_type: GroupBank, Currency: converter.Base()},
+		{Account_type: GroupSavings, Currency: converter.Base()},
+		{Account_type: GroupCrypto, Currency: converter.Base()},
 	}
 
 	config.Logger.Trace().Msg("Grouping sums")
 
 	for _, accountSum := range accountSums {
-		config.Logger.Trace().Str("type", accountSum.Account_type).Float32("value", accountSum.Value).Msg("")
-
-		switch accountSum.Account_type {
-		case "article83", "capitalisation", "crowdlending", "lifeinsurance", "madelin", "market", "pea", "pee", "per", "perco", "perp", "rsp":
-			groupedAccountSums[0].Value += accountSum.Value
-		case "checking":
-			groupedAccountSums[1].Value += accountSum.Value
-		case "savings":
-			groupedAccountSums[2].Value += accountSum.Value
+		config.Logger.Trace().Str("type", accountSum.Account_type).Stringer("value", accountSum.Value).Msg("")
+
+		for i := range groupedAccountSums {
+			if groupedAccountSums[i].Account_type == AccountGroup(accountSum.Account_type) {
+				groupedAccountSums[i].Value += accountSum.Value
+			}
 		}
 	}
 
@@ -139,3 +170,19 @@ func GetAccountSum(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Write(jsonBody)
 }
+
+// Group of assets of an account type. Empty for liabilities (loan, card) and unknown types
+func AccountGroup(accountType string) string {
+
+	switch accountType {
+	case "article83", "capitalisation", "crowdlending", "lifeinsurance", "madelin", "market", "pea", "pee", "per", "perco", "perp", "rsp":
+		return GroupStocks
+	case "checking":
+		return GroupBank
+	case "savings":
+		return GroupSavings
+	case "crypto":
+		return GroupCrypto
+	}
+	return ""
+}
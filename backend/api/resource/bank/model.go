@@ -1,6 +1,8 @@
 package bank
 
 import (
+	"financialApp/money"
+
 	"financialApp/api/resource/investment"
 	"financialApp/api/resource/loan"
 	"financialApp/api/resource/miscellaneous"
@@ -15,23 +17,33 @@ import (
 
 // https://docs.powens.com/api-reference/products/data-aggregation/bank-accounts#bankaccount-object
 type BankAccount struct {
-	Account_id         int     `json:"id"`
-	User_id            int     `json:"id_user"`
-	Number             string  `json:"number"`
-	Bank_Original_name string  `json:"bank_original_name"`
-	Original_name      string  `json:"original_name"`
-	Balance            float32 `json:"balance"`
-	Last_update        string  `json:"last_update"`
-	Iban               string  `json:"iban"`
-	Currency           string  `json:"currency"`
-	Account_type       string  `json:"type"`
-	Error              string  `json:"error"` // not needed ?
-	Usage              string  `json:"usage"`
+	Account_id         int          `json:"id"`
+	User_id            int          `json:"id_user"`
+	Number             string       `json:"number"`
+	Bank_Original_name string       `json:"bank_original_name"`
+	Original_name      string       `json:"original_name"`
+	Balance            money.Amount `json:"balance"`
+	Last_update        string       `json:"last_update"`
+	Iban               string       `json:"iban"`
+	Currency           string       `json:"currency"`
+	Account_type       string       `json:"type"`
+	Error              string       `json:"error"` // not needed ?
+	Usage              string       `json:"usage"`
+	Balance_base       money.Amount `json:"balance_base"` // not present in base data: balance converted to the base currency
 }
 
+// Groups in which the accounts are summed
+const (
+	GroupStocks  = "Stocks and funds"
+	GroupBank    = "Bank accounts"
+	GroupSavings = "Savings books"
+	GroupCrypto  = "Crypto"
+)
+
 type BankAccountSum struct {
-	Account_type string  `json:"type"`
-	Value        float32 `json:"value"`
+	Account_type string       `json:"type"`
+	Value        money.Amount `json:"value"`
+	Currency     string       `json:"currency"` // base currency
 }
 
 type BankAccountWebhook struct {
@@ -39,7 +51,7 @@ type BankAccountWebhook struct {
 	User_id       int                       `json:"id_user"`
 	Number        string                    `json:"number"`
 	Original_name string                    `json:"original_name"`
-	Balance       float32                   `json:"balance"`
+	Balance       money.Amount              `json:"balance"`
 	Last_update   string                    `json:"last_update"`
 	Iban          string                    `json:"iban"`
 	Currency      miscellaneous.Currency    `json:"currency"`
@@ -0,0 +1,345 @@
+package crypto
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"financialApp/api/resource/manual"
+	"financialApp/config"
+)
+
+func CreateWallet(w http.ResponseWriter, r *http.Request) {
+
+	var wallet Wallet
+	err := json.NewDecoder(r.Body).Decode(&wallet)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if wallet.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if wallet.Platform == "" {
+		wallet.Platform = "Crypto"
+	}
+
+	dbTx, err := config.DB.Begin()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot begin transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer dbTx.Rollback()
+
+	wallet.Account_id, err = manual.NextId(dbTx, "SELECT account_id FROM bankAccount ORDER BY account_id LIMIT 1 FOR UPDATE")
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get next wallet id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	wallet.Balance = 0
+
+	var query string = "INSERT INTO bankAccount (account_id, user_id, bank_original_name, bank_number, original_name, balance, last_update, iban, currency, account_type, usage_type) VALUES (?, 0, ?, '', ?, 0, ?, '', ?, ?, '')"
+	_, err = dbTx.Exec(query, wallet.Account_id, wallet.Platform, wallet.Name, time.Now().Format("2006-01-02 15:04:05"), config.Conf.Crypto.Currency, accountType)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot commit transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(wallet)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal wallet")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+func GetWallets(w http.ResponseWriter, r *http.Request) {
+
+	wallets, err := readWallets()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read wallets")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(wallets)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal wallets")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+func DeleteWallet(w http.ResponseWriter, r *http.Request) {
+
+	accountId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	if !isWallet(accountId) {
+		http.Error(w, "Wallet does not exist", http.StatusNotFound)
+		return
+	}
+
+	// Everything is deleted, or nothing if a query fails
+	dbTx, err := config.DB.Begin()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot begin transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer dbTx.Rollback()
+
+	// Order matters because of foreign keys
+	for _, query := range []string{
+		"DELETE FROM cryptoOperation WHERE account_id=?",
+		"DELETE FROM historyValue WHERE bank_account_id=?",
+		"DELETE FROM bankAccount WHERE account_id=?",
+	} {
+		if _, err := dbTx.Exec(query, accountId); err != nil {
+			config.Logger.Error().Err(err).Msg(query)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot commit transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Add a buy or a sell to a wallet, and value the wallets again
+func CreateOperation(w http.ResponseWriter, r *http.Request) {
+
+	accountId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	if !isWallet(accountId) {
+		http.Error(w, "Wallet does not exist", http.StatusNotFound)
+		return
+	}
+
+	var operation Operation
+	if err := json.NewDecoder(r.Body).Decode(&operation); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	operation.Account_id = accountId
+
+	if err := validateOperation(&operation); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Cannot sell more than what is held
+	if operation.Operation_type == "sell" {
+		operations, err := readOperations(accountId)
+		if err != nil {
+			config.Logger.Error().Err(err).Msg("Cannot read crypto operations")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+
+		var held float64
+		for _, position := range computePositions(operations) {
+			if position.Symbol == operation.Symbol {
+				held = position.Quantity
+			}
+		}
+		if operation.Quantity > held+quantityEpsilon {
+			http.Error(w, "Cannot sell more than the quantity held", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var query string = "INSERT INTO cryptoOperation (account_id, symbol, operation_type, quantity, unit_price, fees, operation_date) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	result, err := config.DB.Exec(query, operation.Account_id, operation.Symbol, operation.Operation_type, operation.Quantity, operation.Unit_price, operation.Fees, operation.Date)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get operation id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	operation.Operation_id = int(id)
+
+	if err := Snapshot(r.Context()); err != nil {
+		config.Logger.Error().Err(err).Msg("Crypto snapshot failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(operation)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal operation")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+// Get the operations of every wallet, or of a single one with ?wallet={id}
+func GetOperations(w http.ResponseWriter, r *http.Request) {
+
+	var accountId int
+	if wallet := r.URL.Query().Get("wallet"); wallet != "" {
+		var err error
+		accountId, err = strconv.Atoi(wallet)
+		if err != nil {
+			http.Error(w, "Wrong wallet id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	operations, err := readOperations(accountId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read crypto operations")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(operations)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal operations")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Get the current positions of every wallet, valued with the current prices
+func GetPositions(w http.ResponseWriter, r *http.Request) {
+
+	positions, err := readPositions(r.Context())
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read crypto positions")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(positions)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal positions")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+func validateOperation(operation *Operation) error {
+
+	operation.Symbol = strings.ToUpper(strings.TrimSpace(operation.Symbol))
+	if operation.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+
+	if operation.Operation_type != "buy" && operation.Operation_type != "sell" {
+		return errors.New("type must be buy or sell")
+	}
+
+	if operation.Quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+	if operation.Unit_price < 0 || operation.Fees < 0 {
+		return errors.New("unit price and fees cannot be negative")
+	}
+
+	if operation.Date == "" {
+		operation.Date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", operation.Date); err != nil {
+		return errors.New("wrong date, must be YYYY-MM-DD")
+	}
+
+	return nil
+}
+
+func readWallets() ([]Wallet, error) {
+
+	var wallets []Wallet
+
+	var query string = "SELECT account_id, bank_original_name, original_name, balance FROM bankAccount WHERE account_type=? ORDER BY original_name"
+	rows, err := config.DB.Query(query, accountType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var wallet Wallet
+		if err := rows.Scan(&wallet.Account_id, &wallet.Platform, &wallet.Name, &wallet.Balance); err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	return wallets, rows.Err()
+}
+
+// Read the operations of a wallet, or of every wallet if accountId is 0, sorted by date
+func readOperations(accountId int) ([]Operation, error) {
+
+	var operations []Operation
+
+	var query string = "SELECT operation_id, account_id, symbol, operation_type, quantity, unit_price, fees, operation_date FROM cryptoOperation WHERE ? = 0 OR account_id = ? ORDER BY operation_date, operation_id"
+	rows, err := config.DB.Query(query, accountId, accountId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var operation Operation
+		if err := rows.Scan(&operation.Operation_id, &operation.Account_id, &operation.Symbol, &operation.Operation_type, &operation.Quantity, &operation.Unit_price, &operation.Fees, &operation.Date); err != nil {
+			return nil, err
+		}
+		operations = append(operations, operation)
+	}
+
+	return operations, rows.Err()
+}
+
+func isWallet(accountId int) bool {
+
+	var exists bool
+	var query string = "SELECT EXISTS (SELECT 1 FROM bankAccount WHERE account_id=? AND account_type=?)"
+	if err := config.DB.QueryRow(query, accountId, accountType).Scan(&exists); err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		return false
+	}
+	return exists
+}
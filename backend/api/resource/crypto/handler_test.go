@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"financialApp/money"
+)
+
+func TestComputePositions(t *testing.T) {
+
+	operations := []Operation{
+		{Account_id: -1, Symbol: "BTC", Operation_type: "buy", Quantity: 1, Unit_price: money.MustParse("20000"), Fees: money.MustParse("100")},
+		{Account_id: -1, Symbol: "BTC", Operation_type: "buy", Quantity: 1, Unit_price: money.MustParse("40000"), Fees: money.MustParse("100")},
+		{Account_id: -1, Symbol: "BTC", Operation_type: "sell", Quantity: 0.5, Unit_price: money.MustParse("50000")},
+		{Account_id: -2, Symbol: "ETH", Operation_type: "buy", Quantity: 2, Unit_price: money.MustParse("1000")},
+		{Account_id: -2, Symbol: "ETH", Operation_type: "sell", Quantity: 2, Unit_price: money.MustParse("1500")},
+	}
+
+	positions := computePositions(operations)
+
+	if len(positions) != 1 {
+		t.Fatalf("Wrong number of positions: got %v want 1 (closed positions are removed)", len(positions))
+	}
+	if positions[0].Quantity != 1.5 {
+		t.Errorf("Wrong quantity: got %v want 1.5", positions[0].Quantity)
+	}
+	if positions[0].Unit_price != money.MustParse("30100") {
+		t.Errorf("Wrong average cost: got %v want 30100", positions[0].Unit_price)
+	}
+}
+
+func TestValuePositions(t *testing.T) {
+
+	positions := []Position{
+		{Symbol: "BTC", Quantity: 2, Unit_price: money.MustParse("30000")},
+		{Symbol: "UNKNOWN", Quantity: 10, Unit_price: money.MustParse("1")},
+	}
+
+	prices, err := StaticProvider{"BTC": 45000}.Prices(context.Background(), []string{"BTC", "UNKNOWN"}, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	valuePositions(positions, prices)
+
+	if positions[0].Valuation != money.MustParse("90000") || positions[0].Diff != money.MustParse("30000") || math.Abs(positions[0].Diff_percent-0.5) > 1e-9 {
+		t.Errorf("Wrong valued position: %+v", positions[0])
+	}
+
+	// Without price, the position is valued at its cost
+	if positions[1].Valuation != money.MustParse("10") || positions[1].Diff != 0 {
+		t.Errorf("Wrong position without price: %+v", positions[1])
+	}
+}
@@ -0,0 +1,40 @@
+package crypto
+
+import "financialApp/money"
+
+// A wallet is stored in bankAccount with the "crypto" type and a negative id, like manual accounts.
+// Its balance and its history are updated by the snapshots, so wallets are taken into account everywhere
+
+const accountType = "crypto"
+
+type Wallet struct {
+	Account_id int          `json:"id"`
+	Platform   string       `json:"bank_original_name"` // exchange or wallet software
+	Name       string       `json:"original_name"`
+	Balance    money.Amount `json:"balance"`
+}
+
+type Operation struct {
+	Operation_id   int          `json:"id"`
+	Account_id     int          `json:"id_account"`
+	Symbol         string       `json:"symbol"` // BTC, ETH...
+	Operation_type string       `json:"type"`   // buy or sell
+	Quantity       float64      `json:"quantity"`
+	Unit_price     money.Amount `json:"unitprice"`
+	Fees           money.Amount `json:"fees"`
+	Date           string       `json:"date"` // YYYY-MM-DD
+}
+
+// Holding of one asset in one wallet, computed from the operations
+type Position struct {
+	Account_id   int          `json:"id_account"`
+	Platform     string       `json:"bank_original_name"`
+	Name         string       `json:"original_name"`
+	Symbol       string       `json:"symbol"`
+	Quantity     float64      `json:"quantity"`
+	Unit_price   money.Amount `json:"unitprice"` // average cost, fees included
+	Unit_value   money.Amount `json:"unitvalue"` // current price, given by the price provider
+	Valuation    money.Amount `json:"valuation"`
+	Diff         money.Amount `json:"diff"`
+	Diff_percent float64      `json:"diff_percent"`
+}
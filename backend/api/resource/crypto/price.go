@@ -0,0 +1,102 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"financialApp/config"
+)
+
+// Give the current price of crypto assets, by symbol
+type PriceProvider interface {
+	// Return the price of every known symbol in the given currency. Unknown symbols are absent from the map
+	Prices(ctx context.Context, symbols []string, currency string) (map[string]float64, error)
+}
+
+// Provider used by the handlers and the snapshots, set by Init
+var provider PriceProvider
+
+// Create the price provider set in CRYPTO_PRICE_PROVIDER. Return nil if crypto are valued at their cost
+func NewPriceProvider(conf config.ConfCrypto) (PriceProvider, error) {
+
+	switch conf.PriceProvider {
+	case "none":
+		return nil, nil
+	case "coingecko":
+		return &CoinGeckoProvider{
+			BaseUrl: "https://api.coingecko.com/api/v3",
+			Client:  &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	case "static":
+		return StaticProvider(conf.StaticPrices), nil
+	default:
+		return nil, fmt.Errorf("unsupported crypto price provider '%s'. Should be none, coingecko or static", conf.PriceProvider)
+	}
+}
+
+// Fixed prices, whatever the currency. Useful for tests or to run without internet access
+type StaticProvider map[string]float64
+
+func (p StaticProvider) Prices(ctx context.Context, symbols []string, currency string) (map[string]float64, error) {
+
+	prices := make(map[string]float64)
+	for _, symbol := range symbols {
+		if price, ok := p[strings.ToUpper(symbol)]; ok {
+			prices[symbol] = price
+		}
+	}
+	return prices, nil
+}
+
+// Prices from the public CoinGecko API
+// https://docs.coingecko.com/reference/simple-price
+type CoinGeckoProvider struct {
+	BaseUrl string
+	Client  *http.Client
+}
+
+func (p *CoinGeckoProvider) Prices(ctx context.Context, symbols []string, currency string) (map[string]float64, error) {
+
+	if len(symbols) == 0 {
+		return map[string]float64{}, nil
+	}
+
+	params := url.Values{}
+	params.Set("symbols", strings.ToLower(strings.Join(symbols, ",")))
+	params.Set("vs_currencies", strings.ToLower(currency))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.BaseUrl+"/simple/price?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("CoinGecko returned " + resp.Status)
+	}
+
+	// Ex: {"btc":{"eur":60000}}
+	var body map[string]map[string]float64
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64)
+	for _, symbol := range symbols {
+		if price, ok := body[strings.ToLower(symbol)][strings.ToLower(currency)]; ok {
+			prices[symbol] = price
+		}
+	}
+	return prices, nil
+}
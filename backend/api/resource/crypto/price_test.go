@@ -0,0 +1,33 @@
+package crypto
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCoinGeckoProvider(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("symbols"); got != "btc,eth,doge" {
+			t.Errorf("Wrong symbols: got %v want btc,eth,doge", got)
+		}
+		w.Write([]byte(`{"btc":{"eur":60000},"eth":{"eur":3000.5}}`))
+	}))
+	defer server.Close()
+
+	provider := &CoinGeckoProvider{BaseUrl: server.URL, Client: server.Client()}
+
+	prices, err := provider.Prices(context.Background(), []string{"BTC", "ETH", "DOGE"}, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if prices["BTC"] != 60000 || prices["ETH"] != 3000.5 {
+		t.Errorf("Wrong prices: %v", prices)
+	}
+	if _, ok := prices["DOGE"]; ok {
+		t.Errorf("Unknown symbols must be absent: %v", prices)
+	}
+}
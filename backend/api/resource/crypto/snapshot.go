@@ -0,0 +1,211 @@
+package crypto
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"financialApp/api/resource/investment"
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Quantities below this threshold are considered as zero, to avoid keeping float rounding leftovers as positions
+const quantityEpsilon = 1e-9
+
+// Create the price provider and start valuing wallets every CRYPTO_SNAPSHOT_INTERVAL
+func Init() {
+
+	var err error
+	provider, err = NewPriceProvider(config.Conf.Crypto)
+	if err != nil {
+		config.Logger.Fatal().Err(err).Msg("Cannot create crypto price provider")
+	}
+
+	go func() {
+		ticker := time.NewTicker(config.Conf.Crypto.SnapshotInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := Snapshot(context.Background()); err != nil {
+				config.Logger.Error().Err(err).Msg("Crypto snapshot failed")
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Value every wallet with the current prices: update its balance and write today's point in historyValue.
+// Running it several times a day only keeps the last value of the day
+func Snapshot(ctx context.Context) error {
+
+	wallets, err := readWallets()
+	if err != nil {
+		return err
+	}
+
+	positions, err := readPositions(ctx)
+	if err != nil {
+		return err
+	}
+
+	balances := make(map[int]money.Amount)
+	for _, position := range positions {
+		balances[position.Account_id] += position.Valuation
+	}
+
+	today := time.Now().Format("2006-01-02")
+	now := time.Now().Format("2006-01-02 15:04:05")
+
+	dbTx, err := config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+
+	for _, wallet := range wallets {
+		balance := balances[wallet.Account_id]
+
+		historyValue := investment.HistoryValue{BankAccountId: wallet.Account_id, Valuation: balance, DateValuation: today}
+		if err := investment.RecordBalances(dbTx, []investment.HistoryValue{historyValue}); err != nil {
+			return err
+		}
+		if _, err := dbTx.Exec("UPDATE bankAccount SET balance=?, last_update=? WHERE account_id=?", balance, now, wallet.Account_id); err != nil {
+			return err
+		}
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return err
+	}
+
+	config.Logger.Info().Int("wallets", len(wallets)).Msg("Crypto wallets valued")
+	return nil
+}
+
+// Compute the positions from every operation, and value them with the current prices
+func readPositions(ctx context.Context) ([]Position, error) {
+
+	wallets, err := readWallets()
+	if err != nil {
+		return nil, err
+	}
+
+	operations, err := readOperations(0)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := computePositions(operations)
+
+	for i := range positions {
+		for _, wallet := range wallets {
+			if wallet.Account_id == positions[i].Account_id {
+				positions[i].Platform = wallet.Platform
+				positions[i].Name = wallet.Name
+			}
+		}
+	}
+
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, position := range positions {
+		if !seen[position.Symbol] {
+			seen[position.Symbol] = true
+			symbols = append(symbols, position.Symbol)
+		}
+	}
+
+	// Without price provider, positions are valued at their cost
+	var prices map[string]float64
+	if provider != nil {
+		prices, err = provider.Prices(ctx, symbols, config.Conf.Crypto.Currency)
+		if err != nil {
+			// Positions are still returned, valued at their cost
+			config.Logger.Error().Err(err).Msg("Cannot get crypto prices")
+			prices = map[string]float64{}
+		}
+	}
+
+	valuePositions(positions, prices)
+
+	return positions, nil
+}
+
+// Build the positions of every wallet from its operations, sorted by date.
+// The unit price is the weighted average cost: a buy adds its price and fees to the cost,
+// a sell removes its quantity at the average cost and does not change the unit price
+func computePositions(operations []Operation) []Position {
+
+	type key struct {
+		accountId int
+		symbol    string
+	}
+
+	var positions []Position
+	indexes := make(map[key]int)
+	costs := make(map[key]money.Amount)
+
+	for _, operation := range operations {
+		k := key{operation.Account_id, operation.Symbol}
+
+		index, ok := indexes[k]
+		if !ok {
+			positions = append(positions, Position{Account_id: operation.Account_id, Symbol: operation.Symbol})
+			index = len(positions) - 1
+			indexes[k] = index
+		}
+		position := &positions[index]
+
+		switch operation.Operation_type {
+		case "buy":
+			costs[k] += operation.Unit_price.Mul(operation.Quantity) + operation.Fees
+			position.Quantity += operation.Quantity
+		case "sell":
+			if position.Quantity > 0 {
+				costs[k] -= costs[k].Mul(operation.Quantity / position.Quantity)
+			}
+			position.Quantity -= operation.Quantity
+		}
+
+		if math.Abs(position.Quantity) < quantityEpsilon {
+			position.Quantity = 0
+			costs[k] = 0
+		}
+	}
+
+	// Closed positions are not displayed
+	var openPositions []Position
+	for i, position := range positions {
+		if position.Quantity == 0 {
+			continue
+		}
+		position.Unit_price = costs[key{positions[i].Account_id, positions[i].Symbol}].Mul(1 / position.Quantity)
+		openPositions = append(openPositions, position)
+	}
+
+	return openPositions
+}
+
+// Fill the current value of each position. Positions without a known price are valued at their cost.
+// Diff percent is a ratio, like the one of the invests
+func valuePositions(positions []Position, prices map[string]float64) {
+
+	for i := range positions {
+		position := &positions[i]
+
+		price := position.Unit_price
+		if providedPrice, ok := prices[position.Symbol]; ok {
+			price = money.FromFloat(providedPrice)
+		} else if prices != nil {
+			config.Logger.Warn().Str("symbol", position.Symbol).Msg("No price for crypto, valued at its cost")
+		}
+
+		position.Unit_value = price
+		position.Valuation = price.Mul(position.Quantity)
+		position.Diff = position.Valuation - position.Unit_price.Mul(position.Quantity)
+		if !position.Unit_price.IsZero() {
+			position.Diff_percent = price.Div(position.Unit_price) - 1
+		}
+	}
+}
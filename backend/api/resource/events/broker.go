@@ -0,0 +1,96 @@
+package events
+
+import (
+	"sync"
+
+	"financialApp/config"
+)
+
+// Events kept for a client which has not read them yet. A client lagging further behind is disconnected,
+// and reloads everything when it comes back
+const clientBuffer = 32
+
+// Broker sends every published event to the connected clients
+type Broker struct {
+	mu      sync.Mutex
+	clients map[chan Event]struct{}
+	lastId  int
+	closed  bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{clients: make(map[chan Event]struct{})}
+}
+
+// Broker of the /events stream
+var broker = NewBroker()
+
+// Register a client. The channel is closed when the broker is closed or when the client is too slow,
+// the returned function unregisters the client
+func (b *Broker) Subscribe() (<-chan Event, func()) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	client := make(chan Event, clientBuffer)
+	if b.closed {
+		close(client)
+		return client, func() {}
+	}
+	b.clients[client] = struct{}{}
+
+	return client, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.clients[client]; ok {
+			delete(b.clients, client)
+			close(client)
+		}
+	}
+}
+
+// Send an event to every client, without waiting for any of them
+func (b *Broker) Publish(eventType string, data any) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.lastId++
+	event := Event{Id: b.lastId, Type: eventType, Data: data}
+
+	for client := range b.clients {
+		select {
+		case client <- event:
+		default:
+			config.Logger.Warn().Int("event_id", event.Id).Msg("Event stream client too slow, disconnected")
+			delete(b.clients, client)
+			close(client)
+		}
+	}
+}
+
+// Disconnect every client and refuse the new ones
+func (b *Broker) Close() {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for client := range b.clients {
+		delete(b.clients, client)
+		close(client)
+	}
+}
+
+// Send an event to the clients of the /events stream
+func Publish(eventType string, data any) {
+	broker.Publish(eventType, data)
+}
+
+// End the /events streams, which would otherwise keep the server from shutting down
+func Close() {
+	broker.Close()
+}
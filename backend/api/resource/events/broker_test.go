@@ -0,0 +1,69 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestBroker(t *testing.T) {
+
+	broker := NewBroker()
+
+	first, unsubscribeFirst := broker.Subscribe()
+	second, unsubscribeSecond := broker.Subscribe()
+	defer unsubscribeSecond()
+
+	broker.Publish(TypeAccountChanged, AccountChanged{Account_id: 3, Action: ActionUpdated})
+
+	for _, client := range []<-chan Event{first, second} {
+		event := <-client
+		if event.Id != 1 || event.Type != TypeAccountChanged || event.Data.(AccountChanged).Account_id != 3 {
+			t.Errorf("Wrong event: %+v", event)
+		}
+	}
+
+	// An unsubscribed client receives nothing more
+	unsubscribeFirst()
+	unsubscribeFirst()
+	broker.Publish(TypeSyncCompleted, SyncCompleted{Connection_id: 1})
+	if _, ok := <-first; ok {
+		t.Error("Expected a closed channel")
+	}
+	if event := <-second; event.Id != 2 {
+		t.Errorf("Wrong event: %+v", event)
+	}
+}
+
+func TestBrokerSlowClient(t *testing.T) {
+
+	broker := NewBroker()
+	client, unsubscribe := broker.Subscribe()
+	defer unsubscribe()
+
+	// Publishing never blocks: a client which does not read is dropped once its buffer is full
+	for range clientBuffer + 1 {
+		broker.Publish(TypeTransactionChanged, TransactionChanged{})
+	}
+
+	received := 0
+	for range client {
+		received++
+	}
+	if received != clientBuffer {
+		t.Errorf("Got %d events, want %d", received, clientBuffer)
+	}
+}
+
+func TestBrokerClose(t *testing.T) {
+
+	broker := NewBroker()
+	before, _ := broker.Subscribe()
+	broker.Close()
+
+	if _, ok := <-before; ok {
+		t.Error("Expected the clients to be disconnected")
+	}
+	if after, _ := broker.Subscribe(); func() bool { _, ok := <-after; return ok }() {
+		t.Error("Expected new clients to be refused")
+	}
+	broker.Publish(TypeSyncCompleted, nil)
+}
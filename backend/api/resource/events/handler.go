@@ -0,0 +1,82 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"financialApp/config"
+)
+
+// Comment sent when nothing happens, so that proxies and clients do not drop an idle stream
+const heartbeatInterval = 30 * time.Second
+
+// Wait asked to the clients before reconnecting
+const retryDelay = 5 * time.Second
+
+// Server-Sent Events stream of the changes: sync_completed, transaction_changed and account_changed.
+// See https://html.spec.whatwg.org/multipage/server-sent-events.html
+func Stream(w http.ResponseWriter, r *http.Request) {
+
+	controller := http.NewResponseController(w)
+
+	// The stream lasts longer than the write timeout of the server
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot remove write deadline")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := broker.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", retryDelay.Milliseconds())
+	if err := controller.Flush(); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot flush event stream")
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			err = writeEvent(w, event)
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		}
+		if err == nil {
+			err = controller.Flush()
+		}
+		if err != nil {
+			config.Logger.Debug().Err(err).Msg("Event stream closed")
+			return
+		}
+	}
+}
+
+// Write an event in the SSE format: its id, its type, and its data on a single line
+func writeEvent(w io.Writer, event Event) error {
+
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+	return err
+}
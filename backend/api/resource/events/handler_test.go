@@ -0,0 +1,55 @@
+package events
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+
+	broker = NewBroker()
+	defer func() { broker = NewBroker() }()
+
+	server := httptest.NewServer(http.HandlerFunc(Stream))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Wrong content type %s", resp.Header.Get("Content-Type"))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	readFrame := func() string {
+		var frame strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line == "\n" {
+				return frame.String()
+			}
+			frame.WriteString(line)
+		}
+	}
+
+	if frame := readFrame(); frame != "retry: 5000\n" {
+		t.Errorf("Wrong first frame %q", frame)
+	}
+
+	// The client is subscribed before the first frame is sent
+	Publish(TypeTransactionChanged, TransactionChanged{Transaction_id: 42, Account_id: 7, Action: ActionDeleted})
+
+	want := "id: 1\nevent: transaction_changed\ndata: {\"transaction_id\":42,\"account_id\":7,\"action\":\"deleted\"}\n"
+	if frame := readFrame(); frame != want {
+		t.Errorf("Got %q, want %q", frame, want)
+	}
+}
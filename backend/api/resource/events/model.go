@@ -0,0 +1,39 @@
+package events
+
+// Types of the events sent on the stream
+const (
+	TypeSyncCompleted      = "sync_completed"
+	TypeTransactionChanged = "transaction_changed"
+	TypeAccountChanged     = "account_changed"
+)
+
+// What happened to a transaction or an account
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+type Event struct {
+	Id   int    `json:"id"`
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// A Powens sync has been processed
+type SyncCompleted struct {
+	Connection_id    int   `json:"connection_id"`
+	Account_ids      []int `json:"account_ids"`
+	New_transactions int   `json:"new_transactions"`
+}
+
+type TransactionChanged struct {
+	Transaction_id int    `json:"transaction_id"`
+	Account_id     int    `json:"account_id"`
+	Action         string `json:"action"`
+}
+
+type AccountChanged struct {
+	Account_id int    `json:"account_id"`
+	Action     string `json:"action"`
+}
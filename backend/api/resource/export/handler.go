@@ -0,0 +1,183 @@
+package export
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"financialApp/api/resource/loan"
+	"financialApp/config"
+)
+
+// Export bank accounts, txs, invest positions and loan balances as a plain-text accounting journal
+func GetJournal(w http.ResponseWriter, r *http.Request) {
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatLedger
+	}
+
+	var extension string
+	switch format {
+	case formatLedger:
+		extension = "ledger"
+	case formatHledger:
+		extension = "journal"
+	case formatBeancount:
+		extension = "beancount"
+	default:
+		config.Logger.Warn().Str("format", format).Msg("Unsupported journal format")
+		http.Error(w, "Unsupported format. Must be: ledger, hledger, beancount", http.StatusBadRequest)
+		return
+	}
+
+	mapping, err := loadMapping()
+	if err != nil {
+		config.Logger.Error().Err(err).Str("file", config.Conf.Export.MappingFile).Msg("Cannot load export mapping file")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := readJournalData()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read journal data")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=freenahi."+extension)
+
+	if err := WriteJournal(w, format, data, mapping); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot write journal")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Load the mapping from the file set in EXPORT_MAPPING_FILE, or use the default one.
+// The file is read on each export so it can be edited without restarting the server
+func loadMapping() (Mapping, error) {
+
+	if config.Conf.Export.MappingFile == "" {
+		return DefaultMapping(), nil
+	}
+
+	file, err := os.ReadFile(config.Conf.Export.MappingFile)
+	if err != nil {
+		return Mapping{}, err
+	}
+
+	var mapping Mapping
+	if err := json.Unmarshal(file, &mapping); err != nil {
+		return Mapping{}, err
+	}
+
+	return mapping.withDefaults(), nil
+}
+
+// Read every data needed for the journal from DB
+func readJournalData() (JournalData, error) {
+
+	var data JournalData
+
+	query := "SELECT account_id, bank_original_name, original_name, currency, account_type, balance, last_update FROM bankAccount ORDER BY account_id"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return data, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var account Account
+		if err := rows.Scan(&account.Id, &account.BankName, &account.Name, &account.Currency, &account.Account_type, &account.Balance, &account.Last_update); err != nil {
+			return data, err
+		}
+		data.Accounts = append(data.Accounts, account)
+	}
+	if err := rows.Err(); err != nil {
+		return data, err
+	}
+
+	query = "SELECT tx_id, account_id, tx_date, tx_value, tx_type, original_wording FROM tx ORDER BY tx_date"
+	txRows, err := config.DB.Query(query)
+	if err != nil {
+		return data, err
+	}
+	defer txRows.Close()
+
+	for txRows.Next() {
+		var tx Transaction
+		var date string
+		if err := txRows.Scan(&tx.Id, &tx.Account_id, &date, &tx.Value, &tx.Transaction_type, &tx.Original_wording); err != nil {
+			return data, err
+		}
+		tx.Date, err = time.Parse("2006-01-02 15:04:05", date)
+		if err != nil {
+			return data, err
+		}
+		data.Transactions = append(data.Transactions, tx)
+	}
+	if err := txRows.Err(); err != nil {
+		return data, err
+	}
+
+	query = "SELECT bank_account_id, valuation, date_valuation FROM historyValue ORDER BY date_valuation"
+	historyRows, err := config.DB.Query(query)
+	if err != nil {
+		return data, err
+	}
+	defer historyRows.Close()
+
+	for historyRows.Next() {
+		var point HistoryPoint
+		var date string
+		if err := historyRows.Scan(&point.Account_id, &point.Valuation, &date); err != nil {
+			return data, err
+		}
+		point.Date, err = time.Parse("2006-01-02", date)
+		if err != nil {
+			return data, err
+		}
+		data.History = append(data.History, point)
+	}
+	if err := historyRows.Err(); err != nil {
+		return data, err
+	}
+
+	query = "SELECT account_id, invest_label, invest_code, stock_symbol, quantity, unit_price, unit_value, last_update FROM invest WHERE closed_date IS NULL"
+	investRows, err := config.DB.Query(query)
+	if err != nil {
+		return data, err
+	}
+	defer investRows.Close()
+
+	for investRows.Next() {
+		var position Position
+		var date string
+		if err := investRows.Scan(&position.Account_id, &position.Label, &position.Code, &position.Stock_symbol, &position.Quantity, &position.Unit_price, &position.Unit_value, &date); err != nil {
+			return data, err
+		}
+
+		// Powens sometimes does not send the last update of an investment
+		position.Date, err = time.Parse("2006-01-02 15:04:05", date)
+		if err != nil {
+			position.Date = time.Now()
+		}
+		data.Positions = append(data.Positions, position)
+	}
+	if err := investRows.Err(); err != nil {
+		return data, err
+	}
+
+	loans, err := loan.ReadLoans()
+	if err != nil {
+		return data, err
+	}
+	for _, l := range loans {
+		data.Loans = append(data.Loans, LoanBalance{Account_id: l.Loan_account_id, Capital: l.OutstandingCapital(), Date: time.Now()})
+	}
+
+	return data, nil
+}
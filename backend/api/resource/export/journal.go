@@ -0,0 +1,481 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"financialApp/money"
+)
+
+// Order of the directives written for the same day.
+// Balance assertions come last so they are checked after the transactions of the day
+const (
+	kindOpening int = iota
+	kindTransaction
+	kindPosition
+	kindPrice
+	kindAssertion
+)
+
+type posting struct {
+	account   string
+	amount    money.Amount
+	quantity  float64 // Quantity of a commodity posting
+	commodity string
+	costPrice money.Amount // Unit cost of a commodity posting, 0 for a currency posting
+	currency  string
+	elided    bool // Amount is left empty and inferred by the accounting software
+}
+
+type directive struct {
+	date      time.Time
+	kind      int
+	payee     string
+	postings  []posting
+	account   string       // Used by assertion
+	amount    money.Amount // Used by price and assertion
+	commodity string       // Used by price
+	currency  string
+}
+
+// Return the mapping used when no mapping file is configured
+func DefaultMapping() Mapping {
+	return Mapping{
+		AccountTypes: map[string]string{
+			// https://docs.powens.com/api-reference/products/data-aggregation/bank-account-types#accounttypename-values
+			"checking":       "Assets:Bank:Checking",
+			"savings":        "Assets:Bank:Savings",
+			"deposit":        "Assets:Bank:Deposit",
+			"ldds":           "Assets:Bank:Savings",
+			"card":           "Liabilities:Card",
+			"loan":           "Liabilities:Loan",
+			"market":         "Assets:Invest:Market",
+			"pea":            "Assets:Invest:PEA",
+			"pee":            "Assets:Invest:PEE",
+			"per":            "Assets:Invest:PER",
+			"perco":          "Assets:Invest:PERCO",
+			"perp":           "Assets:Invest:PERP",
+			"lifeinsurance":  "Assets:Invest:LifeInsurance",
+			"capitalisation": "Assets:Invest:Capitalisation",
+			"crowdlending":   "Assets:Invest:Crowdlending",
+			"article83":      "Assets:Invest:Article83",
+			"madelin":        "Assets:Invest:Madelin",
+			"rsp":            "Assets:Invest:RSP",
+			"real_estate":    "Assets:RealEstate",
+		},
+		Categories:      map[string]string{},
+		DefaultAsset:    "Assets:Other",
+		DefaultIncome:   "Income",
+		DefaultExpense:  "Expenses",
+		OpeningBalances: "Equity:Opening-Balances",
+		Currency:        "EUR",
+	}
+}
+
+// Complete the given mapping with default values for every empty field
+func (m Mapping) withDefaults() Mapping {
+	def := DefaultMapping()
+
+	if m.AccountTypes == nil {
+		m.AccountTypes = def.AccountTypes
+	} else {
+		for key, value := range def.AccountTypes {
+			if _, ok := m.AccountTypes[key]; !ok {
+				m.AccountTypes[key] = value
+			}
+		}
+	}
+	if m.Categories == nil {
+		m.Categories = def.Categories
+	}
+	if m.DefaultAsset == "" {
+		m.DefaultAsset = def.DefaultAsset
+	}
+	if m.DefaultIncome == "" {
+		m.DefaultIncome = def.DefaultIncome
+	}
+	if m.DefaultExpense == "" {
+		m.DefaultExpense = def.DefaultExpense
+	}
+	if m.OpeningBalances == "" {
+		m.OpeningBalances = def.OpeningBalances
+	}
+	if m.Currency == "" {
+		m.Currency = def.Currency
+	}
+
+	return m
+}
+
+// Write the journal of the given data in the requested format (ledger, hledger or beancount)
+func WriteJournal(w io.Writer, format string, data JournalData, mapping Mapping) error {
+
+	switch format {
+	case formatLedger, formatHledger, formatBeancount:
+	default:
+		return errors.New("unsupported journal format " + format)
+	}
+
+	mapping = mapping.withDefaults()
+	directives := buildDirectives(data, mapping)
+
+	if format == formatBeancount {
+		return writeBeancount(w, directives, mapping)
+	}
+	return writeLedger(w, directives)
+}
+
+// Convert accounts, txs, history, positions and loans to a sorted list of directives
+func buildDirectives(data JournalData, mapping Mapping) []directive {
+
+	var directives []directive
+
+	positionsByAccount := make(map[int][]Position)
+	for _, position := range data.Positions {
+		positionsByAccount[position.Account_id] = append(positionsByAccount[position.Account_id], position)
+	}
+
+	txsByAccount := make(map[int][]Transaction)
+	for _, tx := range data.Transactions {
+		txsByAccount[tx.Account_id] = append(txsByAccount[tx.Account_id], tx)
+	}
+
+	historyByAccount := make(map[int][]HistoryPoint)
+	for _, point := range data.History {
+		historyByAccount[point.Account_id] = append(historyByAccount[point.Account_id], point)
+	}
+
+	loansByAccount := make(map[int]LoanBalance)
+	for _, loan := range data.Loans {
+		loansByAccount[loan.Account_id] = loan
+	}
+
+	for _, account := range data.Accounts {
+
+		name := accountName(account, mapping)
+		currency := account.Currency
+		if currency == "" {
+			currency = mapping.Currency
+		}
+
+		txs := txsByAccount[account.Id]
+		sort.SliceStable(txs, func(i, j int) bool { return txs[i].Date.Before(txs[j].Date) })
+
+		history := historyByAccount[account.Id]
+		sort.SliceStable(history, func(i, j int) bool { return history[i].Date.Before(history[j].Date) })
+
+		// Loans: the balance is the outstanding capital owed, instead of the balances sent by Powens
+		if loan, ok := loansByAccount[account.Id]; ok {
+			history = []HistoryPoint{{Account_id: account.Id, Valuation: -loan.Capital, Date: loan.Date}}
+		}
+
+		// Investment accounts: the balance moves with the market, so we export the positions at cost
+		// and their last known price instead of cash balance assertions
+		if positions, ok := positionsByAccount[account.Id]; ok {
+			for _, position := range positions {
+				commodity := commodityName(position)
+				directives = append(directives,
+					directive{
+						date:  day(position.Date),
+						kind:  kindPosition,
+						payee: "Position " + position.Label,
+						postings: []posting{
+							{account: name, quantity: float64(position.Quantity), commodity: commodity, costPrice: position.Unit_price, currency: currency},
+							{account: mapping.OpeningBalances, elided: true},
+						},
+					},
+					directive{
+						date:      day(position.Date),
+						kind:      kindPrice,
+						commodity: commodity,
+						amount:    position.Unit_value,
+						currency:  currency,
+					},
+				)
+			}
+			continue
+		}
+
+		// The opening balance is the first known balance minus the txs which happened before it
+		var openingDate time.Time
+		var openingAmount money.Amount
+
+		if len(history) != 0 {
+			openingDate = history[0].Date
+			openingAmount = history[0].Valuation
+			for _, tx := range txs {
+				if day(tx.Date).After(day(history[0].Date)) {
+					break
+				}
+				openingAmount -= tx.Value
+			}
+		} else {
+			lastUpdate, err := time.Parse("2006-01-02 15:04:05", account.Last_update)
+			if err != nil {
+				lastUpdate = time.Now()
+			}
+			openingDate = lastUpdate
+			openingAmount = account.Balance
+			for _, tx := range txs {
+				openingAmount -= tx.Value
+			}
+		}
+
+		if len(txs) != 0 && txs[0].Date.Before(openingDate) {
+			openingDate = txs[0].Date
+		}
+
+		directives = append(directives, directive{
+			date:  day(openingDate),
+			kind:  kindOpening,
+			payee: "Opening balance",
+			postings: []posting{
+				{account: name, amount: openingAmount, currency: currency},
+				{account: mapping.OpeningBalances, elided: true},
+			},
+		})
+
+		for _, tx := range txs {
+			directives = append(directives, directive{
+				date:  day(tx.Date),
+				kind:  kindTransaction,
+				payee: tx.Original_wording,
+				postings: []posting{
+					{account: name, amount: tx.Value, currency: currency},
+					{account: categoryName(tx, mapping), elided: true},
+				},
+			})
+		}
+
+		for _, point := range history {
+			directives = append(directives, directive{
+				date:     day(point.Date),
+				kind:     kindAssertion,
+				account:  name,
+				amount:   point.Valuation,
+				currency: currency,
+			})
+		}
+	}
+
+	sort.SliceStable(directives, func(i, j int) bool {
+		if !directives[i].date.Equal(directives[j].date) {
+			return directives[i].date.Before(directives[j].date)
+		}
+		return directives[i].kind < directives[j].kind
+	})
+
+	return directives
+}
+
+// Write directives with the Ledger syntax, which is also understood by hledger
+func writeLedger(w io.Writer, directives []directive) error {
+
+	b := &strings.Builder{}
+	b.WriteString("; Generated by Freenahi\n\n")
+
+	for _, d := range directives {
+		date := d.date.Format("2006-01-02")
+
+		switch d.kind {
+		case kindOpening, kindTransaction, kindPosition:
+			fmt.Fprintf(b, "%s * %s\n", date, cleanPayee(d.payee))
+			for _, p := range d.postings {
+				switch {
+				case p.elided:
+					fmt.Fprintf(b, "    %s\n", p.account)
+				case p.commodity != "":
+					fmt.Fprintf(b, "    %s  %s \"%s\" @ %s %s\n", p.account, formatQuantity(p.quantity), p.commodity, formatAmount(p.costPrice), p.currency)
+				default:
+					fmt.Fprintf(b, "    %s  %s %s\n", p.account, formatAmount(p.amount), p.currency)
+				}
+			}
+
+		case kindPrice:
+			fmt.Fprintf(b, "P %s \"%s\" %s %s\n", date, d.commodity, formatAmount(d.amount), d.currency)
+
+		case kindAssertion:
+			fmt.Fprintf(b, "%s * Balance assertion\n", date)
+			fmt.Fprintf(b, "    %s  0 %s = %s %s\n", d.account, d.currency, formatAmount(d.amount), d.currency)
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Write directives with the Beancount syntax
+func writeBeancount(w io.Writer, directives []directive, mapping Mapping) error {
+
+	b := &strings.Builder{}
+	b.WriteString("; Generated by Freenahi\n\n")
+	fmt.Fprintf(b, "option \"operating_currency\" \"%s\"\n\n", mapping.Currency)
+
+	// Beancount needs every account to be opened before being used
+	opened := make(map[string]bool)
+	for _, d := range directives {
+		accounts := []string{d.account}
+		for _, p := range d.postings {
+			accounts = append(accounts, p.account)
+		}
+		for _, account := range accounts {
+			if account == "" || opened[account] {
+				continue
+			}
+			opened[account] = true
+			fmt.Fprintf(b, "%s open %s\n", d.date.Format("2006-01-02"), account)
+		}
+	}
+	b.WriteString("\n")
+
+	for _, d := range directives {
+		date := d.date.Format("2006-01-02")
+
+		switch d.kind {
+		case kindOpening, kindTransaction, kindPosition:
+			fmt.Fprintf(b, "%s * \"%s\"\n", date, strings.ReplaceAll(cleanPayee(d.payee), "\"", "'"))
+			for _, p := range d.postings {
+				switch {
+				case p.elided:
+					fmt.Fprintf(b, "  %s\n", p.account)
+				case p.commodity != "":
+					fmt.Fprintf(b, "  %s  %s %s {%s %s}\n", p.account, formatQuantity(p.quantity), p.commodity, formatAmount(p.costPrice), p.currency)
+				default:
+					fmt.Fprintf(b, "  %s  %s %s\n", p.account, formatAmount(p.amount), p.currency)
+				}
+			}
+
+		case kindPrice:
+			fmt.Fprintf(b, "%s price %s %s %s\n", date, d.commodity, formatAmount(d.amount), d.currency)
+
+		case kindAssertion:
+			// Beancount checks balances at the beginning of the day, so the balance of day D is asserted on D+1
+			fmt.Fprintf(b, "%s balance %s %s %s\n", d.date.AddDate(0, 0, 1).Format("2006-01-02"), d.account, formatAmount(d.amount), d.currency)
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Build the journal account name of a bank account. Ex: Assets:Bank:Checking:Boursobank:Compte-Courant
+func accountName(account Account, mapping Mapping) string {
+	prefix, ok := mapping.AccountTypes[account.Account_type]
+	if !ok {
+		prefix = mapping.DefaultAsset
+	}
+	return prefix + ":" + sanitize(account.BankName) + ":" + sanitize(account.Name)
+}
+
+// Get the counterpart account of a tx according to its type and sign
+func categoryName(tx Transaction, mapping Mapping) string {
+	if category, ok := mapping.Categories[tx.Transaction_type]; ok {
+		return category
+	}
+
+	base := mapping.DefaultExpense
+	if tx.Value > 0 {
+		base = mapping.DefaultIncome
+	}
+	if tx.Transaction_type == "" {
+		return base
+	}
+	return base + ":" + sanitize(tx.Transaction_type)
+}
+
+// Get a commodity name from a position: the stock symbol if set, the code (ISIN) otherwise
+func commodityName(position Position) string {
+	name := position.Stock_symbol
+	if name == "" {
+		name = position.Code
+	}
+	if name == "" {
+		name = position.Label
+	}
+
+	var commodity strings.Builder
+	for _, char := range strings.ToUpper(foldAccents(name)) {
+		switch {
+		case char >= 'A' && char <= 'Z', char >= '0' && char <= '9', char == '.', char == '_', char == '-':
+			commodity.WriteRune(char)
+		}
+	}
+
+	result := commodity.String()
+	if result == "" || result[0] < 'A' || result[0] > 'Z' {
+		result = "X" + result
+	}
+	return result
+}
+
+// Convert a name to a valid account component for every format. Ex: from "compte chèque" to "Compte-Cheque"
+func sanitize(name string) string {
+
+	var component strings.Builder
+	upperNext := true
+	for _, char := range foldAccents(name) {
+		switch {
+		case char >= 'a' && char <= 'z':
+			if upperNext {
+				char = char - 'a' + 'A'
+			}
+			component.WriteRune(char)
+			upperNext = false
+		case char >= 'A' && char <= 'Z', char >= '0' && char <= '9':
+			component.WriteRune(char)
+			upperNext = false
+		default:
+			if !upperNext {
+				component.WriteRune('-')
+			}
+			upperNext = true
+		}
+	}
+
+	result := strings.TrimRight(component.String(), "-")
+	if result == "" {
+		return "Unknown"
+	}
+	return result
+}
+
+// Replace the most common french accented letters with their ASCII equivalent
+func foldAccents(s string) string {
+	replacer := strings.NewReplacer(
+		"à", "a", "â", "a", "ä", "a", "é", "e", "è", "e", "ê", "e", "ë", "e",
+		"î", "i", "ï", "i", "ô", "o", "ö", "o", "ù", "u", "û", "u", "ü", "u", "ç", "c",
+		"À", "A", "Â", "A", "Ä", "A", "É", "E", "È", "E", "Ê", "E", "Ë", "E",
+		"Î", "I", "Ï", "I", "Ô", "O", "Ö", "O", "Ù", "U", "Û", "U", "Ü", "U", "Ç", "C",
+	)
+	return replacer.Replace(s)
+}
+
+// Payee must fit on a single line
+func cleanPayee(payee string) string {
+	payee = strings.Join(strings.Fields(payee), " ")
+	if payee == "" {
+		return "Unknown"
+	}
+	return payee
+}
+
+// Truncate the time of a date
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func formatAmount(value money.Amount) string {
+	return value.StringFixed(2)
+}
+
+// Quantities are stored as float32, keep their shortest representation
+func formatQuantity(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 32)
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"financialApp/money"
+)
+
+func testJournalData() JournalData {
+	return JournalData{
+		Accounts: []Account{
+			{Id: 1, BankName: "Connecteur de test", Name: "Compte chèque", Currency: "EUR", Account_type: "checking", Balance: money.MustParse("900"), Last_update: "2024-01-03 10:00:00"},
+			{Id: 2, BankName: "Connecteur de test", Name: "PEA", Currency: "EUR", Account_type: "pea", Balance: money.MustParse("1500"), Last_update: "2024-01-03 10:00:00"},
+		},
+		Transactions: []Transaction{
+			{Id: 10, Account_id: 1, Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Value: money.MustParse("-100"), Transaction_type: "card", Original_wording: "SUPERMARKET"},
+		},
+		History: []HistoryPoint{
+			{Account_id: 1, Valuation: money.MustParse("1000"), Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{Account_id: 1, Valuation: money.MustParse("900"), Date: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		},
+		Positions: []Position{
+			{Account_id: 2, Label: "ISHARES MSCI WORLD", Code: "IE00B4L5Y983", Quantity: 10, Unit_price: money.MustParse("120"), Unit_value: money.MustParse("150"), Date: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+}
+
+func TestWriteJournalLedger(t *testing.T) {
+
+	var b strings.Builder
+	if err := WriteJournal(&b, "ledger", testJournalData(), DefaultMapping()); err != nil {
+		t.Fatal(err)
+	}
+	journal := b.String()
+
+	expected := []string{
+		"2024-01-01 * Opening balance\n    Assets:Bank:Checking:Connecteur-De-Test:Compte-Cheque  1000.00 EUR\n    Equity:Opening-Balances\n",
+		"2024-01-02 * SUPERMARKET\n    Assets:Bank:Checking:Connecteur-De-Test:Compte-Cheque  -100.00 EUR\n    Expenses:Card\n",
+		"    Assets:Bank:Checking:Connecteur-De-Test:Compte-Cheque  0 EUR = 900.00 EUR\n",
+		"    Assets:Invest:PEA:Connecteur-De-Test:PEA  10 \"IE00B4L5Y983\" @ 120.00 EUR\n",
+		"P 2024-01-03 \"IE00B4L5Y983\" 150.00 EUR\n",
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(journal, line) {
+			t.Errorf("Journal does not contain %q:\n%s", line, journal)
+		}
+	}
+}
+
+func TestWriteJournalBeancount(t *testing.T) {
+
+	var b strings.Builder
+	if err := WriteJournal(&b, "beancount", testJournalData(), DefaultMapping()); err != nil {
+		t.Fatal(err)
+	}
+	journal := b.String()
+
+	expected := []string{
+		"2024-01-01 open Assets:Bank:Checking:Connecteur-De-Test:Compte-Cheque\n",
+		"2024-01-02 open Expenses:Card\n",
+		"2024-01-04 balance Assets:Bank:Checking:Connecteur-De-Test:Compte-Cheque 900.00 EUR\n", // asserted the day after
+		"  Assets:Invest:PEA:Connecteur-De-Test:PEA  10 IE00B4L5Y983 {120.00 EUR}\n",
+		"2024-01-03 price IE00B4L5Y983 150.00 EUR\n",
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(journal, line) {
+			t.Errorf("Journal does not contain %q:\n%s", line, journal)
+		}
+	}
+}
+
+func TestWriteJournalUnsupportedFormat(t *testing.T) {
+
+	var b strings.Builder
+	if err := WriteJournal(&b, "gnucash", testJournalData(), DefaultMapping()); err == nil {
+		t.Errorf("Expected an error for an unsupported format")
+	}
+}
+
+func TestMappingCategories(t *testing.T) {
+
+	mapping := Mapping{Categories: map[string]string{"card": "Expenses:Groceries"}}.withDefaults()
+
+	tx := Transaction{Value: money.MustParse("-10"), Transaction_type: "card"}
+	if got := categoryName(tx, mapping); got != "Expenses:Groceries" {
+		t.Errorf("Wrong category: got %v want %v", got, "Expenses:Groceries")
+	}
+
+	tx = Transaction{Value: money.MustParse("10"), Transaction_type: "transfer"}
+	if got := categoryName(tx, mapping); got != "Income:Transfer" {
+		t.Errorf("Wrong category: got %v want %v", got, "Income:Transfer")
+	}
+}
+
+func TestWriteJournalLoan(t *testing.T) {
+
+	data := JournalData{
+		Accounts: []Account{
+			{Id: 3, BankName: "Connecteur de test", Name: "Prêt immo", Currency: "EUR", Account_type: "loan", Balance: money.MustParse("-95000"), Last_update: "2024-06-20 10:00:00"},
+		},
+		Transactions: []Transaction{
+			{Id: 20, Account_id: 3, Date: time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC), Value: money.MustParse("1000"), Transaction_type: "bank", Original_wording: "ECHEANCE PRET"},
+		},
+		// Balances sent by Powens, replaced by the outstanding capital
+		History: []HistoryPoint{
+			{Account_id: 3, Valuation: money.MustParse("-95000"), Date: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		Loans: []LoanBalance{
+			{Account_id: 3, Capital: money.MustParse("90000"), Date: time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	var b strings.Builder
+	if err := WriteJournal(&b, "ledger", data, DefaultMapping()); err != nil {
+		t.Fatal(err)
+	}
+	journal := b.String()
+
+	expected := []string{
+		"2024-06-15 * Opening balance\n    Liabilities:Loan:Connecteur-De-Test:Pret-Immo  -91000.00 EUR\n",
+		"    Liabilities:Loan:Connecteur-De-Test:Pret-Immo  0 EUR = -90000.00 EUR\n",
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(journal, line) {
+			t.Errorf("Journal does not contain %q:\n%s", line, journal)
+		}
+	}
+	if strings.Contains(journal, "-95000.00") {
+		t.Errorf("Journal contains the balance sent by Powens:\n%s", journal)
+	}
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"time"
+
+	"financialApp/money"
+)
+
+// Supported plain-text accounting formats
+const (
+	formatLedger    = "ledger"
+	formatHledger   = "hledger"
+	formatBeancount = "beancount"
+)
+
+// Mapping between Freenahi data and the account names of the journal.
+// It can be overridden with a JSON file set in EXPORT_MAPPING_FILE
+type Mapping struct {
+	AccountTypes    map[string]string `json:"account_types"`    // account_type => account prefix. Ex: "checking" => "Assets:Bank:Checking"
+	Categories      map[string]string `json:"categories"`       // tx_type => counterpart account. Ex: "card" => "Expenses:Card"
+	DefaultAsset    string            `json:"default_asset"`    // Used when the account_type is not in AccountTypes
+	DefaultIncome   string            `json:"default_income"`   // Used for positive tx when the tx_type is not in Categories
+	DefaultExpense  string            `json:"default_expense"`  // Used for negative tx when the tx_type is not in Categories
+	OpeningBalances string            `json:"opening_balances"` // Counterpart of opening balances
+	Currency        string            `json:"currency"`         // Used when an account has no currency
+}
+
+// Data needed to build a journal, as stored in DB
+type Account struct {
+	Id           int
+	BankName     string
+	Name         string
+	Currency     string
+	Account_type string
+	Balance      money.Amount
+	Last_update  string
+}
+
+type Transaction struct {
+	Id               int
+	Account_id       int
+	Date             time.Time
+	Value            money.Amount
+	Transaction_type string
+	Original_wording string
+}
+
+type HistoryPoint struct {
+	Account_id int
+	Valuation  money.Amount
+	Date       time.Time
+}
+
+type Position struct {
+	Account_id   int
+	Label        string
+	Code         string
+	Stock_symbol string
+	Quantity     float32
+	Unit_price   money.Amount
+	Unit_value   money.Amount
+	Date         time.Time
+}
+
+// Outstanding capital of a loan at a date, calculated from its schedule
+type LoanBalance struct {
+	Account_id int
+	Capital    money.Amount
+	Date       time.Time
+}
+
+type JournalData struct {
+	Accounts     []Account
+	Transactions []Transaction
+	History      []HistoryPoint
+	Positions    []Position
+	Loans        []LoanBalance
+}
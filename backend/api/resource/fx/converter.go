@@ -0,0 +1,115 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Returned when an amount is in a currency without any rate to the base currency
+var ErrNoRate = errors.New("no fx rate")
+
+type datedRate struct {
+	date time.Time
+	rate float64
+}
+
+// Convert amounts to the base currency with the rates known at a given date
+type Converter struct {
+	base  string
+	rates map[string][]datedRate // by currency, sorted by date
+}
+
+// Load every rate of the base currency set in BASE_CURRENCY
+func LoadConverter() (*Converter, error) {
+
+	base := BaseCurrency()
+
+	var rates []Rate
+
+	var query string = "SELECT base_currency, currency, rate, rate_date, source FROM fxRate WHERE base_currency=? ORDER BY rate_date"
+	rows, err := config.DB.Query(query, base)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate Rate
+		if err := rows.Scan(&rate.Base_currency, &rate.Currency, &rate.Rate, &rate.Date, &rate.Source); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newConverter(base, rates), nil
+}
+
+func newConverter(base string, rates []Rate) *Converter {
+
+	converter := &Converter{
+		base:  base,
+		rates: make(map[string][]datedRate),
+	}
+
+	for _, rate := range rates {
+		date, err := time.Parse("2006-01-02", rate.Date)
+		if err != nil {
+			config.Logger.Warn().Str("date", rate.Date).Msg("Wrong fx rate date, rate ignored")
+			continue
+		}
+		currency := strings.ToUpper(rate.Currency)
+		converter.rates[currency] = append(converter.rates[currency], datedRate{date: date, rate: rate.Rate})
+	}
+
+	for _, rates := range converter.rates {
+		sort.Slice(rates, func(i, j int) bool {
+			return rates[i].date.Before(rates[j].date)
+		})
+	}
+
+	return converter
+}
+
+func (c *Converter) Base() string {
+	return c.base
+}
+
+// Convert an amount with the last rate known at the given date.
+// Before the first known rate, the first rate is used.
+// Without any rate for the currency, ErrNoRate is returned: an amount in another currency cannot be added to the others.
+// The result is rounded to the 4th decimal
+func (c *Converter) ToBase(amount money.Amount, currency string, date time.Time) (money.Amount, error) {
+
+	currency = strings.ToUpper(currency)
+	if currency == "" || currency == c.base {
+		return amount, nil
+	}
+
+	rates := c.rates[currency]
+	if len(rates) == 0 {
+		return 0, fmt.Errorf("%w from %s to %s, add one with POST /fx/rate/", ErrNoRate, currency, c.base)
+	}
+
+	// Index of the first rate after the date
+	index := sort.Search(len(rates), func(i int) bool {
+		return rates[i].date.After(date)
+	})
+	if index == 0 {
+		return amount.Mul(rates[0].rate), nil
+	}
+	return amount.Mul(rates[index-1].rate), nil
+}
+
+// Currency in which every aggregate is given
+func BaseCurrency() string {
+	return strings.ToUpper(config.Conf.Fx.BaseCurrency)
+}
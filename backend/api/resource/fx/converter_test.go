@@ -0,0 +1,40 @@
+package fx
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"financialApp/money"
+)
+
+func TestConverterToBase(t *testing.T) {
+
+	converter := newConverter("EUR", []Rate{
+		{Currency: "USD", Rate: 0.90, Date: "2024-01-01"},
+		{Currency: "USD", Rate: 0.95, Date: "2024-02-01"},
+	})
+
+	tests := []struct {
+		currency string
+		date     time.Time
+		want     money.Amount
+	}{
+		{currency: "EUR", date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), want: money.MustParse("100")},
+		{currency: "usd", date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), want: money.MustParse("90")}, // last rate before the date
+		{currency: "USD", date: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), want: money.MustParse("95")},  // rate of the day
+		{currency: "USD", date: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), want: money.MustParse("90")},  // before the first rate
+	}
+
+	for _, test := range tests {
+		got, err := converter.ToBase(money.MustParse("100"), test.currency, test.date)
+		if err != nil || got != test.want {
+			t.Errorf("Wrong conversion of %s at %s: got %v (%v) want %v", test.currency, test.date.Format("2006-01-02"), got, err, test.want)
+		}
+	}
+
+	// Without rate, the amount cannot be added to amounts in the base currency
+	if _, err := converter.ToBase(money.MustParse("100"), "GBP", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Expected ErrNoRate for a currency without rate, got %v", err)
+	}
+}
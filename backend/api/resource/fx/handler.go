@@ -0,0 +1,205 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"financialApp/config"
+)
+
+// Create the rate provider set in FX_RATE_PROVIDER and fetch rates every FX_RATE_INTERVAL.
+// Does nothing if rates are only entered through the API
+func Init() {
+
+	provider, err := NewRateProvider(config.Conf.Fx)
+	if err != nil {
+		config.Logger.Fatal().Err(err).Msg("Cannot create fx rate provider")
+	}
+	if provider == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(config.Conf.Fx.RateInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := RefreshRates(context.Background(), provider); err != nil {
+				config.Logger.Error().Err(err).Str("provider", provider.Name()).Msg("Fx rate refresh failed")
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Fetch today's rate of every currency used by an account
+func RefreshRates(ctx context.Context, provider RateProvider) error {
+
+	base := BaseCurrency()
+
+	var currencies []string
+
+	var query string = "SELECT DISTINCT UPPER(currency) FROM bankAccount WHERE UPPER(currency)<>? AND currency<>''"
+	rows, err := config.DB.Query(query, base)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var currency string
+		if err := rows.Scan(&currency); err != nil {
+			return err
+		}
+		currencies = append(currencies, currency)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	fetched, err := provider.Rates(ctx, base, currencies)
+	if err != nil {
+		return err
+	}
+
+	today := time.Now().Format("2006-01-02")
+	var rates []Rate
+	for currency, rate := range fetched {
+		rates = append(rates, Rate{Base_currency: base, Currency: currency, Rate: rate, Date: today, Source: provider.Name()})
+	}
+
+	if err := saveRates(rates); err != nil {
+		return err
+	}
+
+	config.Logger.Info().Str("provider", provider.Name()).Int("rates", len(rates)).Msg("Fx rates refreshed")
+	return nil
+}
+
+// Get the rates of the base currency, optionally filtered with ?currency=USD
+func GetRates(w http.ResponseWriter, r *http.Request) {
+
+	currency := strings.ToUpper(r.URL.Query().Get("currency"))
+
+	var rates []Rate
+
+	var query string = "SELECT base_currency, currency, rate, rate_date, source FROM fxRate WHERE base_currency=? AND (?='' OR currency=?) ORDER BY currency, rate_date"
+	rows, err := config.DB.Query(query, BaseCurrency(), currency, currency)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate Rate
+		if err := rows.Scan(&rate.Base_currency, &rate.Currency, &rate.Rate, &rate.Date, &rate.Source); err != nil {
+			config.Logger.Error().Err(err).Msg("Cannot scan row")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		config.Logger.Error().Err(err).Msg("Error in rows")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(rates)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Import a list of rates. A rate already known for the same currency and date is replaced
+func CreateRates(w http.ResponseWriter, r *http.Request) {
+
+	var rates []Rate
+	if err := json.NewDecoder(r.Body).Decode(&rates); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for i := range rates {
+		if err := validateRate(&rates[i]); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := saveRates(rates); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot save fx rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// Get the currency in which aggregates are given
+func GetBaseCurrency(w http.ResponseWriter, r *http.Request) {
+
+	jsonBody, err := json.Marshal(map[string]string{"base_currency": BaseCurrency()})
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal base currency")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+func validateRate(rate *Rate) error {
+
+	rate.Base_currency = BaseCurrency()
+	rate.Currency = strings.ToUpper(rate.Currency)
+	if len(rate.Currency) != 3 {
+		return errors.New("currency must be an ISO 4217 code, ex: USD")
+	}
+
+	if rate.Rate <= 0 {
+		return errors.New("rate must be positive")
+	}
+
+	if rate.Date == "" {
+		rate.Date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", rate.Date); err != nil {
+		return errors.New("wrong date, must be YYYY-MM-DD")
+	}
+
+	if rate.Source == "" {
+		rate.Source = "manual"
+	}
+
+	return nil
+}
+
+func saveRates(rates []Rate) error {
+
+	if len(rates) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO fxRate (base_currency, currency, rate_date, rate, source) VALUES "
+	vals := []any{}
+	for _, rate := range rates {
+		query += "(?, ?, ?, ?, ?),"
+		vals = append(vals, rate.Base_currency, rate.Currency, rate.Date, rate.Rate, rate.Source)
+	}
+	query = query[0 : len(query)-1]
+
+	// if duplicate entry, update the field by the new value
+	query += " AS new(a, b, c, Nrate, Nsource) ON DUPLICATE KEY UPDATE rate=Nrate, source=Nsource"
+
+	_, err := config.DB.Exec(query, vals...)
+	return err
+}
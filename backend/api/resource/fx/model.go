@@ -0,0 +1,11 @@
+package fx
+
+// Value of one unit of Currency in Base_currency at a given date.
+// Ex: base EUR, currency USD, rate 0.92 means 1 USD = 0.92 EUR
+type Rate struct {
+	Base_currency string  `json:"base_currency"`
+	Currency      string  `json:"currency"`
+	Rate          float64 `json:"rate"`
+	Date          string  `json:"date"`   // YYYY-MM-DD
+	Source        string  `json:"source"` // manual, csv, frankfurter...
+}
@@ -0,0 +1,153 @@
+package fx
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"financialApp/config"
+)
+
+// Give the current value of currencies in the base currency
+type RateProvider interface {
+	// Name recorded as the source of the rates
+	Name() string
+	// Return the value of one unit of each currency in the base currency. Unknown currencies are absent from the map
+	Rates(ctx context.Context, base string, currencies []string) (map[string]float64, error)
+}
+
+// Create the rate provider set in FX_RATE_PROVIDER. Return nil if rates are only entered through the API
+func NewRateProvider(conf config.ConfFx) (RateProvider, error) {
+
+	switch conf.RateProvider {
+	case "none", "":
+		return nil, nil
+	case "csv":
+		if conf.RateCsvFile == "" {
+			return nil, errors.New("FX_RATE_CSV_FILE is required by the csv rate provider")
+		}
+		return &CsvProvider{Path: conf.RateCsvFile}, nil
+	case "frankfurter":
+		return &FrankfurterProvider{
+			BaseUrl: "https://api.frankfurter.app",
+			Client:  &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported fx rate provider '%s'. Should be none, csv or frankfurter", conf.RateProvider)
+	}
+}
+
+// Rates read from a local CSV file, with lines "currency,rate": the value of one unit of currency in the base currency.
+// A header line and lines starting with # are ignored
+type CsvProvider struct {
+	Path string
+}
+
+func (p *CsvProvider) Name() string {
+	return "csv"
+}
+
+func (p *CsvProvider) Rates(ctx context.Context, base string, currencies []string) (map[string]float64, error) {
+
+	file, err := os.Open(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	known := make(map[string]float64)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			continue
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			continue // header or malformed line
+		}
+		known[strings.ToUpper(strings.TrimSpace(record[0]))] = rate
+	}
+
+	rates := make(map[string]float64)
+	for _, currency := range currencies {
+		if rate, ok := known[strings.ToUpper(currency)]; ok {
+			rates[currency] = rate
+		}
+	}
+	return rates, nil
+}
+
+// Rates published by the European Central Bank, through the free Frankfurter API
+// https://frankfurter.dev/
+type FrankfurterProvider struct {
+	BaseUrl string
+	Client  *http.Client
+}
+
+func (p *FrankfurterProvider) Name() string {
+	return "frankfurter"
+}
+
+func (p *FrankfurterProvider) Rates(ctx context.Context, base string, currencies []string) (map[string]float64, error) {
+
+	if len(currencies) == 0 {
+		return map[string]float64{}, nil
+	}
+
+	params := url.Values{}
+	params.Set("from", base)
+	params.Set("to", strings.Join(currencies, ","))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.BaseUrl+"/latest?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("Frankfurter returned " + resp.Status)
+	}
+
+	// Ex: {"base":"EUR","rates":{"USD":1.08}}: 1 EUR = 1.08 USD
+	var body struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	// Invert them to get the value of one unit of currency in the base currency
+	rates := make(map[string]float64)
+	for _, currency := range currencies {
+		if rate, ok := body.Rates[currency]; ok && rate != 0 {
+			rates[currency] = 1 / rate
+		}
+	}
+	return rates, nil
+}
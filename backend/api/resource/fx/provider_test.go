@@ -0,0 +1,50 @@
+package fx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFrankfurterProvider(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("from") != "EUR" || r.URL.Query().Get("to") != "USD,CHF" {
+			t.Errorf("Wrong query: %v", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"amount":1.0,"base":"EUR","date":"2024-01-03","rates":{"USD":1.25,"CHF":0.5}}`))
+	}))
+	defer server.Close()
+
+	provider := &FrankfurterProvider{BaseUrl: server.URL, Client: server.Client()}
+
+	rates, err := provider.Rates(context.Background(), "EUR", []string{"USD", "CHF"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Inverted: value of one unit of currency in EUR
+	if rates["USD"] != 0.8 || rates["CHF"] != 2 {
+		t.Errorf("Wrong rates: %v", rates)
+	}
+}
+
+func TestCsvProvider(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	if err := os.WriteFile(path, []byte("currency,rate\nusd,0.92\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rates, err := (&CsvProvider{Path: path}).Rates(context.Background(), "EUR", []string{"USD", "GBP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rates) != 1 || rates["USD"] != 0.92 {
+		t.Errorf("Wrong rates: %v", rates)
+	}
+}
@@ -0,0 +1,379 @@
+package income
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"financialApp/api/resource/fx"
+	"financialApp/api/resource/incomerule"
+	"financialApp/api/resource/transaction"
+	"financialApp/config"
+	"financialApp/money"
+)
+
+func CreateRule(w http.ResponseWriter, r *http.Request) {
+
+	var rule incomerule.Rule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var query string = "INSERT INTO incomeRule (income_type, tx_type, pattern, account_id, invest_code) VALUES (?, ?, ?, ?, ?)"
+	result, err := config.DB.Exec(query, rule.Income_type, rule.Tx_type, rule.Pattern, rule.Account_id, rule.Code)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get rule id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	rule.Rule_id = int(id)
+
+	jsonBody, err := json.Marshal(rule)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal income rule")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+func GetRules(w http.ResponseWriter, r *http.Request) {
+
+	rules, err := incomerule.ReadRules()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read income rules")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if len(rules) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	jsonBody, err := json.Marshal(rules)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal income rules")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+func DeleteRule(w http.ResponseWriter, r *http.Request) {
+
+	ruleId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var query string = "DELETE FROM incomeRule WHERE rule_id=?"
+	result, err := config.DB.Exec(query, ruleId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		http.Error(w, "Rule does not exist", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Tag a tx as an income, linked to a security or not, or as not an income with the type "none"
+func UpdateTag(w http.ResponseWriter, r *http.Request) {
+
+	txId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var tag incomerule.Tag
+	if err := json.NewDecoder(r.Body).Decode(&tag); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateTag(&tag); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	var query string = "SELECT EXISTS (SELECT 1 FROM tx WHERE tx_id=?)"
+	if err := config.DB.QueryRow(query, txId).Scan(&exists); err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Transaction does not exist", http.StatusNotFound)
+		return
+	}
+
+	query = "INSERT INTO incomeTag (tx_id, income_type, invest_code) VALUES (?, ?, ?) AS new(a, Ntype, Ncode) ON DUPLICATE KEY UPDATE income_type=Ntype, invest_code=Ncode"
+	if _, err := config.DB.Exec(query, txId, tag.Income_type, tag.Code); err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Remove the tag of a tx, the rules apply again
+func DeleteTag(w http.ResponseWriter, r *http.Request) {
+
+	txId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var query string = "DELETE FROM incomeTag WHERE tx_id=?"
+	if _, err := config.DB.Exec(query, txId); err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Get the txs recognized as income, most recent first. Can be limited to a year (?year=2024)
+func GetIncomes(w http.ResponseWriter, r *http.Request) {
+
+	year, err := yearParam(r)
+	if err != nil {
+		http.Error(w, "Wrong year", http.StatusBadRequest)
+		return
+	}
+
+	incomes, err := readIncomes(year)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read incomes")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if len(incomes) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Most recent first, like the txs
+	for i, j := 0, len(incomes)-1; i < j; i, j = i+1, j-1 {
+		incomes[i], incomes[j] = incomes[j], incomes[i]
+	}
+
+	jsonBody, err := json.Marshal(incomes)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal incomes")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Report the passive income of each year, or of a year (?year=2024), by account and by security with the yield on cost
+func GetReport(w http.ResponseWriter, r *http.Request) {
+
+	year, err := yearParam(r)
+	if err != nil {
+		http.Error(w, "Wrong year", http.StatusBadRequest)
+		return
+	}
+
+	incomes, err := readIncomes(year)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read incomes")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if len(incomes) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	converter, err := fx.LoadConverter()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot load fx rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	accounts, err := readAccounts()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read accounts")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	securities, err := readSecurities(converter)
+	if errors.Is(err, fx.ErrNoRate) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read securities")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	var baseValues []money.Amount
+	for _, income := range incomes {
+		date, err := time.Parse("2006-01-02", income.Date[0:10])
+		if err != nil {
+			date = time.Now()
+		}
+		value, err := converter.ToBase(income.Value, accounts[income.Account_id].currency, date)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		baseValues = append(baseValues, value)
+	}
+
+	jsonBody, err := json.Marshal(yearlyIncome(incomes, baseValues, accounts, securities))
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal income report")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+func yearParam(r *http.Request) (int, error) {
+
+	value := r.URL.Query().Get("year")
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// Read the txs of a year, or of every year with 0, and keep the ones recognized as income
+func readIncomes(year int) ([]Income, error) {
+
+	rules, err := incomerule.ReadRules()
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := incomerule.ReadTags()
+	if err != nil {
+		return nil, err
+	}
+
+	securities, err := readSecurities(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var incomes []Income
+
+	var query string = "SELECT tx_id, account_id, tx_date, tx_value, tx_type, original_wording FROM tx WHERE ? = 0 OR YEAR(tx_date) = ? ORDER BY tx_date, tx_id"
+	rows, err := config.DB.Query(query, year, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tx transaction.Transaction
+		if err := rows.Scan(&tx.Id, &tx.Account_id, &tx.Date, &tx.Value, &tx.Transaction_type, &tx.Original_wording); err != nil {
+			return nil, err
+		}
+
+		var tag *incomerule.Tag
+		if t, ok := tags[tx.Id]; ok {
+			tag = &t
+		}
+		if income, ok := classify(tx, tag, rules, securities); ok {
+			incomes = append(incomes, income)
+		}
+	}
+
+	return incomes, rows.Err()
+}
+
+// Read the invests, sold ones included since they may have paid income before.
+// The cost of the quantity held is converted to the base currency when a converter is given
+func readSecurities(converter *fx.Converter) ([]security, error) {
+
+	var securities []security
+	now := time.Now()
+
+	var query string = "SELECT invest.account_id, invest.invest_code, invest.invest_label, invest.quantity, invest.unit_price, invest.closed_date IS NULL, bankAccount.currency FROM invest INNER JOIN bankAccount ON invest.account_id = bankAccount.account_id"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var security security
+		var quantity float64
+		var unitPrice money.Amount
+		var open bool
+		var currency string
+		if err := rows.Scan(&security.accountId, &security.code, &security.label, &quantity, &unitPrice, &open, &currency); err != nil {
+			return nil, err
+		}
+		if open && converter != nil {
+			security.cost, err = converter.ToBase(unitPrice.Mul(quantity), currency, now)
+			if err != nil {
+				return nil, err
+			}
+		}
+		securities = append(securities, security)
+	}
+
+	return securities, rows.Err()
+}
+
+func readAccounts() (map[int]account, error) {
+
+	accounts := make(map[int]account)
+
+	var query string = "SELECT account_id, original_name, account_type, currency FROM bankAccount"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var accountId int
+		var account account
+		if err := rows.Scan(&accountId, &account.name, &account.accountType, &account.currency); err != nil {
+			return nil, err
+		}
+		accounts[accountId] = account
+	}
+
+	return accounts, rows.Err()
+}
@@ -0,0 +1,56 @@
+package income
+
+import "financialApp/money"
+
+// Tx recognized as an income, in the currency of its account
+type Income struct {
+	Tx_id       int          `json:"id"`
+	Account_id  int          `json:"id_account"`
+	Date        string       `json:"date"`
+	Value       money.Amount `json:"value"`
+	Wording     string       `json:"original_wording"`
+	Income_type string       `json:"type"`
+	Code        string       `json:"code"` // empty for the interest of a savings account
+	Label       string       `json:"label"`
+	Source      string       `json:"source"` // tag, rule or default
+}
+
+type AccountIncome struct {
+	Account_id   int          `json:"id_account"`
+	Name         string       `json:"original_name"`
+	Account_type string       `json:"account_type"`
+	Currency     string       `json:"currency"`
+	Value        money.Amount `json:"value"`      // in the account currency
+	Base_value   money.Amount `json:"base_value"` // in the base currency
+}
+
+type SecurityIncome struct {
+	Code          string       `json:"code"`
+	Label         string       `json:"label"`
+	Value         money.Amount `json:"value"`         // in the base currency
+	Cost          money.Amount `json:"cost"`          // what the quantity held cost, in the base currency
+	Yield_on_cost float64      `json:"yield_on_cost"` // ratio of the income to the cost, 0.04 for 4%
+}
+
+// Passive income of a year, in the base currency
+type YearIncome struct {
+	Year       int                     `json:"year"`
+	Total      money.Amount            `json:"total"`
+	By_type    map[string]money.Amount `json:"by_type"`
+	Accounts   []AccountIncome         `json:"accounts"`
+	Securities []SecurityIncome        `json:"securities"`
+}
+
+// Invest of an account, used to link the income to the security paying it
+type security struct {
+	accountId int
+	code      string
+	label     string
+	cost      money.Amount // quantity × average buy price, in the base currency
+}
+
+type account struct {
+	name        string
+	accountType string
+	currency    string
+}
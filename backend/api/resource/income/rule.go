@@ -0,0 +1,138 @@
+package income
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"financialApp/api/resource/incomerule"
+	"financialApp/api/resource/transaction"
+	"financialApp/money"
+)
+
+// Recognize a tx as an income with the income rules, and link it to its security.
+// When no security is given, the one of the account whose label or ISIN is in the wording is used
+func classify(tx transaction.Transaction, tag *incomerule.Tag, rules []incomerule.Rule, securities []security) (Income, bool) {
+
+	income := Income{
+		Tx_id:      tx.Id,
+		Account_id: tx.Account_id,
+		Date:       tx.Date,
+		Value:      tx.Value,
+		Wording:    tx.Original_wording,
+	}
+
+	match, ok := incomerule.Classify(incomerule.Tx{Account_id: tx.Account_id, Value: tx.Value, Transaction_type: tx.Transaction_type, Original_wording: tx.Original_wording}, tag, rules)
+	if !ok {
+		return income, false
+	}
+	income.Income_type, income.Code, income.Source = match.Income_type, match.Code, match.Source
+
+	wording := strings.ToUpper(tx.Original_wording)
+	for _, security := range securities {
+		if security.accountId != tx.Account_id || security.code == "" {
+			continue
+		}
+		if income.Code == "" && income.Income_type != "interest" && (strings.Contains(wording, strings.ToUpper(security.code)) || (security.label != "" && strings.Contains(wording, strings.ToUpper(security.label)))) {
+			income.Code = security.code
+		}
+		if income.Code == security.code {
+			income.Label = security.label
+			break
+		}
+	}
+
+	return income, true
+}
+
+// Sum the incomes by year, most recent first, by account and by security.
+// The yield on cost divides the income of a year by what the securities held today cost
+func yearlyIncome(incomes []Income, baseValues []money.Amount, accounts map[int]account, securities []security) []YearIncome {
+
+	costs := make(map[string]money.Amount)
+	labels := make(map[string]string)
+	for _, security := range securities {
+		costs[security.code] += security.cost
+		labels[security.code] = security.label
+	}
+
+	byYear := make(map[int]*YearIncome)
+	for i, income := range incomes {
+		year, err := strconv.Atoi(income.Date[0:4])
+		if err != nil {
+			continue
+		}
+		if byYear[year] == nil {
+			byYear[year] = &YearIncome{Year: year, By_type: make(map[string]money.Amount)}
+		}
+		yearIncome := byYear[year]
+		yearIncome.Total += baseValues[i]
+		yearIncome.By_type[income.Income_type] += baseValues[i]
+
+		index := slices.IndexFunc(yearIncome.Accounts, func(a AccountIncome) bool { return a.Account_id == income.Account_id })
+		if index < 0 {
+			index = len(yearIncome.Accounts)
+			info := accounts[income.Account_id]
+			yearIncome.Accounts = append(yearIncome.Accounts, AccountIncome{Account_id: income.Account_id, Name: info.name, Account_type: info.accountType, Currency: info.currency})
+		}
+		yearIncome.Accounts[index].Value += income.Value
+		yearIncome.Accounts[index].Base_value += baseValues[i]
+
+		if income.Code == "" {
+			continue
+		}
+		index = slices.IndexFunc(yearIncome.Securities, func(s SecurityIncome) bool { return s.Code == income.Code })
+		if index < 0 {
+			index = len(yearIncome.Securities)
+			label := labels[income.Code]
+			if label == "" {
+				label = income.Label
+			}
+			yearIncome.Securities = append(yearIncome.Securities, SecurityIncome{Code: income.Code, Label: label, Cost: costs[income.Code]})
+		}
+		yearIncome.Securities[index].Value += baseValues[i]
+	}
+
+	var years []YearIncome
+	for _, yearIncome := range byYear {
+		for i, security := range yearIncome.Securities {
+			if security.Cost.Sign() > 0 {
+				yearIncome.Securities[i].Yield_on_cost = security.Value.Div(security.Cost)
+			}
+		}
+		sort.Slice(yearIncome.Accounts, func(i, j int) bool { return yearIncome.Accounts[i].Base_value > yearIncome.Accounts[j].Base_value })
+		sort.Slice(yearIncome.Securities, func(i, j int) bool { return yearIncome.Securities[i].Value > yearIncome.Securities[j].Value })
+		years = append(years, *yearIncome)
+	}
+	sort.Slice(years, func(i, j int) bool { return years[i].Year > years[j].Year })
+
+	return years
+}
+
+func validateRule(rule *incomerule.Rule) error {
+
+	if !slices.Contains(incomerule.Types, rule.Income_type) {
+		return errors.New("type must be dividend, interest or coupon")
+	}
+
+	rule.Pattern = strings.TrimSpace(rule.Pattern)
+	rule.Tx_type = strings.TrimSpace(rule.Tx_type)
+	rule.Code = strings.ToUpper(strings.TrimSpace(rule.Code))
+	if rule.Pattern == "" && rule.Tx_type == "" && rule.Account_id == 0 {
+		return errors.New("pattern, tx_type or id_account is required")
+	}
+
+	return nil
+}
+
+func validateTag(tag *incomerule.Tag) error {
+
+	if tag.Income_type != incomerule.TypeNone && !slices.Contains(incomerule.Types, tag.Income_type) {
+		return errors.New("type must be dividend, interest, coupon or none")
+	}
+	tag.Code = strings.ToUpper(strings.TrimSpace(tag.Code))
+
+	return nil
+}
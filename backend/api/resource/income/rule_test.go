@@ -0,0 +1,92 @@
+package income
+
+import (
+	"testing"
+
+	"financialApp/api/resource/incomerule"
+	"financialApp/api/resource/transaction"
+	"financialApp/money"
+)
+
+func TestClassify(t *testing.T) {
+
+	securities := []security{
+		{accountId: 1, code: "FR0000120271", label: "TotalEnergies"},
+		{accountId: 2, code: "FR0000131104", label: "BNP Paribas"},
+	}
+	rules := []incomerule.Rule{
+		{Income_type: "coupon", Pattern: "DISTRIBUTION", Code: "IE00B4L5Y983"},
+	}
+
+	tests := []struct {
+		name   string
+		tx     transaction.Transaction
+		tag    *incomerule.Tag
+		ok     bool
+		kind   string
+		code   string
+		source string
+	}{
+		{name: "default rule linked by label", tx: transaction.Transaction{Account_id: 1, Value: money.MustParse("42"), Original_wording: "DIVIDENDE TOTALENERGIES"}, ok: true, kind: "dividend", code: "FR0000120271", source: "default"},
+		{name: "security of another account", tx: transaction.Transaction{Account_id: 1, Value: money.MustParse("42"), Original_wording: "DIVIDENDE BNP PARIBAS"}, ok: true, kind: "dividend", source: "default"},
+		{name: "savings interest", tx: transaction.Transaction{Account_id: 3, Value: money.MustParse("120"), Original_wording: "INTERETS CREDITEURS 2024"}, ok: true, kind: "interest", source: "default"},
+		{name: "debit interest", tx: transaction.Transaction{Account_id: 3, Value: money.MustParse("-12"), Original_wording: "INTERETS DEBITEURS"}, ok: false},
+		{name: "user rule before default", tx: transaction.Transaction{Account_id: 1, Value: money.MustParse("10"), Original_wording: "DISTRIBUTION DIVIDENDE ETF"}, ok: true, kind: "coupon", code: "IE00B4L5Y983", source: "rule"},
+		{name: "debit matching a user rule", tx: transaction.Transaction{Account_id: 1, Value: money.MustParse("-250"), Original_wording: "ACHAT DISTRIBUTION ETF"}, ok: false},
+		{name: "tag", tx: transaction.Transaction{Account_id: 2, Value: money.MustParse("-5"), Original_wording: "PRELEVEMENT A LA SOURCE"}, tag: &incomerule.Tag{Income_type: "dividend", Code: "FR0000131104"}, ok: true, kind: "dividend", code: "FR0000131104", source: "tag"},
+		{name: "excluded by tag", tx: transaction.Transaction{Account_id: 1, Value: money.MustParse("42"), Original_wording: "DIVIDENDE TOTALENERGIES"}, tag: &incomerule.Tag{Income_type: incomerule.TypeNone}, ok: false},
+		{name: "not an income", tx: transaction.Transaction{Account_id: 1, Value: money.MustParse("1500"), Original_wording: "VIREMENT SALAIRE"}, ok: false},
+	}
+
+	for _, tt := range tests {
+		income, ok := classify(tt.tx, tt.tag, rules, securities)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if income.Income_type != tt.kind || income.Code != tt.code || income.Source != tt.source {
+			t.Errorf("%s: income = %+v", tt.name, income)
+		}
+	}
+}
+
+func TestYearlyIncome(t *testing.T) {
+
+	incomes := []Income{
+		{Account_id: 1, Date: "2023-06-01 00:00:00", Value: money.MustParse("30"), Income_type: "dividend", Code: "FR0000120271"},
+		{Account_id: 1, Date: "2024-06-01 00:00:00", Value: money.MustParse("40"), Income_type: "dividend", Code: "FR0000120271"},
+		{Account_id: 1, Date: "2024-12-01 00:00:00", Value: money.MustParse("10"), Income_type: "dividend", Code: "FR0000120271"},
+		{Account_id: 3, Date: "2024-12-31 00:00:00", Value: money.MustParse("100"), Income_type: "interest"},
+	}
+	// Account 3 is in dollars, converted at 0.9
+	baseValues := []money.Amount{money.MustParse("30"), money.MustParse("40"), money.MustParse("10"), money.MustParse("90")}
+	accounts := map[int]account{
+		1: {name: "Securities", accountType: "market", currency: "EUR"},
+		3: {name: "Savings", accountType: "savings", currency: "USD"},
+	}
+	securities := []security{
+		{accountId: 1, code: "FR0000120271", label: "TotalEnergies", cost: money.MustParse("1000")},
+	}
+
+	years := yearlyIncome(incomes, baseValues, accounts, securities)
+	if len(years) != 2 || years[0].Year != 2024 || years[1].Year != 2023 {
+		t.Fatalf("years = %+v", years)
+	}
+
+	year := years[0]
+	if got := year.Total.StringFixed(2); got != "140.00" {
+		t.Errorf("total = %s, want 140.00", got)
+	}
+	if got := year.By_type["interest"].StringFixed(2); got != "90.00" {
+		t.Errorf("interest = %s, want 90.00", got)
+	}
+	if len(year.Accounts) != 2 || year.Accounts[0].Account_id != 3 || year.Accounts[0].Value.StringFixed(2) != "100.00" || year.Accounts[0].Base_value.StringFixed(2) != "90.00" {
+		t.Errorf("accounts = %+v", year.Accounts)
+	}
+	if len(year.Securities) != 1 || year.Securities[0].Label != "TotalEnergies" || year.Securities[0].Yield_on_cost != 0.05 {
+		t.Errorf("securities = %+v", year.Securities)
+	}
+}
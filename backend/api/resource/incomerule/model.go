@@ -0,0 +1,40 @@
+package incomerule
+
+import "financialApp/money"
+
+// Kinds of passive income. A tag with "none" excludes a tx matched by a rule
+var Types = []string{"dividend", "interest", "coupon"}
+
+const TypeNone = "none"
+
+// Recognize txs as income. Empty criteria match every tx, a rule without criteria is refused.
+// Ex: {dividend, "", "DIVIDENDE TOTALENERGIES", 0, FR0000120271}
+type Rule struct {
+	Rule_id     int    `json:"id"`
+	Income_type string `json:"type"`
+	Tx_type     string `json:"tx_type"`    // Powens tx type, like "bank"
+	Pattern     string `json:"pattern"`    // contained in the wording, case insensitive
+	Account_id  int    `json:"id_account"` // 0 for every account
+	Code        string `json:"code"`       // ISIN of the security paying the income
+}
+
+// Manual tagging of a tx, which prevails over the rules
+type Tag struct {
+	Income_type string `json:"type"`
+	Code        string `json:"code"`
+}
+
+// Fields of a tx read by the rules
+type Tx struct {
+	Account_id       int
+	Value            money.Amount
+	Transaction_type string
+	Original_wording string
+}
+
+// How a tx is recognized as an income
+type Match struct {
+	Income_type string
+	Code        string // empty when the tag or the rule gives no security
+	Source      string // tag, rule or default
+}
@@ -0,0 +1,104 @@
+package incomerule
+
+import (
+	"slices"
+	"strings"
+
+	"financialApp/config"
+)
+
+// Wordings used by French banks and brokers, applied after the rules
+var defaultRules = []Rule{
+	{Income_type: "dividend", Pattern: "DIVIDEND"}, // DIVIDENDE too
+	{Income_type: "coupon", Pattern: "COUPON"},
+	{Income_type: "interest", Pattern: "INTERET"},
+	{Income_type: "interest", Pattern: "INTÉRÊT"},
+	{Income_type: "interest", Pattern: "INTEREST"},
+}
+
+func (rule Rule) matches(tx Tx) bool {
+
+	if rule.Account_id != 0 && rule.Account_id != tx.Account_id {
+		return false
+	}
+	if rule.Tx_type != "" && rule.Tx_type != tx.Transaction_type {
+		return false
+	}
+	if rule.Pattern != "" && !strings.Contains(strings.ToUpper(tx.Original_wording), strings.ToUpper(rule.Pattern)) {
+		return false
+	}
+	return true
+}
+
+// Recognize a tx as an income with its tag, the first matching rule, or the default rules.
+// Rules only match credits: purchases and fees with the same wording are not income. A tag can book a debit, like a withholding tax
+func Classify(tx Tx, tag *Tag, rules []Rule) (Match, bool) {
+
+	if tag != nil {
+		if tag.Income_type == TypeNone {
+			return Match{}, false
+		}
+		return Match{Income_type: tag.Income_type, Code: tag.Code, Source: "tag"}, true
+	}
+
+	if tx.Value.Sign() <= 0 {
+		return Match{}, false
+	}
+
+	index := slices.IndexFunc(rules, func(rule Rule) bool { return rule.matches(tx) })
+	if index >= 0 {
+		return Match{Income_type: rules[index].Income_type, Code: rules[index].Code, Source: "rule"}, true
+	}
+
+	index = slices.IndexFunc(defaultRules, func(rule Rule) bool { return rule.matches(tx) })
+	if index < 0 {
+		return Match{}, false
+	}
+	return Match{Income_type: defaultRules[index].Income_type, Source: "default"}, true
+}
+
+func ReadRules() ([]Rule, error) {
+
+	var rules []Rule
+
+	var query string = "SELECT rule_id, income_type, tx_type, pattern, account_id, invest_code FROM incomeRule ORDER BY rule_id"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rule Rule
+		if err := rows.Scan(&rule.Rule_id, &rule.Income_type, &rule.Tx_type, &rule.Pattern, &rule.Account_id, &rule.Code); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// Read the tags by tx id
+func ReadTags() (map[int]Tag, error) {
+
+	tags := make(map[int]Tag)
+
+	var query string = "SELECT tx_id, income_type, invest_code FROM incomeTag"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var txId int
+		var tag Tag
+		if err := rows.Scan(&txId, &tag.Income_type, &tag.Code); err != nil {
+			return nil, err
+		}
+		tags[txId] = tag
+	}
+
+	return tags, rows.Err()
+}
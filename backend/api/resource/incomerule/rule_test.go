@@ -0,0 +1,39 @@
+package incomerule
+
+import (
+	"testing"
+
+	"financialApp/money"
+)
+
+func TestClassify(t *testing.T) {
+
+	rules := []Rule{
+		{Income_type: "coupon", Pattern: "DISTRIBUTION", Code: "IE00B4L5Y983"},
+		{Income_type: "interest", Tx_type: "bank", Account_id: 3},
+	}
+
+	tests := []struct {
+		name  string
+		tx    Tx
+		tag   *Tag
+		ok    bool
+		match Match
+	}{
+		{name: "default wording", tx: Tx{Account_id: 1, Value: money.MustParse("42"), Original_wording: "DIVIDENDE TOTALENERGIES"}, ok: true, match: Match{Income_type: "dividend", Source: "default"}},
+		{name: "deposit", tx: Tx{Account_id: 1, Value: money.MustParse("500"), Original_wording: "VIR SEPA DEPOT"}, ok: false},
+		{name: "debit with an income wording", tx: Tx{Account_id: 1, Value: money.MustParse("-3"), Original_wording: "FRAIS COUPON"}, ok: false},
+		{name: "rule before default", tx: Tx{Account_id: 1, Value: money.MustParse("10"), Original_wording: "Distribution dividende ETF"}, ok: true, match: Match{Income_type: "coupon", Code: "IE00B4L5Y983", Source: "rule"}},
+		{name: "rule of an account and a tx type", tx: Tx{Account_id: 3, Value: money.MustParse("8"), Transaction_type: "bank", Original_wording: "VIR LIVRET"}, ok: true, match: Match{Income_type: "interest", Source: "rule"}},
+		{name: "rule of another account", tx: Tx{Account_id: 2, Value: money.MustParse("8"), Transaction_type: "bank", Original_wording: "VIR LIVRET"}, ok: false},
+		{name: "tagged debit", tx: Tx{Account_id: 2, Value: money.MustParse("-5"), Original_wording: "PRELEVEMENT A LA SOURCE"}, tag: &Tag{Income_type: "dividend", Code: "FR0000131104"}, ok: true, match: Match{Income_type: "dividend", Code: "FR0000131104", Source: "tag"}},
+		{name: "excluded by tag", tx: Tx{Account_id: 1, Value: money.MustParse("40"), Original_wording: "INTERETS PRET FAMILIAL"}, tag: &Tag{Income_type: TypeNone}, ok: false},
+	}
+
+	for _, tt := range tests {
+		match, ok := Classify(tt.tx, tt.tag, rules)
+		if ok != tt.ok || match != tt.match {
+			t.Errorf("%s: match = %+v, %v", tt.name, match, ok)
+		}
+	}
+}
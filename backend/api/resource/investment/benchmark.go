@@ -0,0 +1,431 @@
+package investment
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"financialApp/api/resource/fx"
+	"financialApp/config"
+)
+
+func CreateBenchmark(w http.ResponseWriter, r *http.Request) {
+
+	var benchmark Benchmark
+	if err := json.NewDecoder(r.Body).Decode(&benchmark); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	benchmark.Name = strings.TrimSpace(benchmark.Name)
+	if benchmark.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	var entries uint
+	var query string = "SELECT EXISTS (SELECT 1 FROM benchmark WHERE benchmark_name=?)"
+	if err := config.DB.QueryRow(query, benchmark.Name).Scan(&entries); err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if entries != 0 {
+		http.Error(w, "Benchmark already exists", http.StatusConflict)
+		return
+	}
+
+	query = "INSERT INTO benchmark (benchmark_name, invest_code, stock_symbol) VALUES (?, ?, ?)"
+	result, err := config.DB.Exec(query, benchmark.Name, benchmark.Code, benchmark.Stock_symbol)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get benchmark id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	benchmark.Benchmark_id = int(id)
+
+	jsonBody, err := json.Marshal(benchmark)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal benchmark")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+func GetBenchmarks(w http.ResponseWriter, r *http.Request) {
+
+	benchmarks, err := readBenchmarks()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read benchmarks")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(benchmarks)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal benchmarks")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Delete a benchmark and its values
+func DeleteBenchmark(w http.ResponseWriter, r *http.Request) {
+
+	benchmarkId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var query string = "DELETE FROM benchmark WHERE benchmark_id=?"
+	result, err := config.DB.Exec(query, benchmarkId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		http.Error(w, "Benchmark does not exist", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Import values of a benchmark, as a JSON list of {date, value} or, with the text/csv content type, as "date,value" lines.
+// A value already known for the same date is replaced
+func CreateBenchmarkValues(w http.ResponseWriter, r *http.Request) {
+
+	benchmarkId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var values []BenchmarkValue
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		values, err = parseBenchmarkCsv(r.Body)
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&values)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, value := range values {
+		if _, err := time.Parse("2006-01-02", value.Date); err != nil {
+			http.Error(w, "wrong date, must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		if value.Value <= 0 {
+			http.Error(w, "value must be positive", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var entries uint
+	var query string = "SELECT EXISTS (SELECT 1 FROM benchmark WHERE benchmark_id=?)"
+	if err := config.DB.QueryRow(query, benchmarkId).Scan(&entries); err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if entries == 0 {
+		http.Error(w, "Benchmark does not exist", http.StatusNotFound)
+		return
+	}
+
+	if err := saveBenchmarkValues(benchmarkId, values); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot save benchmark values")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// Compare the portfolio, or the accounts selected like in GetPerformance, with a benchmark over a period (all, month or year).
+// Deposits and withdrawals are neutralized, so only the performance is compared
+func GetBenchmarkComparison(w http.ResponseWriter, r *http.Request) {
+
+	benchmarkId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var since time.Time
+	switch r.URL.Query().Get("period") {
+	case "", "all":
+	case "month":
+		since = time.Now().Add(-31 * 24 * time.Hour)
+	case "year":
+		since = time.Now().Add(-365 * 24 * time.Hour)
+	default:
+		http.Error(w, "period must be all, month or year", http.StatusBadRequest)
+		return
+	}
+
+	filter, args, err := scopeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var benchmarkName string
+	var query string = "SELECT benchmark_name FROM benchmark WHERE benchmark_id=?"
+	if err := config.DB.QueryRow(query, benchmarkId).Scan(&benchmarkName); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Benchmark does not exist", http.StatusNotFound)
+			return
+		}
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	values, err := readBenchmarkValues(benchmarkId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read benchmark values")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	converter, err := fx.LoadConverter()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot load fx rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	points, flows, err := readPortfolio(converter, filter, args)
+	if errors.Is(err, fx.ErrNoRate) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read portfolio history")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	comparison := compareWithBenchmark(pointsSince(points, since), flows, values)
+	if len(comparison.Points) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	comparison.Benchmark = benchmarkName
+
+	jsonBody, err := json.Marshal(comparison)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal benchmark comparison")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Align the portfolio with the benchmark, from the first day both are known. A missing benchmark value
+// (week-end, holiday) is the previous one. Values are sorted by date
+func compareWithBenchmark(points []HistoryValuePoint, flows []cashFlow, values []BenchmarkValue) BenchmarkComparison {
+
+	var comparison BenchmarkComparison
+
+	// Benchmark value for each portfolio day, 0 while unknown
+	aligned := make([]float64, len(points))
+	next := 0
+	var last float64
+	for i, point := range points {
+		day := point.DateValuation.Format("2006-01-02")
+		for next < len(values) && values[next].Date <= day {
+			last = values[next].Value
+			next++
+		}
+		aligned[i] = last
+	}
+
+	start := 0
+	for start < len(points) && aligned[start] == 0 {
+		start++
+	}
+	if start >= len(points) {
+		return comparison
+	}
+
+	growth := growthIndex(points[start:], flows)
+	for i, point := range points[start:] {
+		portfolio := growth[i] * 100
+		benchmark := aligned[start+i] / aligned[start] * 100
+		comparison.Points = append(comparison.Points, BenchmarkPoint{
+			Date:      point.DateValuation.Format("2006-01-02"),
+			Portfolio: portfolio,
+			Benchmark: benchmark,
+			Relative:  portfolio/benchmark - 1,
+		})
+	}
+
+	end := comparison.Points[len(comparison.Points)-1]
+	comparison.Portfolio_return = end.Portfolio/100 - 1
+	comparison.Benchmark_return = end.Benchmark/100 - 1
+	comparison.Relative = end.Relative
+
+	return comparison
+}
+
+// Read "date,value" lines. A header line and lines starting with # are ignored
+func parseBenchmarkCsv(r io.Reader) ([]BenchmarkValue, error) {
+
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var values []BenchmarkValue
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected date,value", line)
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: wrong value '%s'", line, record[1])
+		}
+		values = append(values, BenchmarkValue{Date: strings.TrimSpace(record[0]), Value: value})
+	}
+
+	return values, nil
+}
+
+// Store today's value of every benchmark with an ISIN code or a stock symbol
+func RefreshBenchmarks(ctx context.Context, provider PriceProvider) error {
+
+	benchmarks, err := readBenchmarks()
+	if err != nil {
+		return err
+	}
+
+	var instruments []Instrument
+	for _, benchmark := range benchmarks {
+		instrument := Instrument{Code: benchmark.Code, Stock_symbol: benchmark.Stock_symbol}
+		if instrument.Key() != "" {
+			instruments = append(instruments, instrument)
+		}
+	}
+	if len(instruments) == 0 {
+		return nil
+	}
+
+	quotes, err := provider.Quotes(ctx, instruments)
+	if err != nil {
+		return err
+	}
+
+	for _, benchmark := range benchmarks {
+		quote, ok := quotes[Instrument{Code: benchmark.Code, Stock_symbol: benchmark.Stock_symbol}.Key()]
+		if !ok {
+			continue
+		}
+		value := BenchmarkValue{Date: quote.Date.Format("2006-01-02"), Value: quote.Price.Float64()}
+		if err := saveBenchmarkValues(benchmark.Benchmark_id, []BenchmarkValue{value}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readBenchmarks() ([]Benchmark, error) {
+
+	var benchmarks []Benchmark
+
+	var query string = "SELECT benchmark_id, benchmark_name, invest_code, stock_symbol FROM benchmark ORDER BY benchmark_name"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var benchmark Benchmark
+		if err := rows.Scan(&benchmark.Benchmark_id, &benchmark.Name, &benchmark.Code, &benchmark.Stock_symbol); err != nil {
+			return nil, err
+		}
+		benchmarks = append(benchmarks, benchmark)
+	}
+
+	return benchmarks, rows.Err()
+}
+
+func readBenchmarkValues(benchmarkId int) ([]BenchmarkValue, error) {
+
+	var values []BenchmarkValue
+
+	var query string = "SELECT value_date, value FROM benchmarkValue WHERE benchmark_id=? ORDER BY value_date"
+	rows, err := config.DB.Query(query, benchmarkId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value BenchmarkValue
+		if err := rows.Scan(&value.Date, &value.Value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+func saveBenchmarkValues(benchmarkId int, values []BenchmarkValue) error {
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO benchmarkValue (benchmark_id, value_date, value) VALUES "
+	vals := []any{}
+	for _, value := range values {
+		query += "(?, ?, ?),"
+		vals = append(vals, benchmarkId, value.Date, value.Value)
+	}
+	query = query[0 : len(query)-1]
+
+	// if duplicate entry, update the field by the new value
+	query += " AS new(a, b, Nvalue) ON DUPLICATE KEY UPDATE value=Nvalue"
+
+	_, err := config.DB.Exec(query, vals...)
+	return err
+}
@@ -0,0 +1,61 @@
+package investment
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"financialApp/money"
+)
+
+func TestCompareWithBenchmark(t *testing.T) {
+
+	points := []HistoryValuePoint{
+		{DateValuation: day("2024-01-05"), Valuation: money.MustParse("1000")},
+		{DateValuation: day("2024-01-06"), Valuation: money.MustParse("1000")},
+		{DateValuation: day("2024-01-07"), Valuation: money.MustParse("1650")}, // 500 deposited this day
+	}
+	flows := []cashFlow{{date: day("2024-01-07"), amount: money.MustParse("500")}}
+
+	values := []BenchmarkValue{
+		{Date: "2024-01-04", Value: 200},
+		{Date: "2024-01-07", Value: 220},
+	}
+
+	comparison := compareWithBenchmark(points, flows, values)
+
+	if len(comparison.Points) != 3 {
+		t.Fatalf("points = %+v", comparison.Points)
+	}
+	// 2024-01-06 has no benchmark value: the one of the 4th is kept
+	if comparison.Points[1].Benchmark != 100 {
+		t.Errorf("benchmark on a missing day = %v, want 100", comparison.Points[1].Benchmark)
+	}
+	if math.Abs(comparison.Portfolio_return-0.15) > 1e-9 || math.Abs(comparison.Benchmark_return-0.1) > 1e-9 {
+		t.Errorf("returns = %v, %v, want 0.15, 0.1", comparison.Portfolio_return, comparison.Benchmark_return)
+	}
+	if math.Abs(comparison.Relative-(1.15/1.1-1)) > 1e-9 {
+		t.Errorf("relative = %v", comparison.Relative)
+	}
+
+	// the benchmark starts after the portfolio: the comparison starts with it
+	comparison = compareWithBenchmark(points, flows, values[1:])
+	if len(comparison.Points) != 1 || comparison.Points[0].Date != "2024-01-07" {
+		t.Errorf("points = %+v", comparison.Points)
+	}
+}
+
+func TestParseBenchmarkCsv(t *testing.T) {
+
+	values, err := parseBenchmarkCsv(strings.NewReader("date,close\n# MSCI World\n2024-01-02,3150.5\n2024-01-03, 3160\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0].Date != "2024-01-02" || values[0].Value != 3150.5 || values[1].Value != 3160 {
+		t.Errorf("values = %+v", values)
+	}
+
+	if _, err := parseBenchmarkCsv(strings.NewReader("2024-01-02,3150.5\n2024-01-03,n/a\n")); err == nil {
+		t.Error("a wrong value is accepted")
+	}
+}
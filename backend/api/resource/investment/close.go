@@ -0,0 +1,120 @@
+package investment
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Close the positions of an account which are absent from the invests sent by Powens: they have been sold or swapped.
+// Closed positions keep their last state and their history, but are excluded from current views.
+// gains holds the result of the positions fully sold by recorded operations, by ISIN or by label without ISIN
+func ClosePositions(accountId int, synced []Investment, closeDate string, gains map[string]money.Amount) error {
+
+	var open []Investment
+
+	var query string = "SELECT invest_id, invest_label, invest_code, quantity, unit_price, valuation FROM invest WHERE account_id=? AND closed_date IS NULL"
+	rows, err := config.DB.Query(query, accountId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var investment Investment
+		if err := rows.Scan(&investment.Invest_id, &investment.Label, &investment.Code, &investment.Quantity, &investment.Unit_price, &investment.Valuation); err != nil {
+			return err
+		}
+		open = append(open, investment)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Every position is closed, or none if a query fails
+	dbTx, err := config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+
+	if err := closePositions(dbTx, open, synced, closeDate, gains); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// Close the open positions which are not synced, with the result of their recorded sales,
+// or their realized result without them
+func closePositions(db execer, open []Investment, synced []Investment, closeDate string, gains map[string]money.Amount) error {
+
+	for _, position := range open {
+		if slices.ContainsFunc(synced, func(invest Investment) bool { return invest.Invest_id == position.Invest_id }) {
+			continue
+		}
+
+		key := position.Code
+		if key == "" {
+			key = position.Label
+		}
+		result, ok := gains[key]
+		if !ok {
+			result = realizedResult(position)
+		}
+
+		query := "UPDATE invest SET closed_date=?, realized_result=? WHERE invest_id=?"
+		if _, err := db.Exec(query, closeDate, result, position.Invest_id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Without recorded sales the price is unknown: the position is sold at its last valuation,
+// and the result is this valuation minus what the quantity held cost
+func realizedResult(position Investment) money.Amount {
+	return position.Valuation - position.Unit_price.Mul(float64(position.Quantity))
+}
+
+// Get closed positions, most recently closed first
+func GetClosedInvestments(w http.ResponseWriter, r *http.Request) {
+
+	var investments []Investment
+
+	var query string = "SELECT invest.invest_id, invest.account_id, invest.invest_label, invest.invest_code, invest.invest_code_type, invest.stock_symbol, invest.quantity, invest.unit_price, invest.unit_value, invest.valuation, invest.diff, invest.diff_percent, invest.last_update, invest.price_source, invest.closed_date, COALESCE(invest.realized_result, 0), bankAccount.bank_original_name, bankAccount.original_name FROM invest INNER JOIN bankAccount ON invest.account_id = bankAccount.account_id WHERE invest.closed_date IS NOT NULL ORDER BY invest.closed_date DESC"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var investment Investment
+		if err := rows.Scan(&investment.Invest_id, &investment.Account_id, &investment.Label, &investment.Code, &investment.Code_type, &investment.Stock_symbol, &investment.Quantity, &investment.Unit_price, &investment.Unit_value, &investment.Valuation, &investment.Diff, &investment.Diff_percent, &investment.Last_update, &investment.Price_source, &investment.Closed_date, &investment.Realized_result, &investment.BankOriginalName, &investment.OriginalName); err != nil {
+			config.Logger.Error().Err(err).Msg("Cannot scan row")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		investments = append(investments, investment)
+	}
+	if err := rows.Err(); err != nil {
+		config.Logger.Error().Err(err).Msg("Error in rows")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(investments)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal investments")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
@@ -0,0 +1,70 @@
+package investment
+
+import (
+	"database/sql"
+	"testing"
+
+	"financialApp/money"
+)
+
+type recordedExec struct {
+	query string
+	args  []any
+}
+
+type recordingExecer struct {
+	execs []recordedExec
+}
+
+func (e *recordingExecer) Exec(query string, args ...any) (sql.Result, error) {
+	e.execs = append(e.execs, recordedExec{query: query, args: args})
+	return nil, nil
+}
+
+func TestClosePositions(t *testing.T) {
+
+	open := []Investment{
+		{Invest_id: 11, Code: "FR0000120271", Quantity: 10, Unit_price: money.MustParse("50"), Valuation: money.MustParse("620")},
+		{Invest_id: 12, Label: "Fonds euros", Quantity: 4, Unit_price: money.MustParse("100"), Valuation: money.MustParse("380")},
+		{Invest_id: 13, Code: "FR0000131104", Quantity: 1, Unit_price: money.MustParse("10"), Valuation: money.MustParse("12")},
+	}
+	synced := []Investment{{Invest_id: 13}, {Invest_id: 14}}
+
+	db := &recordingExecer{}
+	if err := closePositions(db, open, synced, "2024-03-10", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sold at the last valuation: 620 - 10 x 50 and 380 - 4 x 100
+	if len(db.execs) != 2 {
+		t.Fatalf("execs = %+v", db.execs)
+	}
+	for i, want := range []struct {
+		id     int
+		result string
+	}{{11, "120.00"}, {12, "-20.00"}} {
+		args := db.execs[i].args
+		if len(args) != 3 || args[0] != "2024-03-10" || args[1].(money.Amount).StringFixed(2) != want.result || args[2] != want.id {
+			t.Errorf("close of %d: args = %v", want.id, args)
+		}
+	}
+
+	// an empty list from Powens means every position of the account has been sold
+	db = &recordingExecer{}
+	if err := closePositions(db, open, []Investment{}, "2024-03-10", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.execs) != 3 {
+		t.Errorf("execs = %+v", db.execs)
+	}
+
+	// The recorded sales give the result, the label is the key of a position without ISIN
+	db = &recordingExecer{}
+	gains := map[string]money.Amount{"FR0000120271": money.MustParse("95.5"), "Fonds euros": money.MustParse("-12")}
+	if err := closePositions(db, open, synced, "2024-03-10", gains); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.execs) != 2 || db.execs[0].args[1].(money.Amount).StringFixed(2) != "95.50" || db.execs[1].args[1].(money.Amount).StringFixed(2) != "-12.00" {
+		t.Errorf("execs = %+v", db.execs)
+	}
+}
@@ -1,25 +1,35 @@
 package investment
 
 import (
+	"cmp"
 	"database/sql"
 	"encoding/json"
 	"net/http"
 	"slices"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"financialApp/api/resource/fx"
 	"financialApp/config"
 )
 
-// Get invests ordered by valuation (DESC)
+// Get invests ordered by valuation in the base currency (DESC). Amounts are in the currency of their account,
+// valuation_base is converted to the base currency
 func GetInvestments(w http.ResponseWriter, r *http.Request) {
 
+	converter, err := fx.LoadConverter()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot load fx rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+
 	var investments []Investment
 
-	// Invest_id, Account_id, Label, Code, Code_type, Stock_symbol, Quantity, Unit_price, Unit_value, Valuation, Diff, Diff_percent, Last_update
-	var query string = "SELECT invest.invest_id, invest.account_id, invest.invest_label, invest.invest_code, invest.invest_code_type, invest.stock_symbol, invest.quantity, invest.unit_price, invest.unit_value, invest.valuation, invest.diff, invest.diff_percent, invest.last_update, bankAccount.bank_original_name, bankAccount.original_name FROM invest INNER JOIN bankAccount ON invest.account_id = bankAccount.account_id ORDER BY valuation DESC"
+	// Invest_id, Account_id, Label, Code, Code_type, Stock_symbol, Quantity, Unit_price, Unit_value, Valuation, Diff, Diff_percent, Last_update, Price_source
+	var query string = "SELECT invest.invest_id, invest.account_id, invest.invest_label, invest.invest_code, invest.invest_code_type, invest.stock_symbol, invest.quantity, invest.unit_price, invest.unit_value, invest.valuation, invest.diff, invest.diff_percent, invest.last_update, invest.price_source, bankAccount.bank_original_name, bankAccount.original_name, bankAccount.currency FROM invest INNER JOIN bankAccount ON invest.account_id = bankAccount.account_id WHERE invest.closed_date IS NULL"
 	rows, err := config.DB.Query(query)
 	if err != nil {
 		config.Logger.Error().Err(err).Msg(query)
@@ -30,11 +40,16 @@ func GetInvestments(w http.ResponseWriter, r *http.Request) {
 
 	for rows.Next() {
 		var investment Investment
-		if err := rows.Scan(&investment.Invest_id, &investment.Account_id, &investment.Label, &investment.Code, &investment.Code_type, &investment.Stock_symbol, &investment.Quantity, &investment.Unit_price, &investment.Unit_value, &investment.Valuation, &investment.Diff, &investment.Diff_percent, &investment.Last_update, &investment.BankOriginalName, &investment.OriginalName); err != nil {
+		if err := rows.Scan(&investment.Invest_id, &investment.Account_id, &investment.Label, &investment.Code, &investment.Code_type, &investment.Stock_symbol, &investment.Quantity, &investment.Unit_price, &investment.Unit_value, &investment.Valuation, &investment.Diff, &investment.Diff_percent, &investment.Last_update, &investment.Price_source, &investment.BankOriginalName, &investment.OriginalName, &investment.Currency); err != nil {
 			config.Logger.Error().Err(err).Msg("Cannot scan row")
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
+		investment.Valuation_base, err = converter.ToBase(investment.Valuation, investment.Currency, now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		investments = append(investments, investment)
 	}
 	if err := rows.Err(); err != nil {
@@ -43,6 +58,10 @@ func GetInvestments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	slices.SortStableFunc(investments, func(a, b Investment) int {
+		return cmp.Compare(b.Valuation_base, a.Valuation_base)
+	})
+
 	jsonBody, err := json.Marshal(investments)
 	if err != nil {
 		config.Logger.Error().Err(err).Msg("Cannot marshal investments")
@@ -62,7 +81,7 @@ func ReadHistoryValues(w http.ResponseWriter, r *http.Request) {
 
 	var rows *sql.Rows
 	var err error
-	query := "SELECT historyValue.bank_account_id, historyValue.valuation, historyValue.date_valuation FROM historyValue INNER JOIN bankAccount ON historyValue.bank_account_id = bankAccount.account_id AND ("
+	query := "SELECT historyValue.bank_account_id, historyValue.valuation, historyValue.date_valuation, bankAccount.currency FROM historyValue INNER JOIN bankAccount ON historyValue.bank_account_id = bankAccount.account_id AND ("
 	args := make([]any, 0)
 
 	switch period {
@@ -114,15 +133,36 @@ func ReadHistoryValues(w http.ResponseWriter, r *http.Request) {
 	}
 	defer rows.Close()
 
+	converter, err := fx.LoadConverter()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot load fx rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
 	var historyValues []HistoryValue
 
 	for rows.Next() {
 		var historyValue HistoryValue
-		if err := rows.Scan(&historyValue.BankAccountId, &historyValue.Valuation, &historyValue.DateValuation); err != nil {
+		if err := rows.Scan(&historyValue.BankAccountId, &historyValue.Valuation, &historyValue.DateValuation, &historyValue.Currency); err != nil {
 			config.Logger.Error().Err(err).Msg("Cannot scan row")
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
+
+		// Aggregated values are given in the base currency, converted with the rate of the day
+		date, err := time.Parse("2006-01-02", historyValue.DateValuation)
+		if err != nil {
+			config.Logger.Error().Err(err).Msg("Cannot parse date")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		historyValue.Valuation, err = converter.ToBase(historyValue.Valuation, historyValue.Currency, date)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
 		historyValues = append(historyValues, historyValue)
 	}
 	if err := rows.Err(); err != nil {
@@ -136,57 +176,7 @@ func ReadHistoryValues(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get every bank account id registered and remove duplicate values
-	var bankAccountIds []int
-	for _, point := range historyValues {
-		bankAccountIds = append(bankAccountIds, point.BankAccountId)
-	}
-
-	slices.Sort(bankAccountIds)
-	bankAccountIds = slices.Compact(bankAccountIds)
-
-	// Create a map: key = date, value = bank / stock / savings valuation :
-	// Points are sorted by date in the previous SQL query, so the first item has the longest history
-	// We create a firt
-	// Then, we loop with every other bankAccountId, and we sum the value for the current date
-	// The goal is to know the global (ie summed) valuation for every date
-	// MAybe issue if they don't have the same length, ie some bank accounts points do not end the same day
-
-	constructedHistoryValues := make(map[time.Time]float32)
-
-	for _, bankAccountId := range bankAccountIds {
-
-		nextHistoryValues := generateInitialValueDatePairs(bankAccountId, historyValues)
-
-		for key, value := range nextHistoryValues {
-
-			if constructedHistoryValues[key] != 0 {
-				constructedHistoryValues[key] += value
-			} else {
-				constructedHistoryValues[key] = value
-			}
-		}
-	}
-
-	var pointValues []HistoryValuePoint
-
-	// Reorder the maping by date
-	var keys []time.Time
-
-	for key := range constructedHistoryValues {
-		keys = append(keys, key)
-	}
-
-	sort.Slice(keys, func(i, j int) bool {
-		return keys[i].Before(keys[j])
-	})
-
-	for _, value := range keys {
-		pointValues = append(pointValues, HistoryValuePoint{
-			DateValuation: value,
-			Valuation:     constructedHistoryValues[value],
-		})
-	}
+	pointValues := SumHistoryValues(historyValues)
 
 	jsonBody, err := json.Marshal(pointValues)
 	if err != nil {
@@ -258,121 +248,14 @@ func ReadHistoryValue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create a list of value/date pairs.
-	constructedHistoryValues := generateInitialValueDatePairs(bankAccountId, historyValues)
-
-	var pointValues []HistoryValuePoint
-
-	// Reorder the maping by date
-	var keys []time.Time
-
-	for key := range constructedHistoryValues {
-		keys = append(keys, key)
-	}
-
-	sort.Slice(keys, func(i, j int) bool {
-		return keys[i].Before(keys[j])
-	})
-
-	for _, value := range keys {
-		pointValues = append(pointValues, HistoryValuePoint{
-			DateValuation: value,
-			Valuation:     constructedHistoryValues[value],
-		})
-	}
+	pointValues := SumHistoryValues(historyValues)
 
 	jsonBody, err := json.Marshal(pointValues)
 	if err != nil {
-		config.Logger.Error().Err(err).Msg("Cannot marshal constructedHistoryValues")
+		config.Logger.Error().Err(err).Msg("Cannot marshal pointValues")
 		http.Error(w, "", http.StatusInternalServerError)
 		return
 	}
 	w.Write(jsonBody)
 
 }
-
-// Create a list of value/date pairs from historical data for the specified bank account.
-func generateInitialValueDatePairs(bankAccountId int, historyValues []HistoryValue) map[time.Time]float32 {
-
-	mappedValues := make(map[time.Time]float32)
-
-	// Get the first value
-	var previousPoint HistoryValue
-	for index, point := range historyValues {
-		if point.BankAccountId != bankAccountId {
-			continue
-		}
-		previousPoint = historyValues[index]
-		break
-	}
-
-	for _, point := range historyValues {
-
-		// Only take into account the requested bank account id
-		if point.BankAccountId != bankAccountId {
-			continue
-		}
-
-		previousTime, err := time.Parse("2006-01-02", previousPoint.DateValuation)
-		if err != nil {
-			config.Logger.Error().Err(err).Msgf("Cannot parse date %s", point.DateValuation)
-		}
-
-		parsedTime, err := time.Parse("2006-01-02", point.DateValuation)
-		if err != nil {
-			config.Logger.Error().Err(err).Msgf("Cannot parse date %s", point.DateValuation)
-		}
-
-		daysDiff := 0
-
-		// If some days are missing (for example Powens was down for some time, or if we missed the wehbook),
-		// we fill the gap with the previously known value
-		for previousTime.Add(24 * time.Hour * time.Duration(daysDiff)).Before(parsedTime) {
-
-			mappedValues[previousTime.Add(24*time.Hour*time.Duration(daysDiff))] = previousPoint.Valuation
-
-			daysDiff += 1
-		}
-
-		previousPoint = point
-	}
-
-	// Add last value of the given bankAccountId, since we miss 1 iteration in the previous loop
-	for i := len(historyValues) - 1; i >= 0; i-- {
-
-		// We need to add the last element. Loop from the end of the list and add the correct element
-		if historyValues[i].BankAccountId != bankAccountId {
-			continue
-		}
-
-		lastTime, err := time.Parse("2006-01-02", historyValues[i].DateValuation)
-		if err != nil {
-			config.Logger.Error().Err(err).Msgf("Cannot parse date %s", historyValues[i].DateValuation)
-		}
-		mappedValues[lastTime] = historyValues[i].Valuation
-
-		extendMapToToday(lastTime, historyValues[i].Valuation, mappedValues)
-
-		break
-	}
-
-	return mappedValues
-}
-
-// Extend the mapping to today if some points are missing.
-// Extend with the last known value for every point
-func extendMapToToday(lastDate time.Time, lastValuation float32, mappedValues map[time.Time]float32) map[time.Time]float32 {
-
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-
-	daysDiff := 0
-
-	for lastDate.Add(24 * time.Hour * time.Duration(daysDiff)).Before(today) {
-
-		mappedValues[lastDate.Add(24*time.Hour*time.Duration(daysDiff+1))] = lastValuation
-		daysDiff += 1
-	}
-
-	return mappedValues
-}
@@ -0,0 +1,131 @@
+package investment
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"financialApp/config"
+)
+
+// Implemented by *sql.DB and *sql.Tx, so the history can be written inside a DB transaction
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Append the state of positions to their history. Only the last state of a day is kept
+func RecordHistory(db execer, points []InvestHistoryPoint) error {
+
+	if len(points) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO investHistory (invest_id, account_id, history_date, quantity, unit_price, unit_value, valuation) VALUES "
+	vals := []any{}
+	for _, point := range points {
+		query += "(?, ?, ?, ?, ?, ?, ?),"
+		vals = append(vals, point.Invest_id, point.Account_id, point.Date, point.Quantity, point.Unit_price, point.Unit_value, point.Valuation)
+	}
+	query = query[0 : len(query)-1]
+
+	// if duplicate entry, update the field by the new value
+	query += " AS new(a, b, c, Nquantity, Nunit_price, Nunit_value, Nvaluation)"
+	query += " ON DUPLICATE KEY UPDATE quantity=Nquantity, unit_price=Nunit_price, unit_value=Nunit_value, valuation=Nvaluation"
+
+	_, err := db.Exec(query, vals...)
+	return err
+}
+
+// Build the history points of invests sent by Powens. The day is the one of the invest last update, today if unknown
+func HistoryPoints(invests []Investment) []InvestHistoryPoint {
+
+	var points []InvestHistoryPoint
+	for _, invest := range invests {
+		points = append(points, InvestHistoryPoint{
+			Invest_id:  invest.Invest_id,
+			Account_id: invest.Account_id,
+			Date:       historyDate(invest.Last_update, time.Now()),
+			Quantity:   invest.Quantity,
+			Unit_price: invest.Unit_price,
+			Unit_value: invest.Unit_value,
+			Valuation:  invest.Valuation,
+		})
+	}
+	return points
+}
+
+// Day of a Powens date "YYYY-MM-DD HH:MM:SS"
+func historyDate(lastUpdate string, now time.Time) string {
+
+	if date, err := time.Parse("2006-01-02 15:04:05", lastUpdate); err == nil {
+		return date.Format("2006-01-02")
+	}
+	if date, err := time.Parse("2006-01-02", lastUpdate); err == nil {
+		return date.Format("2006-01-02")
+	}
+	return now.Format("2006-01-02")
+}
+
+// Returns the daily history of a position, used to chart a single asset
+func GetInvestmentHistory(w http.ResponseWriter, r *http.Request) {
+
+	investId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "id must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+
+	var since time.Time
+	switch period {
+	case "", "all":
+	case "month":
+		since = time.Now().Add(-31 * 24 * time.Hour)
+	case "year":
+		since = time.Now().Add(-365 * 24 * time.Hour)
+	default:
+		http.Error(w, "period must be all, month or year", http.StatusBadRequest)
+		return
+	}
+
+	var query string = "SELECT invest_id, account_id, history_date, quantity, unit_price, unit_value, valuation FROM investHistory WHERE invest_id=? AND history_date > ? ORDER BY history_date"
+	rows, err := config.DB.Query(query, investId, since.Format("2006-01-02"))
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var points []InvestHistoryPoint
+	for rows.Next() {
+		var point InvestHistoryPoint
+		if err := rows.Scan(&point.Invest_id, &point.Account_id, &point.Date, &point.Quantity, &point.Unit_price, &point.Unit_value, &point.Valuation); err != nil {
+			config.Logger.Error().Err(err).Msg("Cannot scan row")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		config.Logger.Error().Err(err).Msg("Error in rows")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if len(points) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	jsonBody, err := json.Marshal(points)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal invest history")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
@@ -0,0 +1,26 @@
+package investment
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistoryDate(t *testing.T) {
+
+	now := time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		lastUpdate string
+		want       string
+	}{
+		{lastUpdate: "2024-03-08 23:59:00", want: "2024-03-08"},
+		{lastUpdate: "2024-03-08", want: "2024-03-08"},
+		{lastUpdate: "", want: "2024-03-10"}, // unknown: day of the sync
+	}
+
+	for _, test := range tests {
+		if got := historyDate(test.lastUpdate, now); got != test.want {
+			t.Errorf("Wrong history date for %q: got %v want %v", test.lastUpdate, got, test.want)
+		}
+	}
+}
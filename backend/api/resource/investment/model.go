@@ -1,36 +1,115 @@
 package investment
 
-import "time"
+import (
+	"time"
+
+	"financialApp/money"
+)
 
 // Models taken from https://docs.powens.com/api-reference/products/wealth-aggregation/investments#data-model
 
 // https://docs.powens.com/api-reference/products/wealth-aggregation/investments#investment-object
 type Investment struct {
-	Invest_id        int     `json:"id"`
-	Account_id       int     `json:"id_account"`
-	Label            string  `json:"label"`
-	Code             string  `json:"code"`
-	Code_type        string  `json:"code_type"`
-	Stock_symbol     string  `json:"stock_symbol"`
-	Quantity         float32 `json:"quantity"`
-	Unit_price       float32 `json:"unitprice"`
-	Unit_value       float32 `json:"unitvalue"`
-	Valuation        float32 `json:"valuation"`
-	Diff             float32 `json:"diff"`
-	Diff_percent     float32 `json:"diff_percent"`
-	Last_update      string  `json:"last_update"`
-	BankOriginalName string  `json:"bank_original_name"` // not present in base data, field added for simplicity
-	OriginalName     string  `json:"original_name"`      // not present in base data, field added for simplicity
+	Invest_id        int          `json:"id"`
+	Account_id       int          `json:"id_account"`
+	Label            string       `json:"label"`
+	Code             string       `json:"code"`
+	Code_type        string       `json:"code_type"`
+	Stock_symbol     string       `json:"stock_symbol"`
+	Quantity         float32      `json:"quantity"`
+	Unit_price       money.Amount `json:"unitprice"`
+	Unit_value       money.Amount `json:"unitvalue"`
+	Valuation        money.Amount `json:"valuation"`
+	Diff             money.Amount `json:"diff"`
+	Diff_percent     float32      `json:"diff_percent"`
+	Last_update      string       `json:"last_update"`
+	Price_source     string       `json:"price_source"`       // not present in base data: powens, or the provider which gave the unit value
+	Closed_date      string       `json:"closed_date"`        // not present in base data: YYYY-MM-DD when the position disappeared from Powens, empty if still held
+	Realized_result  money.Amount `json:"realized_result"`    // not present in base data: result of the sale of a closed position
+	Currency         string       `json:"currency"`           // not present in base data: currency of the bank account, in which the amounts are given
+	Valuation_base   money.Amount `json:"valuation_base"`     // not present in base data: valuation converted to the base currency, to be summed across accounts
+	BankOriginalName string       `json:"bank_original_name"` // not present in base data, field added for simplicity
+	OriginalName     string       `json:"original_name"`      // not present in base data, field added for simplicity
 }
 
 type HistoryValue struct {
 	History_id    int
 	BankAccountId int
-	Valuation     float32
+	Valuation     money.Amount
 	DateValuation string
+	Currency      string // currency of the bank account, used to convert aggregated values
+}
+
+// Valuations of a group of accounts or of an account. Name is the group, or the account id
+type HistorySeries struct {
+	Name       string        `json:"name"`
+	Account_id int           `json:"id_account,omitempty"`
+	Points     []SeriesPoint `json:"points"`
+}
+
+// Value of a day, or of the period ending at this day
+type SeriesPoint struct {
+	Date  string       `json:"date"` // YYYY-MM-DD
+	Value money.Amount `json:"value"`
 }
 
 type HistoryValuePoint struct {
-	Valuation     float32
+	Valuation     money.Amount
 	DateValuation time.Time
 }
+
+// State of a position at the end of a day, recorded on each sync
+type InvestHistoryPoint struct {
+	Invest_id  int          `json:"id_invest"`
+	Account_id int          `json:"id_account"`
+	Date       string       `json:"date"` // YYYY-MM-DD
+	Quantity   float32      `json:"quantity"`
+	Unit_price money.Amount `json:"unitprice"`
+	Unit_value money.Amount `json:"unitvalue"`
+	Valuation  money.Amount `json:"valuation"`
+}
+
+// Returns over a period, as ratios: 0.05 for 5%
+type PeriodReturn struct {
+	Period string  `json:"period"` // ytd, 1y, 3y, 5y or inception
+	Start  string  `json:"start"`  // YYYY-MM-DD, first valuation of the period
+	Twr    float64 `json:"twr"`    // time-weighted return, cumulative over the period
+	Mwr    float64 `json:"mwr"`    // money-weighted return (XIRR), annualized
+}
+
+// Performance of an account, of a group of account types or of the whole portfolio, in the base currency
+type Performance struct {
+	Currency  string         `json:"currency"`
+	Valuation money.Amount   `json:"valuation"`
+	Periods   []PeriodReturn `json:"periods"`
+}
+
+// Index or fund the portfolio is compared with. Its values are imported, or priced by the invest price provider
+// when an ISIN code or a stock symbol is set
+type Benchmark struct {
+	Benchmark_id int    `json:"id"`
+	Name         string `json:"name"`
+	Code         string `json:"code"`
+	Stock_symbol string `json:"stock_symbol"`
+}
+
+type BenchmarkValue struct {
+	Date  string  `json:"date"` // YYYY-MM-DD
+	Value float64 `json:"value"`
+}
+
+// Portfolio and benchmark normalized to 100 at the start of the period
+type BenchmarkPoint struct {
+	Date      string  `json:"date"`
+	Portfolio float64 `json:"portfolio"`
+	Benchmark float64 `json:"benchmark"`
+	Relative  float64 `json:"relative"` // portfolio over benchmark minus 1: 0.02 if the portfolio did 2% better
+}
+
+type BenchmarkComparison struct {
+	Benchmark        string           `json:"benchmark"`
+	Portfolio_return float64          `json:"portfolio_return"`
+	Benchmark_return float64          `json:"benchmark_return"`
+	Relative         float64          `json:"relative"`
+	Points           []BenchmarkPoint `json:"points"`
+}
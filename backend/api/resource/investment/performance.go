@@ -0,0 +1,378 @@
+package investment
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"financialApp/api/resource/fx"
+	"financialApp/api/resource/incomerule"
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// https://docs.powens.com/api-reference/products/data-aggregation/bank-account-types#accounttypename-values
+// Account types holding stocks and funds, the whole portfolio when no account nor type is requested
+const stockAccountTypes = "article83,capitalisation,crowdlending,lifeinsurance,madelin,market,pea,pee,per,perco,perp,rsp"
+
+// Powens tx types which move money inside an account (buy or sell a position), they are not deposits nor withdrawals
+var internalTxTypes = []string{"market_order", "market_fee"}
+
+type cashFlow struct {
+	date   time.Time
+	amount money.Amount // positive for a deposit in the account, negative for a withdrawal
+}
+
+// Returns the performance of an account (?account=id), of account types (?type=pea,market) or of the whole portfolio
+func GetPerformance(w http.ResponseWriter, r *http.Request) {
+
+	filter, args, err := scopeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	converter, err := fx.LoadConverter()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot load fx rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	points, flows, err := readPortfolio(converter, filter, args)
+	if errors.Is(err, fx.ErrNoRate) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read portfolio history")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if len(points) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	performance := Performance{
+		Currency:  converter.Base(),
+		Valuation: points[len(points)-1].Valuation,
+		Periods:   periodReturns(points, flows, time.Now()),
+	}
+
+	jsonBody, err := json.Marshal(performance)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal performance")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Build the condition on bankAccount selecting an account (?account=id), account types (?type=pea,market)
+// or, by default, every account holding stocks and funds
+func scopeFilter(r *http.Request) (string, []any, error) {
+
+	if account := r.URL.Query().Get("account"); account != "" {
+		accountId, err := strconv.Atoi(account)
+		if err != nil {
+			return "", nil, errors.New("account must be an integer")
+		}
+		return "bankAccount.account_id=?", []any{accountId}, nil
+	}
+
+	accountType := r.URL.Query().Get("type")
+	if accountType == "" {
+		accountType = stockAccountTypes
+	}
+
+	filter := "bankAccount.account_type IN ("
+	args := []any{}
+	for _, arg := range strings.Split(accountType, ",") {
+		filter += "?,"
+		args = append(args, arg)
+	}
+	filter = filter[0:len(filter)-1] + ")"
+
+	return filter, args, nil
+}
+
+// Read the daily valuations, summed over the accounts matching the filter, and their deposits and withdrawals.
+// Every amount is converted to the base currency
+func readPortfolio(converter *fx.Converter, filter string, args []any) ([]HistoryValuePoint, []cashFlow, error) {
+
+	var query string = "SELECT historyValue.bank_account_id, historyValue.valuation, historyValue.date_valuation, bankAccount.currency FROM historyValue INNER JOIN bankAccount ON historyValue.bank_account_id = bankAccount.account_id WHERE " + filter + " ORDER BY historyValue.date_valuation"
+	rows, err := config.DB.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var historyValues []HistoryValue
+	for rows.Next() {
+		var historyValue HistoryValue
+		if err := rows.Scan(&historyValue.BankAccountId, &historyValue.Valuation, &historyValue.DateValuation, &historyValue.Currency); err != nil {
+			return nil, nil, err
+		}
+
+		date, err := time.Parse("2006-01-02", historyValue.DateValuation)
+		if err != nil {
+			return nil, nil, err
+		}
+		historyValue.Valuation, err = converter.ToBase(historyValue.Valuation, historyValue.Currency, date)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		historyValues = append(historyValues, historyValue)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(historyValues) == 0 {
+		return nil, nil, nil
+	}
+
+	// Dividends, coupons and interests paid into the account are part of the return, not deposits
+	rules, err := incomerule.ReadRules()
+	if err != nil {
+		return nil, nil, err
+	}
+	tags, err := incomerule.ReadTags()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query = "SELECT tx.tx_id, tx.account_id, tx.tx_date, tx.tx_value, tx.tx_type, tx.original_wording, bankAccount.currency FROM tx INNER JOIN bankAccount ON tx.account_id = bankAccount.account_id WHERE " + filter + " ORDER BY tx.tx_date"
+	txRows, err := config.DB.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer txRows.Close()
+
+	var flows []cashFlow
+	for txRows.Next() {
+		var tx incomerule.Tx
+		var txId int
+		var txDate, currency string
+		if err := txRows.Scan(&txId, &tx.Account_id, &txDate, &tx.Value, &tx.Transaction_type, &tx.Original_wording, &currency); err != nil {
+			return nil, nil, err
+		}
+		if isInternalTx(tx.Transaction_type) {
+			continue
+		}
+		var tag *incomerule.Tag
+		if t, ok := tags[txId]; ok {
+			tag = &t
+		}
+		if _, ok := incomerule.Classify(tx, tag, rules); ok {
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", historyDate(txDate, time.Now()))
+		if err != nil {
+			return nil, nil, err
+		}
+		amount, err := converter.ToBase(tx.Value, currency, date)
+		if err != nil {
+			return nil, nil, err
+		}
+		flows = append(flows, cashFlow{date: date, amount: amount})
+	}
+	if err := txRows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return SumHistoryValues(historyValues), flows, nil
+}
+
+func isInternalTx(txType string) bool {
+	for _, internal := range internalTxTypes {
+		if txType == internal {
+			return true
+		}
+	}
+	return false
+}
+
+// Compute the returns over YTD, 1, 3 and 5 years, and since the first known valuation.
+// A period is skipped if the history does not go back to its start
+func periodReturns(points []HistoryValuePoint, flows []cashFlow, now time.Time) []PeriodReturn {
+
+	var returns []PeriodReturn
+
+	if len(points) == 0 {
+		return returns
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	inception := points[0].DateValuation
+
+	periods := []struct {
+		name  string
+		start time.Time
+	}{
+		{name: "ytd", start: time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "1y", start: today.AddDate(-1, 0, 0)},
+		{name: "3y", start: today.AddDate(-3, 0, 0)},
+		{name: "5y", start: today.AddDate(-5, 0, 0)},
+		{name: "inception", start: inception},
+	}
+
+	for _, period := range periods {
+
+		if period.start.Before(inception) {
+			continue
+		}
+
+		window := pointsSince(points, period.start)
+		if len(window) < 2 {
+			continue
+		}
+
+		periodReturn := PeriodReturn{
+			Period: period.name,
+			Start:  window[0].DateValuation.Format("2006-01-02"),
+			Twr:    timeWeightedReturn(window, flows),
+		}
+		if mwr, ok := xirr(investorFlows(window, flows)); ok {
+			periodReturn.Mwr = mwr
+		}
+		returns = append(returns, periodReturn)
+	}
+
+	return returns
+}
+
+// Points dated on or after the start, points are sorted by date
+func pointsSince(points []HistoryValuePoint, start time.Time) []HistoryValuePoint {
+	for index, point := range points {
+		if !point.DateValuation.Before(start) {
+			return points[index:]
+		}
+	}
+	return nil
+}
+
+// Cumulative return over the points, ie 0.05 for 5%
+func timeWeightedReturn(points []HistoryValuePoint, flows []cashFlow) float64 {
+
+	growth := growthIndex(points, flows)
+	if len(growth) == 0 {
+		return 0
+	}
+	return growth[len(growth)-1] - 1
+}
+
+// Chain the daily returns, each one neutralized from the deposits and withdrawals of the day.
+// Returns the growth of 1 invested at the first point, for every point
+func growthIndex(points []HistoryValuePoint, flows []cashFlow) []float64 {
+
+	flowsByDay := make(map[time.Time]money.Amount)
+	for _, flow := range flows {
+		flowsByDay[flow.date] += flow.amount
+	}
+
+	var index []float64
+	growth := 1.0
+	for i := range points {
+
+		if i > 0 && !points[i-1].Valuation.IsZero() {
+			// Nothing invested yet if the previous valuation is zero, a return cannot be computed
+			flow := flowsByDay[points[i].DateValuation]
+			growth *= points[i].Valuation.Sub(flow).Div(points[i-1].Valuation)
+		}
+		index = append(index, growth)
+	}
+
+	return index
+}
+
+// Cash flows seen by the investor over the points: the starting valuation is invested, deposits are paid,
+// withdrawals are received, and the final valuation is received at the end
+func investorFlows(points []HistoryValuePoint, flows []cashFlow) []cashFlow {
+
+	start := points[0].DateValuation
+	end := points[len(points)-1].DateValuation
+
+	investor := []cashFlow{{date: start, amount: points[0].Valuation.Neg()}}
+	for _, flow := range flows {
+		if flow.date.After(start) && !flow.date.After(end) {
+			investor = append(investor, cashFlow{date: flow.date, amount: flow.amount.Neg()})
+		}
+	}
+	investor = append(investor, cashFlow{date: end, amount: points[len(points)-1].Valuation})
+
+	return investor
+}
+
+// Annual rate cancelling the net present value of the flows, found by bisection.
+// Returns false if there is no such rate, for example if every flow has the same sign
+func xirr(flows []cashFlow) (float64, bool) {
+
+	if len(flows) < 2 {
+		return 0, false
+	}
+
+	first := flows[0].date
+	npv := func(rate float64) float64 {
+		var sum float64
+		for _, flow := range flows {
+			years := flow.date.Sub(first).Hours() / 24 / 365
+			sum += flow.amount.Float64() / math.Pow(1+rate, years)
+		}
+		return sum
+	}
+
+	low, high := -0.9999, 1.0
+	for npv(low)*npv(high) > 0 {
+		high *= 2
+		if high > 1e6 {
+			return 0, false
+		}
+	}
+
+	for range 200 {
+		middle := (low + high) / 2
+		if npv(low)*npv(middle) <= 0 {
+			high = middle
+		} else {
+			low = middle
+		}
+	}
+
+	return (low + high) / 2, true
+}
+
+// Sum the values of the accounts for each day. Each account has one value per day, written by the daily snapshot
+func SumHistoryValues(historyValues []HistoryValue) []HistoryValuePoint {
+
+	var points []HistoryValuePoint
+	indexes := make(map[string]int)
+	for _, value := range historyValues {
+		index, ok := indexes[value.DateValuation]
+		if !ok {
+			date, err := time.Parse("2006-01-02", value.DateValuation)
+			if err != nil {
+				config.Logger.Error().Err(err).Msgf("Cannot parse date %s", value.DateValuation)
+				continue
+			}
+			points = append(points, HistoryValuePoint{DateValuation: date})
+			index = len(points) - 1
+			indexes[value.DateValuation] = index
+		}
+		points[index].Valuation += value.Valuation
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].DateValuation.Before(points[j].DateValuation)
+	})
+
+	return points
+}
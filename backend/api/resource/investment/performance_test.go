@@ -0,0 +1,65 @@
+package investment
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"financialApp/money"
+)
+
+func day(s string) time.Time {
+	date, _ := time.Parse("2006-01-02", s)
+	return date
+}
+
+func TestTimeWeightedReturn(t *testing.T) {
+
+	points := []HistoryValuePoint{
+		{DateValuation: day("2024-01-01"), Valuation: money.MustParse("100")},
+		{DateValuation: day("2024-01-02"), Valuation: money.MustParse("110")},
+		{DateValuation: day("2024-01-03"), Valuation: money.MustParse("170")}, // 50 deposited this day
+	}
+	flows := []cashFlow{{date: day("2024-01-03"), amount: money.MustParse("50")}}
+
+	// 10% then 120/110: the deposit does not count as a gain
+	if got := timeWeightedReturn(points, flows); math.Abs(got-0.2) > 1e-9 {
+		t.Errorf("timeWeightedReturn = %v, want 0.2", got)
+	}
+}
+
+func TestXirr(t *testing.T) {
+
+	flows := []cashFlow{
+		{date: day("2023-01-01"), amount: money.MustParse("-1000")},
+		{date: day("2024-01-01"), amount: money.MustParse("1100")},
+	}
+	rate, ok := xirr(flows)
+	if !ok || math.Abs(rate-0.1) > 1e-6 {
+		t.Errorf("xirr = %v, %v, want 0.1", rate, ok)
+	}
+
+	// Only outflows: no rate can cancel the value
+	if _, ok := xirr([]cashFlow{flows[0], flows[0]}); ok {
+		t.Error("xirr found a rate for flows of the same sign")
+	}
+}
+
+func TestPeriodReturns(t *testing.T) {
+
+	points := []HistoryValuePoint{
+		{DateValuation: day("2024-06-01"), Valuation: money.MustParse("100")},
+		{DateValuation: day("2025-01-01"), Valuation: money.MustParse("100")},
+		{DateValuation: day("2025-03-01"), Valuation: money.MustParse("105")},
+	}
+
+	returns := periodReturns(points, nil, day("2025-03-01"))
+
+	// the history does not go back 1, 3 nor 5 years
+	if len(returns) != 2 || returns[0].Period != "ytd" || returns[1].Period != "inception" {
+		t.Fatalf("periodReturns = %+v", returns)
+	}
+	if math.Abs(returns[0].Twr-0.05) > 1e-9 || returns[0].Start != "2025-01-01" {
+		t.Errorf("ytd = %+v", returns[0])
+	}
+}
@@ -0,0 +1,246 @@
+package investment
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// An instrument to price, identified by its ISIN code and/or its stock symbol
+type Instrument struct {
+	Code         string
+	Stock_symbol string
+}
+
+// Key used to store and look up prices: the ISIN code if known, the stock symbol otherwise
+func (i Instrument) Key() string {
+	if i.Code != "" {
+		return i.Code
+	}
+	return i.Stock_symbol
+}
+
+type Quote struct {
+	Price money.Amount
+	Date  time.Time
+}
+
+// Give the current price of investments between two Powens syncs
+type PriceProvider interface {
+	// Name recorded as the source of the prices
+	Name() string
+	// Return a quote for every known instrument, by instrument key. Unknown instruments are absent from the map
+	Quotes(ctx context.Context, instruments []Instrument) (map[string]Quote, error)
+}
+
+// Create the price provider set in INVEST_PRICE_PROVIDER. Return nil if prices are only given by Powens
+func NewPriceProvider(conf config.ConfInvest) (PriceProvider, error) {
+
+	switch conf.PriceProvider {
+	case "none", "":
+		return nil, nil
+	case "csv":
+		if conf.PriceCsvFile == "" {
+			return nil, errors.New("INVEST_PRICE_CSV_FILE is required by the csv price provider")
+		}
+		return &CsvProvider{Path: conf.PriceCsvFile}, nil
+	case "http":
+		if conf.PriceHttpUrl == "" {
+			return nil, errors.New("INVEST_PRICE_HTTP_URL is required by the http price provider")
+		}
+		return &HttpProvider{
+			UrlTemplate: conf.PriceHttpUrl,
+			Field:       conf.PriceHttpField,
+			Client:      &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported invest price provider '%s'. Should be none, csv or http", conf.PriceProvider)
+	}
+}
+
+// Prices read from a local CSV file, with lines "code,price" or "code,price,date".
+// The code is an ISIN or a stock symbol. A header line and lines starting with # are ignored.
+// The file is read at each refresh, so it can be updated by another tool
+type CsvProvider struct {
+	Path string
+}
+
+func (p *CsvProvider) Name() string {
+	return "csv"
+}
+
+func (p *CsvProvider) Quotes(ctx context.Context, instruments []Instrument) (map[string]Quote, error) {
+
+	file, err := os.Open(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	prices := make(map[string]Quote)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			continue
+		}
+
+		price, err := money.Parse(record[1])
+		if err != nil {
+			continue // header or malformed line
+		}
+
+		quote := Quote{Price: price, Date: time.Now()}
+		if len(record) >= 3 {
+			if date, err := time.Parse("2006-01-02", strings.TrimSpace(record[2])); err == nil {
+				quote.Date = date
+			}
+		}
+		prices[strings.TrimSpace(record[0])] = quote
+	}
+
+	quotes := make(map[string]Quote)
+	for _, instrument := range instruments {
+		if quote, ok := prices[instrument.Code]; ok && instrument.Code != "" {
+			quotes[instrument.Key()] = quote
+		} else if quote, ok := prices[instrument.Stock_symbol]; ok && instrument.Stock_symbol != "" {
+			quotes[instrument.Key()] = quote
+		}
+	}
+	return quotes, nil
+}
+
+// Prices from a JSON quote API, one request per instrument.
+// {isin} and {symbol} are replaced in the URL template, ex: https://quotes.example.com/v1/{symbol}
+// Field is the dot separated path of the price in the response, ex: "data.0.close"
+type HttpProvider struct {
+	UrlTemplate string
+	Field       string
+	Client      *http.Client
+}
+
+func (p *HttpProvider) Name() string {
+	return "http"
+}
+
+func (p *HttpProvider) Quotes(ctx context.Context, instruments []Instrument) (map[string]Quote, error) {
+
+	quotes := make(map[string]Quote)
+	var errs []error
+
+	for _, instrument := range instruments {
+
+		// Cannot build the URL without the needed identifier
+		if (strings.Contains(p.UrlTemplate, "{isin}") && instrument.Code == "") ||
+			(strings.Contains(p.UrlTemplate, "{symbol}") && instrument.Stock_symbol == "") {
+			continue
+		}
+
+		quoteUrl := strings.NewReplacer(
+			"{isin}", url.PathEscape(instrument.Code),
+			"{symbol}", url.PathEscape(instrument.Stock_symbol),
+		).Replace(p.UrlTemplate)
+
+		price, err := p.quote(ctx, quoteUrl)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", instrument.Key(), err))
+			continue
+		}
+		quotes[instrument.Key()] = Quote{Price: price, Date: time.Now()}
+	}
+
+	// A single failing instrument does not prevent to price the others
+	if len(quotes) == 0 && len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+	for _, err := range errs {
+		config.Logger.Warn().Err(err).Msg("Cannot get invest quote")
+	}
+
+	return quotes, nil
+}
+
+func (p *HttpProvider) quote(ctx context.Context, quoteUrl string) (money.Amount, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, quoteUrl, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.New("quote API returned " + resp.Status)
+	}
+
+	// Numbers are kept as text, so the price is read without going through a float
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+
+	var body any
+	if err := decoder.Decode(&body); err != nil {
+		return 0, err
+	}
+
+	return extractField(body, p.Field)
+}
+
+// Follow a dot separated path in a decoded JSON document. Numbers in the path are array indexes.
+// The price can be a JSON number or a string
+func extractField(document any, path string) (money.Amount, error) {
+
+	value := document
+	if path != "" {
+		for _, part := range strings.Split(path, ".") {
+			switch node := value.(type) {
+			case map[string]any:
+				value = node[part]
+			case []any:
+				index, err := strconv.Atoi(part)
+				if err != nil || index < 0 || index >= len(node) {
+					return 0, fmt.Errorf("wrong index '%s' in field path", part)
+				}
+				value = node[index]
+			default:
+				return 0, fmt.Errorf("field '%s' not found", path)
+			}
+		}
+	}
+
+	switch price := value.(type) {
+	case json.Number:
+		return money.Parse(price.String())
+	case float64:
+		return money.FromFloat(price), nil
+	case string:
+		return money.Parse(price)
+	default:
+		return 0, fmt.Errorf("field '%s' is not a price", path)
+	}
+}
@@ -0,0 +1,85 @@
+package investment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"financialApp/money"
+)
+
+func TestCsvProvider(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "prices.csv")
+	content := "code,price,date\n# comment\nIE00B4L5Y983,101.5,2024-01-03\nAAPL,190\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &CsvProvider{Path: path}
+	instruments := []Instrument{
+		{Code: "IE00B4L5Y983"},
+		{Code: "US0378331005", Stock_symbol: "AAPL"}, // found by its symbol
+		{Code: "FR0000000000"},
+	}
+
+	quotes, err := provider.Quotes(context.Background(), instruments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if quotes["IE00B4L5Y983"].Price != money.MustParse("101.5") || quotes["IE00B4L5Y983"].Date.Format("2006-01-02") != "2024-01-03" {
+		t.Errorf("Wrong quote for ISIN: %+v", quotes["IE00B4L5Y983"])
+	}
+	if quotes["US0378331005"].Price != money.MustParse("190") {
+		t.Errorf("Wrong quote for symbol: %+v", quotes["US0378331005"])
+	}
+	if _, ok := quotes["FR0000000000"]; ok {
+		t.Errorf("Unknown instruments must be absent: %v", quotes)
+	}
+}
+
+func TestHttpProvider(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/quote/CW8":
+			w.Write([]byte(`{"data":[{"close":"512.3"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider := &HttpProvider{UrlTemplate: server.URL + "/quote/{symbol}", Field: "data.0.close", Client: server.Client()}
+	instruments := []Instrument{
+		{Code: "LU1681043599", Stock_symbol: "CW8"},
+		{Code: "FR0000000000", Stock_symbol: "NOPE"}, // 404: ignored
+		{Code: "FR0000000001"},                       // no symbol: skipped
+	}
+
+	quotes, err := provider.Quotes(context.Background(), instruments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(quotes) != 1 || quotes["LU1681043599"].Price != money.MustParse("512.3") {
+		t.Errorf("Wrong quotes: %v", quotes)
+	}
+}
+
+func TestExtractField(t *testing.T) {
+
+	document := map[string]any{"chart": map[string]any{"result": []any{map[string]any{"price": json.Number("12.5")}}}}
+
+	if got, err := extractField(document, "chart.result.0.price"); err != nil || got != money.MustParse("12.5") {
+		t.Errorf("Wrong field: got %v, %v want 12.5", got, err)
+	}
+	if _, err := extractField(document, "chart.result.1.price"); err == nil {
+		t.Errorf("Expected an error for an out of range index")
+	}
+}
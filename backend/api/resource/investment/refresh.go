@@ -0,0 +1,172 @@
+package investment
+
+import (
+	"context"
+	"time"
+
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Invest fields needed to value it again with a new price
+type pricedInvest struct {
+	Invest_id    int
+	Account_id   int
+	Instrument   Instrument
+	Quantity     float64
+	Unit_price   money.Amount
+	Unit_value   money.Amount
+	Valuation    money.Amount
+	Diff         money.Amount
+	Diff_percent float64
+}
+
+// Start the daily balance snapshots, then create the price provider set in INVEST_PRICE_PROVIDER and refresh
+// valuations and benchmarks every INVEST_PRICE_INTERVAL. Prices are not refreshed if they are only given by Powens
+func Init() {
+
+	startSnapshots()
+
+	provider, err := NewPriceProvider(config.Conf.Invest)
+	if err != nil {
+		config.Logger.Fatal().Err(err).Msg("Cannot create invest price provider")
+	}
+	if provider == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(config.Conf.Invest.PriceInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := RefreshPrices(context.Background(), provider); err != nil {
+				config.Logger.Error().Err(err).Str("provider", provider.Name()).Msg("Invest price refresh failed")
+			}
+			if err := RefreshBenchmarks(context.Background(), provider); err != nil {
+				config.Logger.Error().Err(err).Str("provider", provider.Name()).Msg("Benchmark refresh failed")
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Value every invest with the prices given by the provider, and record these prices with their source.
+// The balance of the bank account follows the change of valuation of its invests
+func RefreshPrices(ctx context.Context, provider PriceProvider) error {
+
+	invests, err := readPricedInvests()
+	if err != nil {
+		return err
+	}
+
+	var instruments []Instrument
+	seen := make(map[string]bool)
+	for _, invest := range invests {
+		key := invest.Instrument.Key()
+		if key != "" && !seen[key] {
+			seen[key] = true
+			instruments = append(instruments, invest.Instrument)
+		}
+	}
+
+	quotes, err := provider.Quotes(ctx, instruments)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+
+	dbTx, err := config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+
+	for key, quote := range quotes {
+		var query string = "INSERT INTO investPrice (invest_code, price, price_date, source) VALUES (?, ?, ?, ?)"
+		if _, err := dbTx.Exec(query, key, quote.Price, quote.Date.Format("2006-01-02 15:04:05"), provider.Name()); err != nil {
+			return err
+		}
+	}
+
+	today := time.Now().Format("2006-01-02")
+	var points []InvestHistoryPoint
+
+	updated := 0
+	for _, invest := range invests {
+		quote, ok := quotes[invest.Instrument.Key()]
+		if !ok {
+			continue
+		}
+
+		oldValuation := invest.Valuation
+		invest = revalue(invest, quote.Price)
+
+		var query string = "UPDATE invest SET unit_value=?, valuation=?, diff=?, diff_percent=?, last_update=?, price_source=? WHERE invest_id=?"
+		if _, err := dbTx.Exec(query, invest.Unit_value, invest.Valuation, invest.Diff, invest.Diff_percent, now, provider.Name(), invest.Invest_id); err != nil {
+			return err
+		}
+
+		query = "UPDATE bankAccount SET balance=balance+? WHERE account_id=?"
+		if _, err := dbTx.Exec(query, invest.Valuation-oldValuation, invest.Account_id); err != nil {
+			return err
+		}
+		points = append(points, InvestHistoryPoint{
+			Invest_id:  invest.Invest_id,
+			Account_id: invest.Account_id,
+			Date:       today,
+			Quantity:   float32(invest.Quantity),
+			Unit_price: invest.Unit_price,
+			Unit_value: invest.Unit_value,
+			Valuation:  invest.Valuation,
+		})
+		updated++
+	}
+
+	if err := RecordHistory(dbTx, points); err != nil {
+		return err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return err
+	}
+
+	config.Logger.Info().Str("provider", provider.Name()).Int("invests", updated).Msg("Invest prices refreshed")
+	return nil
+}
+
+// Value an invest with a new unit value. Diff percent is a ratio, like the one sent by Powens
+func revalue(invest pricedInvest, price money.Amount) pricedInvest {
+
+	cost := invest.Unit_price.Mul(invest.Quantity)
+
+	invest.Unit_value = price
+	invest.Valuation = price.Mul(invest.Quantity)
+	invest.Diff = invest.Valuation - cost
+	invest.Diff_percent = invest.Diff.Div(cost)
+
+	return invest
+}
+
+func readPricedInvests() ([]pricedInvest, error) {
+
+	var invests []pricedInvest
+
+	var query string = "SELECT invest_id, account_id, invest_code, stock_symbol, quantity, unit_price, unit_value, valuation, diff, diff_percent FROM invest WHERE closed_date IS NULL"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var invest pricedInvest
+		if err := rows.Scan(&invest.Invest_id, &invest.Account_id, &invest.Instrument.Code, &invest.Instrument.Stock_symbol, &invest.Quantity, &invest.Unit_price, &invest.Unit_value, &invest.Valuation, &invest.Diff, &invest.Diff_percent); err != nil {
+			return nil, err
+		}
+		invests = append(invests, invest)
+	}
+
+	return invests, rows.Err()
+}
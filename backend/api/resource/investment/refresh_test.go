@@ -0,0 +1,18 @@
+package investment
+
+import (
+	"testing"
+
+	"financialApp/money"
+)
+
+func TestRevalue(t *testing.T) {
+
+	invest := pricedInvest{Quantity: 10, Unit_price: money.MustParse("100"), Unit_value: money.MustParse("100"), Valuation: money.MustParse("1000")}
+
+	got := revalue(invest, money.MustParse("120.15"))
+
+	if got.Unit_value != money.MustParse("120.15") || got.Valuation != money.MustParse("1201.5") || got.Diff != money.MustParse("201.5") || got.Diff_percent != 0.2015 {
+		t.Errorf("Wrong revalued invest: %+v", got)
+	}
+}
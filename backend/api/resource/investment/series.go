@@ -0,0 +1,370 @@
+package investment
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"financialApp/api/resource/fx"
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Groups which can be requested by name, other names are taken as a Powens account type
+var historyGroups = map[string]string{
+	"stocks":   stockAccountTypes,
+	"checking": "checking",
+	"savings":  "savings",
+	"crypto":   "crypto",
+}
+
+// Ways to aggregate the days of a week, month or quarter
+const (
+	aggregateLast    = "last"
+	aggregateAverage = "average"
+)
+
+var granularities = []string{"day", "week", "month", "quarter"}
+
+// Series returned when max_points is not given
+const defaultMaxPoints = 1000
+
+// Options of a series request
+type seriesOptions struct {
+	from        time.Time
+	to          time.Time
+	granularity string
+	aggregate   string
+	maxPoints   int
+}
+
+// Account valuations which are summed in a series
+type seriesSource struct {
+	series   HistorySeries
+	accounts []int
+}
+
+// Returns one series per group (?group=stocks,savings) or per account (?account=12,34), in the base currency.
+// The range is set with ?from and ?to (YYYY-MM-DD), the days are aggregated by week, month or quarter (?granularity)
+// with their last value or their average (?aggregate), and long series are downsampled to ?max_points
+func GetHistorySeries(w http.ResponseWriter, r *http.Request) {
+
+	options, err := parseSeriesOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var sources []seriesSource
+
+	if groups := r.URL.Query().Get("group"); groups != "" {
+		for _, group := range strings.Split(groups, ",") {
+			sources = append(sources, seriesSource{series: HistorySeries{Name: group}})
+		}
+	} else if accounts := r.URL.Query().Get("account"); accounts != "" {
+		for _, value := range strings.Split(accounts, ",") {
+			accountId, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "Wrong account id "+value, http.StatusBadRequest)
+				return
+			}
+			sources = append(sources, seriesSource{series: HistorySeries{Name: value, Account_id: accountId}, accounts: []int{accountId}})
+		}
+	} else {
+		http.Error(w, "group or account is required", http.StatusBadRequest)
+		return
+	}
+
+	// Find the accounts of each group
+	if sources[0].series.Account_id == 0 {
+		var query string = "SELECT account_id, account_type FROM bankAccount"
+		rows, err := config.DB.Query(query)
+		if err != nil {
+			config.Logger.Error().Err(err).Msg(query)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var accountId int
+			var accountType string
+			if err := rows.Scan(&accountId, &accountType); err != nil {
+				config.Logger.Error().Err(err).Msg("Cannot scan row")
+				http.Error(w, "", http.StatusInternalServerError)
+				return
+			}
+			for i := range sources {
+				accountTypes, ok := historyGroups[sources[i].series.Name]
+				if !ok {
+					accountTypes = sources[i].series.Name
+				}
+				if slices.Contains(strings.Split(accountTypes, ","), accountType) {
+					sources[i].accounts = append(sources[i].accounts, accountId)
+				}
+			}
+		}
+		if err := rows.Err(); err != nil {
+			config.Logger.Error().Err(err).Msg("Error in rows")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	converter, err := fx.LoadConverter()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot load fx rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	// Values before the range are needed to know the value of its first day
+	historyValues, err := readHistoryValuesUntil(converter, options.to)
+	if errors.Is(err, fx.ErrNoRate) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read history values")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	var result []HistorySeries
+	for _, source := range sources {
+		var values []HistoryValue
+		for _, value := range historyValues {
+			if slices.Contains(source.accounts, value.BankAccountId) {
+				values = append(values, value)
+			}
+		}
+
+		source.series.Points = buildSeries(values, options)
+		result = append(result, source.series)
+	}
+
+	jsonBody, err := json.Marshal(result)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal history series")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+func parseSeriesOptions(r *http.Request) (seriesOptions, error) {
+
+	now := time.Now()
+	options := seriesOptions{
+		to:          time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		granularity: "day",
+		aggregate:   aggregateLast,
+		maxPoints:   defaultMaxPoints,
+	}
+
+	query := r.URL.Query()
+	var err error
+
+	if value := query.Get("from"); value != "" {
+		if options.from, err = time.Parse("2006-01-02", value); err != nil {
+			return options, errors.New("wrong from date, must be YYYY-MM-DD")
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		if options.to, err = time.Parse("2006-01-02", value); err != nil {
+			return options, errors.New("wrong to date, must be YYYY-MM-DD")
+		}
+	}
+	if !options.from.IsZero() && options.to.Before(options.from) {
+		return options, errors.New("from must be before to")
+	}
+
+	if value := query.Get("granularity"); value != "" {
+		if !slices.Contains(granularities, value) {
+			return options, errors.New("granularity must be day, week, month or quarter")
+		}
+		options.granularity = value
+	}
+
+	if value := query.Get("aggregate"); value != "" {
+		if value != aggregateLast && value != aggregateAverage {
+			return options, errors.New("aggregate must be last or average")
+		}
+		options.aggregate = value
+	}
+
+	if value := query.Get("max_points"); value != "" {
+		if options.maxPoints, err = strconv.Atoi(value); err != nil || options.maxPoints < 1 {
+			return options, errors.New("max_points must be a positive number")
+		}
+	}
+
+	return options, nil
+}
+
+// Sum the daily values of the accounts, each day keeping the last known value of an account,
+// then aggregate them by period and downsample them. Values must be sorted by date
+func buildSeries(values []HistoryValue, options seriesOptions) []SeriesPoint {
+
+	if len(values) == 0 {
+		return []SeriesPoint{}
+	}
+
+	// Without from, the series starts with the first value
+	from := options.from
+	if from.IsZero() {
+		first, err := time.Parse("2006-01-02", values[0].DateValuation)
+		if err != nil {
+			return []SeriesPoint{}
+		}
+		from = first
+	}
+	days := int(options.to.Sub(from).Hours()/24) + 1
+	if days <= 0 {
+		return []SeriesPoint{}
+	}
+
+	// Fill each day of the range with the last value of each account. An account does not count before its first value
+	sums := make([]money.Amount, days)
+	started := make([]bool, days)
+	last := make(map[int]HistoryValue)
+	lastDay := make(map[int]int)
+
+	flush := func(accountId int, until int) {
+		value, ok := last[accountId]
+		if !ok {
+			return
+		}
+		for day := max(lastDay[accountId], 0); day < min(until, days); day++ {
+			sums[day] += value.Valuation
+			started[day] = true
+		}
+	}
+
+	for _, value := range values {
+		date, err := time.Parse("2006-01-02", value.DateValuation)
+		if err != nil {
+			continue
+		}
+		day := int(date.Sub(from).Hours() / 24)
+		flush(value.BankAccountId, day)
+		last[value.BankAccountId] = value
+		lastDay[value.BankAccountId] = day
+	}
+	for accountId := range last {
+		flush(accountId, days)
+	}
+
+	var points []SeriesPoint
+	var bucket []money.Amount
+	var bucketKey string
+	for day := 0; day < days; day++ {
+		if !started[day] {
+			continue
+		}
+		date := from.AddDate(0, 0, day)
+		key := periodKey(date, options.granularity)
+		if len(points) == 0 || key != bucketKey {
+			if len(bucket) > 0 {
+				points[len(points)-1].Value = aggregate(bucket, options.aggregate)
+				bucket = nil
+			}
+			points = append(points, SeriesPoint{})
+			bucketKey = key
+		}
+		points[len(points)-1].Date = date.Format("2006-01-02")
+		bucket = append(bucket, sums[day])
+	}
+	if len(bucket) > 0 {
+		points[len(points)-1].Value = aggregate(bucket, options.aggregate)
+	}
+
+	return downsample(points, options.maxPoints, options.aggregate)
+}
+
+// Key shared by the days of a period
+func periodKey(date time.Time, granularity string) string {
+
+	switch granularity {
+	case "week":
+		year, week := date.ISOWeek()
+		return strconv.Itoa(year) + "W" + strconv.Itoa(week)
+	case "month":
+		return date.Format("2006-01")
+	case "quarter":
+		return strconv.Itoa(date.Year()) + "Q" + strconv.Itoa((int(date.Month())-1)/3+1)
+	}
+	return date.Format("2006-01-02")
+}
+
+func aggregate(values []money.Amount, method string) money.Amount {
+
+	if method == aggregateLast {
+		return values[len(values)-1]
+	}
+
+	var sum money.Amount
+	for _, value := range values {
+		sum += value
+	}
+	return sum.Mul(1 / float64(len(values)))
+}
+
+// Merge consecutive points so that there are at most maxPoints. The last point of a series is always kept
+func downsample(points []SeriesPoint, maxPoints int, method string) []SeriesPoint {
+
+	if len(points) <= maxPoints {
+		return points
+	}
+
+	size := (len(points) + maxPoints - 1) / maxPoints
+	var sampled []SeriesPoint
+	for end := len(points); end > 0; end -= size {
+		start := max(end-size, 0)
+		var values []money.Amount
+		for _, point := range points[start:end] {
+			values = append(values, point.Value)
+		}
+		sampled = append(sampled, SeriesPoint{Date: points[end-1].Date, Value: aggregate(values, method)})
+	}
+	slices.Reverse(sampled)
+
+	return sampled
+}
+
+// Read every valuation until a date in the base currency, converted with the rate of its day
+func readHistoryValuesUntil(converter *fx.Converter, to time.Time) ([]HistoryValue, error) {
+
+	var historyValues []HistoryValue
+
+	var query string = "SELECT historyValue.bank_account_id, historyValue.valuation, historyValue.date_valuation, bankAccount.currency FROM historyValue INNER JOIN bankAccount ON historyValue.bank_account_id = bankAccount.account_id WHERE historyValue.date_valuation <= ? ORDER BY historyValue.date_valuation, historyValue.history_id"
+	rows, err := config.DB.Query(query, to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var historyValue HistoryValue
+		if err := rows.Scan(&historyValue.BankAccountId, &historyValue.Valuation, &historyValue.DateValuation, &historyValue.Currency); err != nil {
+			return nil, err
+		}
+
+		date, err := time.Parse("2006-01-02", historyValue.DateValuation)
+		if err != nil {
+			return nil, err
+		}
+		historyValue.Valuation, err = converter.ToBase(historyValue.Valuation, historyValue.Currency, date)
+		if err != nil {
+			return nil, err
+		}
+
+		historyValues = append(historyValues, historyValue)
+	}
+
+	return historyValues, rows.Err()
+}
@@ -0,0 +1,86 @@
+package investment
+
+import (
+	"testing"
+	"time"
+
+	"financialApp/money"
+)
+
+func TestBuildSeries(t *testing.T) {
+
+	values := []HistoryValue{
+		{BankAccountId: 1, Valuation: money.MustParse("100"), DateValuation: "2024-01-30"},
+		{BankAccountId: 1, Valuation: money.MustParse("110"), DateValuation: "2024-02-01"},
+		{BankAccountId: 2, Valuation: money.MustParse("50"), DateValuation: "2024-02-02"},
+		{BankAccountId: 1, Valuation: money.MustParse("130"), DateValuation: "2024-02-04"},
+	}
+	to := time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		options seriesOptions
+		want    []SeriesPoint
+	}{
+		{
+			name:    "days from the 1st, the value of the 30th is carried",
+			options: seriesOptions{from: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), to: to, granularity: "day", aggregate: aggregateLast, maxPoints: 100},
+			want: []SeriesPoint{
+				{Date: "2024-01-31", Value: money.MustParse("100")},
+				{Date: "2024-02-01", Value: money.MustParse("110")},
+				{Date: "2024-02-02", Value: money.MustParse("160")},
+				{Date: "2024-02-03", Value: money.MustParse("160")},
+				{Date: "2024-02-04", Value: money.MustParse("180")},
+				{Date: "2024-02-05", Value: money.MustParse("180")},
+			},
+		},
+		{
+			name:    "last value of each month",
+			options: seriesOptions{to: to, granularity: "month", aggregate: aggregateLast, maxPoints: 100},
+			want: []SeriesPoint{
+				{Date: "2024-01-31", Value: money.MustParse("100")},
+				{Date: "2024-02-05", Value: money.MustParse("180")},
+			},
+		},
+		{
+			name:    "average of each month",
+			options: seriesOptions{to: to, granularity: "month", aggregate: aggregateAverage, maxPoints: 100},
+			want: []SeriesPoint{
+				{Date: "2024-01-31", Value: money.MustParse("100")},
+				{Date: "2024-02-05", Value: money.MustParse("158")}, // (110 + 160 * 2 + 180 * 2) / 5
+			},
+		},
+		{
+			name:    "7 days downsampled by chunks of 3, from the last one",
+			options: seriesOptions{to: to, granularity: "day", aggregate: aggregateLast, maxPoints: 3},
+			want: []SeriesPoint{
+				{Date: "2024-01-30", Value: money.MustParse("100")},
+				{Date: "2024-02-02", Value: money.MustParse("160")},
+				{Date: "2024-02-05", Value: money.MustParse("180")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		points := buildSeries(values, tt.options)
+		if len(points) != len(tt.want) {
+			t.Errorf("%s: points = %+v", tt.name, points)
+			continue
+		}
+		for i := range points {
+			if points[i].Date != tt.want[i].Date || points[i].Value != tt.want[i].Value {
+				t.Errorf("%s: point %d = %+v, want %+v", tt.name, i, points[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestPeriodKey(t *testing.T) {
+
+	date := time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)
+	for granularity, want := range map[string]string{"day": "2024-12-30", "week": "2025W1", "month": "2024-12", "quarter": "2024Q4"} {
+		if got := periodKey(date, granularity); got != want {
+			t.Errorf("%s: key = %s, want %s", granularity, got, want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package investment
+
+import (
+	"time"
+
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Fill the gaps left in the history, record the balance of every account now, then every day after midnight
+func startSnapshots() {
+
+	go func() {
+		if err := FillHistoryGaps(); err != nil {
+			config.Logger.Error().Err(err).Msg("Cannot fill history gaps")
+		}
+		for {
+			if err := SnapshotBalances(time.Now()); err != nil {
+				config.Logger.Error().Err(err).Msg("Balance snapshot failed")
+			}
+			time.Sleep(time.Until(nextSnapshot(time.Now())))
+		}
+	}()
+}
+
+// A minute after the next midnight
+func nextSnapshot(now time.Time) time.Time {
+
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 1, 0, 0, now.Location())
+}
+
+// Write one historyValue point per account and per day: the days missing since the last known point keep
+// its value, and the point of today is the current balance of the account
+func SnapshotBalances(now time.Time) error {
+
+	today := now.Format("2006-01-02")
+
+	balances := make(map[int]money.Amount)
+	var accountIds []int
+
+	var query string = "SELECT account_id, balance FROM bankAccount"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var accountId int
+		var balance money.Amount
+		if err := rows.Scan(&accountId, &balance); err != nil {
+			return err
+		}
+		balances[accountId] = balance
+		accountIds = append(accountIds, accountId)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	lastValues := make(map[int]HistoryValue)
+
+	query = "SELECT h.bank_account_id, h.valuation, h.date_valuation FROM historyValue h INNER JOIN (SELECT bank_account_id, MAX(date_valuation) AS last_date FROM historyValue GROUP BY bank_account_id) l ON h.bank_account_id = l.bank_account_id AND h.date_valuation = l.last_date"
+	lastRows, err := config.DB.Query(query)
+	if err != nil {
+		return err
+	}
+	defer lastRows.Close()
+
+	for lastRows.Next() {
+		var historyValue HistoryValue
+		if err := lastRows.Scan(&historyValue.BankAccountId, &historyValue.Valuation, &historyValue.DateValuation); err != nil {
+			return err
+		}
+		lastValues[historyValue.BankAccountId] = historyValue
+	}
+	if err := lastRows.Err(); err != nil {
+		return err
+	}
+
+	var points []HistoryValue
+	for _, accountId := range accountIds {
+		values := []HistoryValue{}
+		if last, ok := lastValues[accountId]; ok && last.DateValuation < today {
+			values = append(values, last)
+		}
+		values = append(values, HistoryValue{BankAccountId: accountId, Valuation: balances[accountId], DateValuation: today})
+
+		// The last known point is already recorded
+		filled := DailyValues(values, today)
+		if len(values) > 1 {
+			filled = filled[1:]
+		}
+		points = append(points, filled...)
+	}
+
+	if err := RecordBalances(config.DB, points); err != nil {
+		return err
+	}
+
+	config.Logger.Info().Int("accounts", len(accountIds)).Int("points", len(points)).Msg("Balances recorded")
+	return nil
+}
+
+// Add the days missing between two points of an account, with the value of the previous point.
+// Histories written before one point was recorded every day have such gaps
+func FillHistoryGaps() error {
+
+	var query string = "SELECT bank_account_id, valuation, date_valuation FROM historyValue ORDER BY bank_account_id, date_valuation"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	accounts := make(map[int][]HistoryValue)
+	var accountIds []int
+	for rows.Next() {
+		var historyValue HistoryValue
+		if err := rows.Scan(&historyValue.BankAccountId, &historyValue.Valuation, &historyValue.DateValuation); err != nil {
+			return err
+		}
+		if _, ok := accounts[historyValue.BankAccountId]; !ok {
+			accountIds = append(accountIds, historyValue.BankAccountId)
+		}
+		accounts[historyValue.BankAccountId] = append(accounts[historyValue.BankAccountId], historyValue)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var missing []HistoryValue
+	for _, accountId := range accountIds {
+		values := accounts[accountId]
+		daily := DailyValues(values, values[len(values)-1].DateValuation)
+		if len(daily) == len(values) {
+			continue
+		}
+
+		known := make(map[string]bool)
+		for _, value := range values {
+			known[value.DateValuation] = true
+		}
+		for _, value := range daily {
+			if !known[value.DateValuation] {
+				missing = append(missing, value)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		config.Logger.Info().Int("points", len(missing)).Msg("History gaps filled")
+	}
+	return RecordBalances(config.DB, missing)
+}
+
+// Write the balance of accounts at a date. A point already recorded for the same account and day is replaced
+func RecordBalances(db execer, values []HistoryValue) error {
+
+	// Keep the statements under the placeholders limit of MySQL
+	const batchSize = 5000
+
+	for start := 0; start < len(values); start += batchSize {
+		query := "INSERT INTO historyValue (bank_account_id, valuation, date_valuation) VALUES "
+		vals := []any{}
+		for _, value := range values[start:min(start+batchSize, len(values))] {
+			query += "(?, ?, ?),"
+			vals = append(vals, value.BankAccountId, value.Valuation, value.DateValuation)
+		}
+		query = query[0 : len(query)-1]
+
+		// if duplicate entry, update the field by the new value
+		query += " AS new(a, Nvaluation, c) ON DUPLICATE KEY UPDATE valuation=Nvaluation"
+
+		if _, err := db.Exec(query, vals...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Give one value per day from the first value of an account until a date (YYYY-MM-DD), a day without value
+// keeping the previous one. Values must be sorted by date
+func DailyValues(values []HistoryValue, until string) []HistoryValue {
+
+	if len(values) == 0 {
+		return []HistoryValue{}
+	}
+
+	end, err := time.Parse("2006-01-02", until)
+	if err != nil {
+		return values
+	}
+
+	var daily []HistoryValue
+	for index, value := range values {
+		date, err := time.Parse("2006-01-02", value.DateValuation)
+		if err != nil {
+			continue
+		}
+
+		next := end.AddDate(0, 0, 1)
+		if index+1 < len(values) {
+			if nextDate, err := time.Parse("2006-01-02", values[index+1].DateValuation); err == nil {
+				next = nextDate
+			}
+		}
+
+		for ; date.Before(next) && !date.After(end); date = date.AddDate(0, 0, 1) {
+			value.DateValuation = date.Format("2006-01-02")
+			daily = append(daily, value)
+		}
+	}
+
+	return daily
+}
@@ -0,0 +1,67 @@
+package investment
+
+import (
+	"testing"
+	"time"
+
+	"financialApp/money"
+)
+
+func TestDailyValues(t *testing.T) {
+
+	values := []HistoryValue{
+		{BankAccountId: 1, Valuation: money.MustParse("100"), DateValuation: "2024-02-27"},
+		{BankAccountId: 1, Valuation: money.MustParse("150"), DateValuation: "2024-03-01"},
+	}
+
+	daily := DailyValues(values, "2024-03-03")
+	want := []struct {
+		date  string
+		value string
+	}{
+		{"2024-02-27", "100.00"},
+		{"2024-02-28", "100.00"},
+		{"2024-02-29", "100.00"},
+		{"2024-03-01", "150.00"},
+		{"2024-03-02", "150.00"},
+		{"2024-03-03", "150.00"},
+	}
+	if len(daily) != len(want) {
+		t.Fatalf("daily = %+v", daily)
+	}
+	for i, value := range daily {
+		if value.DateValuation != want[i].date || value.Valuation.StringFixed(2) != want[i].value || value.BankAccountId != 1 {
+			t.Errorf("Wrong value %d: got %+v want %v", i, value, want[i])
+		}
+	}
+
+	// Values after the end are not kept
+	if daily := DailyValues(values, "2024-02-28"); len(daily) != 2 {
+		t.Errorf("daily until the 28th = %+v", daily)
+	}
+}
+
+func TestSumHistoryValues(t *testing.T) {
+
+	values := []HistoryValue{
+		{BankAccountId: 1, Valuation: money.MustParse("100"), DateValuation: "2024-03-01"},
+		{BankAccountId: 2, Valuation: money.MustParse("50"), DateValuation: "2024-03-02"},
+		{BankAccountId: 1, Valuation: money.MustParse("110"), DateValuation: "2024-03-02"},
+	}
+
+	points := SumHistoryValues(values)
+	if len(points) != 2 {
+		t.Fatalf("points = %+v", points)
+	}
+	if got := points[1].Valuation.StringFixed(2); got != "160.00" || points[1].DateValuation.Day() != 2 {
+		t.Errorf("Wrong sum of the 2nd: got %v %s", points[1].DateValuation, got)
+	}
+}
+
+func TestNextSnapshot(t *testing.T) {
+
+	now := time.Date(2024, 12, 31, 18, 0, 0, 0, time.UTC)
+	if got, want := nextSnapshot(now), time.Date(2025, 1, 1, 0, 1, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Wrong next snapshot: got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,39 @@
+package loan
+
+import (
+	"time"
+
+	"financialApp/money"
+)
+
+// Calculate the capital which remains to be paid today, after the installments of the schedule already due.
+// The capital of a revolving credit is the amount used
+func (l Loan) OutstandingCapital() money.Amount {
+	if l.IsRevolving() {
+		return l.Used_amount
+	}
+	return outstandingAt(l.Schedule(), time.Now().Format("2006-01-02"))
+}
+
+func (l Loan) IsRevolving() bool {
+	return l.Loan_type == TypeRevolving
+}
+
+// Calculate the capital which remained to be paid at a past date, after the installments of the schedule due until then.
+// Before the subscription, the loan did not exist. The capital of a revolving credit is the amount used then
+func (l Loan) OutstandingCapitalAt(date time.Time) money.Amount {
+
+	if subscription, err := parseDate(l.Subscription_date); err == nil && date.Before(subscription) {
+		return 0
+	}
+	if l.IsRevolving() {
+		return l.usedAt(date.Format("2006-01-02"))
+	}
+
+	return outstandingAt(l.Schedule(), date.Format("2006-01-02"))
+}
+
+// Powens sends loan dates as YYYY-MM-DD or YYYY-MM-DD HH:MM:SS
+func parseDate(value string) (time.Time, error) {
+	return time.Parse("2006-01-02", value[:min(len(value), len("2006-01-02"))])
+}
@@ -0,0 +1,52 @@
+package loan
+
+import (
+	"testing"
+	"time"
+
+	"financialApp/money"
+)
+
+func TestOutstandingCapitalAt(t *testing.T) {
+
+	// Installments on the 15th from 2023-09-15
+	loan := Loan{
+		Total_amount:        money.MustParse("100000"),
+		Subscription_date:   "2023-08-15",
+		Next_payment_amount: money.MustParse("1000"),
+		Rate:                0,
+		Nb_payments_done:    10,
+		Nb_payments_total:   100,
+	}
+
+	tests := []struct {
+		date time.Time
+		want string
+	}{
+		{date: time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC), want: "90000.00"},
+		{date: time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC), want: "90000.00"},
+		{date: time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC), want: "91000.00"},
+		{date: time.Date(2024, 5, 21, 0, 0, 0, 0, time.UTC), want: "91000.00"},
+		{date: time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC), want: "100000.00"}, // before the first payment
+		{date: time.Date(2023, 6, 20, 0, 0, 0, 0, time.UTC), want: "0.00"},     // before the subscription
+	}
+
+	for _, tt := range tests {
+		if got := loan.OutstandingCapitalAt(tt.date).StringFixed(2); got != tt.want {
+			t.Errorf("%s: capital = %s, want %s", tt.date.Format("2006-01-02"), got, tt.want)
+		}
+	}
+
+	// The 4 deferred months, until the start of the repayment, refund no capital
+	loan.Deferred = true
+	loan.Start_repayment_date = "2024-01-15"
+	if got := loan.OutstandingCapitalAt(time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)).StringFixed(2); got != "94000.00" {
+		t.Errorf("deferred: capital = %s, want 94000.00", got)
+	}
+
+	// Without dates, the payments done are counted
+	loan.Subscription_date, loan.Start_repayment_date, loan.Deferred = "", "", false
+	if got := loan.OutstandingCapitalAt(time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)).StringFixed(2); got != "90000.00" {
+		t.Errorf("undated: capital = %s, want 90000.00", got)
+	}
+}
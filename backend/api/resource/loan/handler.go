@@ -9,37 +9,78 @@ import (
 
 func GetLoans(w http.ResponseWriter, r *http.Request) {
 
+	loans, err := ReadLoans()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read loans")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(loans)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal loans")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+func ReadLoans() ([]Loan, error) {
+
 	var loans []Loan
 
 	var query string = "SELECT * FROM loan"
 	rows, err := config.DB.Query(query)
 	if err != nil {
-		config.Logger.Error().Err(err).Msg(query)
-		http.Error(w, "", http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var account Loan
-		if err := rows.Scan(&account.Loan_account_id, &account.Total_amount, &account.Available_amount, &account.Used_amount, &account.Subscription_date, &account.Maturity_date, &account.Start_repayment_date, &account.Deferred, &account.Next_payment_amount, &account.Next_payment_date, &account.Rate, &account.Nb_payments_left, &account.Nb_payments_done, &account.Nb_payments_total, &account.Last_payment_amount, &account.Last_payment_date, &account.Account_label, &account.Insurance_label, &account.Insurance_amount, &account.Insurance_rate, &account.Duration, &account.Loan_type); err != nil {
-			config.Logger.Error().Err(err).Msg("Cannot scan row")
-			http.Error(w, "", http.StatusInternalServerError)
-			return
+		account, err := scanLoan(rows)
+		if err != nil {
+			return nil, err
 		}
 		loans = append(loans, account)
 	}
 	if err := rows.Err(); err != nil {
-		config.Logger.Error().Err(err).Msg("Error in rows")
-		http.Error(w, "", http.StatusInternalServerError)
-		return
+		return nil, err
 	}
+	rows.Close()
 
-	jsonBody, err := json.Marshal(loans)
+	for i := range loans {
+		if err := loans[i].loadUsages(); err != nil {
+			return nil, err
+		}
+		if err := loans[i].loadTranches(); err != nil {
+			return nil, err
+		}
+	}
+
+	return loans, nil
+}
+
+// Read the loan linked to the account loanAccountId
+func ReadLoan(loanAccountId int) (Loan, error) {
+
+	var query string = "SELECT * FROM loan WHERE loan_account_id=?"
+	row := config.DB.QueryRow(query, loanAccountId)
+
+	account, err := scanLoan(row)
 	if err != nil {
-		config.Logger.Error().Err(err).Msg("Cannot marshal loans")
-		http.Error(w, "", http.StatusInternalServerError)
-		return
+		return account, err
 	}
-	w.Write(jsonBody)
+
+	if err := account.loadUsages(); err != nil {
+		return account, err
+	}
+	return account, account.loadTranches()
+}
+
+// Scan a full row of the loan table, as returned by "SELECT * FROM loan"
+func scanLoan(row interface{ Scan(...any) error }) (Loan, error) {
+
+	var account Loan
+	err := row.Scan(&account.Loan_account_id, &account.Total_amount, &account.Available_amount, &account.Used_amount, &account.Subscription_date, &account.Maturity_date, &account.Start_repayment_date, &account.Deferred, &account.Next_payment_amount, &account.Next_payment_date, &account.Rate, &account.Nb_payments_left, &account.Nb_payments_done, &account.Nb_payments_total, &account.Last_payment_amount, &account.Last_payment_date, &account.Account_label, &account.Insurance_label, &account.Insurance_amount, &account.Insurance_rate, &account.Duration, &account.Loan_type)
+	return account, err
 }
@@ -1,5 +1,14 @@
 package loan
 
+import "financialApp/money"
+
+// Loan types sent by Powens. Revolving credits have a limit instead of a schedule
+const (
+	TypeMortgage  = "mortgage"
+	TypeConsumer  = "consumercredit"
+	TypeRevolving = "revolvingcredit"
+)
+
 // Models taken from https://docs.powens.com/api-reference/products/data-aggregation/bank-accounts#data-model
 
 // Time sent by Powens API is not RFC3339
@@ -8,26 +17,129 @@ package loan
 
 // https://docs.powens.com/api-reference/products/data-aggregation/bank-accounts#loan-object
 type Loan struct {
-	Loan_account_id      int     `json:"-"` // absent in base data, field added for simplicity
-	Total_amount         float32 `json:"total_amount"`
-	Available_amount     float32 `json:"available_amount"`
-	Used_amount          float32 `json:"used_amount"`
-	Subscription_date    string  `json:"subscription_date"`
-	Maturity_date        string  `json:"maturity_date"`
-	Start_repayment_date string  `json:"start_repayment_date"`
-	Deferred             bool    `json:"deferred"`
-	Next_payment_amount  float32 `json:"next_payment_amount"`
-	Next_payment_date    string  `json:"next_payment_date"`
-	Rate                 float32 `json:"rate"`
-	Nb_payments_left     uint    `json:"nb_payments_left"`
-	Nb_payments_done     uint    `json:"nb_payments_done"`
-	Nb_payments_total    uint    `json:"nb_payments_total"`
-	Last_payment_amount  float32 `json:"last_payment_amount"`
-	Last_payment_date    string  `json:"last_payment_date"`
-	Account_label        string  `json:"account_label"`
-	Insurance_label      string  `json:"insurance_label"`
-	Insurance_amount     float32 `json:"insurance_amount"`
-	Insurance_rate       float32 `json:"insurance_rate"`
-	Duration             uint    `json:"duration"`
-	Loan_type            string  `json:"type"`
+	Loan_account_id      int          `json:"loan_account_id"` // absent in base data, field added for simplicity
+	Total_amount         money.Amount `json:"total_amount"`
+	Available_amount     money.Amount `json:"available_amount"`
+	Used_amount          money.Amount `json:"used_amount"`
+	Subscription_date    string       `json:"subscription_date"`
+	Maturity_date        string       `json:"maturity_date"`
+	Start_repayment_date string       `json:"start_repayment_date"`
+	Deferred             bool         `json:"deferred"`
+	Next_payment_amount  money.Amount `json:"next_payment_amount"`
+	Next_payment_date    string       `json:"next_payment_date"`
+	Rate                 float32      `json:"rate"`
+	Nb_payments_left     uint         `json:"nb_payments_left"`
+	Nb_payments_done     uint         `json:"nb_payments_done"`
+	Nb_payments_total    uint         `json:"nb_payments_total"`
+	Last_payment_amount  money.Amount `json:"last_payment_amount"`
+	Last_payment_date    string       `json:"last_payment_date"`
+	Account_label        string       `json:"account_label"`
+	Insurance_label      string       `json:"insurance_label"`
+	Insurance_amount     money.Amount `json:"insurance_amount"`
+	Insurance_rate       float32      `json:"insurance_rate"`
+	Duration             uint         `json:"duration"`
+	Loan_type            string       `json:"type"`
+
+	usages   []Usage   // uses of a revolving credit, oldest first, set by ReadLoan and ReadLoans
+	tranches *Schedule // merged schedule of the tranches, set by ReadLoan and ReadLoans when the loan has some
+}
+
+// One payment of a loan. Insurance is paid on top of the capital and the interest
+type Installment struct {
+	Number              int          `json:"number"`
+	Date                string       `json:"date"` // YYYY-MM-DD
+	Payment             money.Amount `json:"payment"`
+	Capital             money.Amount `json:"capital"`
+	Interest            money.Amount `json:"interest"`
+	Insurance           money.Amount `json:"insurance"`
+	Outstanding_capital money.Amount `json:"outstanding_capital"` // after the payment
+	Deferred            bool         `json:"deferred"`            // only the interest and the insurance are paid
+	Paid                bool         `json:"paid"`
+}
+
+// Full amortization schedule of a loan, which sums the schedules of its tranches when it has some
+type Schedule struct {
+	Loan_account_id int           `json:"loan_account_id"`
+	Name            string        `json:"name,omitempty"` // name of the tranche
+	Tranches        []Schedule    `json:"tranches,omitempty"`
+	Installments    []Installment `json:"installments"`
+	Total_capital   money.Amount  `json:"total_capital"`
+	Total_interest  money.Amount  `json:"total_interest"`
+	Total_insurance money.Amount  `json:"total_insurance"`
+	Total_paid      money.Amount  `json:"total_paid"`
+}
+
+// Use of a revolving credit at a date
+type Usage struct {
+	Date             string       `json:"date"` // YYYY-MM-DD
+	Available_amount money.Amount `json:"available_amount"`
+	Used_amount      money.Amount `json:"used_amount"`
+	Utilization      float64      `json:"utilization"` // used part of the limit, in %
+}
+
+// Scenarios which can be simulated on a loan
+const (
+	ScenarioRepayment   = "repayment"
+	ScenarioRefinancing = "refinancing"
+)
+
+// After an early repayment, the installment is kept and the duration is reduced, or the opposite
+const (
+	ModeReduceDuration    = "reduce_duration"
+	ModeReduceInstallment = "reduce_installment"
+)
+
+// Indemnities paid on the capital repaid early: the legal cap (6 months of interest, at most 3% of the
+// outstanding capital), none, or a rate of the capital repaid
+const (
+	IndemnityLegal = "legal"
+	IndemnityNone  = "none"
+	IndemnityRate  = "rate"
+)
+
+// Early repayment or refinancing simulated from the next installment of a loan
+type SimulationRequest struct {
+	Scenario       string       `json:"scenario"`
+	Amount         money.Amount `json:"amount"`         // repayment: capital repaid, the whole outstanding capital if 0
+	Mode           string       `json:"mode"`           // repayment: reduce_duration (default) or reduce_installment
+	Indemnity      string       `json:"indemnity"`      // legal (default), none or rate
+	Indemnity_rate float64      `json:"indemnity_rate"` // % of the capital repaid, when indemnity is rate
+	Rate           float64      `json:"rate"`           // refinancing: yearly rate of the new loan, in %
+	Duration       uint         `json:"duration"`       // refinancing: number of payments of the new loan
+	Fees           money.Amount `json:"fees"`           // refinancing: bank and guarantee fees
+}
+
+type Simulation struct {
+	Outstanding_capital money.Amount `json:"outstanding_capital"` // before the repayment or the refinancing
+	Repaid_capital      money.Amount `json:"repaid_capital"`
+	Indemnity           money.Amount `json:"indemnity"`
+	Fees                money.Amount `json:"fees"`
+	Current             Schedule     `json:"current"` // installments left without any change
+	New                 Schedule     `json:"new"`
+	Interest_saved      money.Amount `json:"interest_saved"`
+	Net_saving          money.Amount `json:"net_saving"`       // interest and insurance saved, minus the indemnity and the fees
+	Break_even_month    int          `json:"break_even_month"` // installments before the savings cover the indemnity and the fees, 0 if they never do
+}
+
+// Part of a mortgage with its own amount, rate and duration (main loan, zero-rate loan, employer loan...)
+type Tranche struct {
+	Tranche_id         int          `json:"id"`
+	Loan_account_id    int          `json:"loan_account_id"`
+	Name               string       `json:"name"`
+	Amount             money.Amount `json:"amount"`
+	Rate               float64      `json:"rate"`     // yearly rate at the start, in %
+	Rate_cap           float64      `json:"rate_cap"` // highest rate of a variable rate, in %. 0 if there is none
+	Payments           uint         `json:"payments"` // payments which refund the capital
+	Deferred_payments  uint         `json:"deferred_payments"`
+	First_payment_date string       `json:"first_payment_date"` // YYYY-MM-DD
+	Insurance_amount   money.Amount `json:"insurance_amount"`
+	Rate_changes       []RateChange `json:"rate_changes"`
+}
+
+// New rate of a variable rate tranche, from a date
+type RateChange struct {
+	Change_id  int     `json:"id"`
+	Tranche_id int     `json:"tranche_id"`
+	Date       string  `json:"date"` // YYYY-MM-DD
+	Rate       float64 `json:"rate"`
 }
@@ -0,0 +1,220 @@
+package loan
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Get the payment schedule of a loan: capital, interest, insurance and outstanding capital of each installment.
+// The schedule of a loan split in tranches also gives the schedule of each tranche
+func GetSchedule(w http.ResponseWriter, r *http.Request) {
+
+	loanAccountId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	l, err := ReadLoan(loanAccountId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Loan does not exist", http.StatusNotFound)
+			return
+		}
+		config.Logger.Error().Err(err).Int("loan_account_id", loanAccountId).Msg("Cannot read loan")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if l.IsRevolving() {
+		http.Error(w, "A revolving credit has no schedule", http.StatusBadRequest)
+		return
+	}
+
+	jsonBody, err := json.Marshal(l.Schedule())
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal schedule")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Build every installment of the loan. A loan split in tranches is the sum of their schedules.
+// The months between the subscription and the start of the repayment of a deferred loan only pay the interest
+// and the insurance, the other ones refund the capital with a constant mensuality: the one known from Powens,
+// or the one of an annuity of the capital when it is unknown
+func (l Loan) Schedule() Schedule {
+
+	if l.tranches != nil {
+		return *l.tranches
+	}
+
+	payments := int(l.Nb_payments_total)
+	if payments == 0 {
+		payments = int(l.Duration)
+	}
+	if payments == 0 || l.Total_amount <= 0 || l.IsRevolving() {
+		return newSchedule(l.Loan_account_id, []Installment{})
+	}
+
+	_, _, deferredPayments := l.firstPayment()
+	payments = max(payments-deferredPayments, 1)
+
+	monthlyRate := float64(l.Rate) / 100 / 12
+	insurance := l.insurance()
+	mensuality := l.Next_payment_amount - l.Insurance_amount
+	if l.Next_payment_amount.IsZero() || mensuality <= 0 {
+		mensuality = annuity(l.Total_amount, monthlyRate, payments)
+	}
+
+	installments := []Installment{}
+	for range deferredPayments {
+		interest := l.Total_amount.Mul(monthlyRate)
+		installments = append(installments, Installment{
+			Payment:             interest + insurance,
+			Interest:            interest,
+			Insurance:           insurance,
+			Outstanding_capital: l.Total_amount,
+			Deferred:            true,
+		})
+	}
+	installments = append(installments, amortize(l.Total_amount, monthlyRate, mensuality, payments, insurance)...)
+
+	for i := range installments {
+		installments[i].Number = i + 1
+		installments[i].Date = l.installmentDate(i)
+		installments[i].Paid = i < int(l.Nb_payments_done)
+	}
+
+	return newSchedule(l.Loan_account_id, installments)
+}
+
+// Refund a capital with a constant mensuality (capital and interest), in a number of payments at most.
+// The last installment refunds what remains, and no installment refunds more than what remains
+func amortize(capital money.Amount, monthlyRate float64, mensuality money.Amount, payments int, insurance money.Amount) []Installment {
+
+	installments := []Installment{}
+
+	remainingCapital := capital
+	for i := 0; i < payments && remainingCapital > 0; i++ {
+		installment := Installment{
+			Interest:  remainingCapital.Mul(monthlyRate),
+			Insurance: insurance,
+		}
+
+		installment.Capital = mensuality - installment.Interest
+		if i == payments-1 || installment.Capital > remainingCapital {
+			installment.Capital = remainingCapital
+		}
+		remainingCapital -= installment.Capital
+
+		installment.Payment = installment.Capital + installment.Interest + installment.Insurance
+		installment.Outstanding_capital = remainingCapital
+
+		installments = append(installments, installment)
+	}
+
+	return installments
+}
+
+// Capital of a schedule which remains to be paid after the installments until a date (included).
+// Without the dates of the loan, the installments paid are the ones until the date
+func outstandingAt(schedule Schedule, date string) money.Amount {
+
+	outstanding := schedule.Total_capital
+	for _, installment := range schedule.Installments {
+		if installment.Date > date || installment.Date == "" && !installment.Paid {
+			break
+		}
+		outstanding = installment.Outstanding_capital
+	}
+	return outstanding
+}
+
+// Sum the installments of a schedule
+func newSchedule(loanAccountId int, installments []Installment) Schedule {
+
+	schedule := Schedule{Loan_account_id: loanAccountId, Installments: installments}
+	for _, installment := range installments {
+		schedule.Total_capital += installment.Capital
+		schedule.Total_interest += installment.Interest
+		schedule.Total_insurance += installment.Insurance
+		schedule.Total_paid += installment.Payment
+	}
+	return schedule
+}
+
+// Insurance paid with each installment, given by Powens or calculated from its rate on the initial capital
+func (l Loan) insurance() money.Amount {
+
+	if !l.Insurance_amount.IsZero() {
+		return l.Insurance_amount
+	}
+	return l.Total_amount.Mul(float64(l.Insurance_rate) / 100 / 12)
+}
+
+// Date of an installment (0 for the first one), empty if the dates of the loan are unknown
+func (l Loan) installmentDate(index int) string {
+
+	reference, offset, _ := l.firstPayment()
+	if reference.IsZero() {
+		return ""
+	}
+	return addMonths(reference, offset+index).Format("2006-01-02")
+}
+
+// Date from which the installments are counted, months between this date and the first installment, and number
+// of deferred installments. Installments start one month after the subscription; when the loan is deferred,
+// they refund the capital from the start of the repayment
+func (l Loan) firstPayment() (time.Time, int, int) {
+
+	subscription, err := parseDate(l.Subscription_date)
+	if err != nil {
+		// Without subscription date, the schedule starts with the repayment, without date if it is unknown too
+		start, err := parseDate(l.Start_repayment_date)
+		if err != nil {
+			return time.Time{}, 0, 0
+		}
+		return start, 0, 0
+	}
+
+	first := addMonths(subscription, 1)
+	if !l.Deferred {
+		return subscription, 1, 0
+	}
+
+	start, err := parseDate(l.Start_repayment_date)
+	if err != nil || !start.After(first) {
+		return subscription, 1, 0
+	}
+
+	deferredPayments := (start.Year()-first.Year())*12 + int(start.Month()) - int(first.Month())
+	return subscription, 1, max(deferredPayments, 0)
+}
+
+// Constant payment refunding a capital and its interest in a number of payments
+func annuity(capital money.Amount, monthlyRate float64, payments int) money.Amount {
+
+	if monthlyRate == 0 {
+		return capital.Mul(1 / float64(payments))
+	}
+	// m = (C*r) / (1-(1+r)^-n)
+	return capital.Mul(monthlyRate / (1 - math.Pow(1+monthlyRate, -float64(payments))))
+}
+
+// Add months to a date, keeping the last day of the month when the day does not exist (31/01 -> 28/02)
+func addMonths(date time.Time, months int) time.Time {
+
+	firstOfMonth := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), min(date.Day(), lastDay), 0, 0, 0, 0, date.Location())
+}
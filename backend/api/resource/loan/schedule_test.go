@@ -0,0 +1,83 @@
+package loan
+
+import (
+	"testing"
+	"time"
+
+	"financialApp/money"
+)
+
+func TestSchedule(t *testing.T) {
+
+	loan := Loan{
+		Total_amount:      money.MustParse("100000"),
+		Subscription_date: "2024-01-31",
+		Rate:              12,
+		Insurance_amount:  money.MustParse("10"),
+		Nb_payments_total: 12,
+		Nb_payments_done:  2,
+	}
+
+	schedule := loan.Schedule()
+	if len(schedule.Installments) != 12 {
+		t.Fatalf("installments = %+v", schedule.Installments)
+	}
+
+	first := schedule.Installments[0]
+	if first.Date != "2024-02-29" || first.Interest.StringFixed(2) != "1000.00" || first.Payment.StringFixed(2) != "8894.88" || !first.Paid {
+		t.Errorf("Wrong first installment %+v", first)
+	}
+	if schedule.Installments[2].Paid || schedule.Installments[2].Date != "2024-04-30" {
+		t.Errorf("Wrong third installment %+v", schedule.Installments[2])
+	}
+
+	last := schedule.Installments[11]
+	if !last.Outstanding_capital.IsZero() || last.Date != "2025-01-31" {
+		t.Errorf("Wrong last installment %+v", last)
+	}
+	if schedule.Total_capital != loan.Total_amount || schedule.Total_insurance.StringFixed(2) != "120.00" {
+		t.Errorf("Wrong totals %+v", schedule)
+	}
+	if got := schedule.Total_interest.StringFixed(2); got != "6618.55" {
+		t.Errorf("total interest = %s", got)
+	}
+}
+
+func TestScheduleDeferred(t *testing.T) {
+
+	loan := Loan{
+		Total_amount:         money.MustParse("1200"),
+		Subscription_date:    "2024-01-15 00:00:00",
+		Start_repayment_date: "2024-04-15 00:00:00",
+		Deferred:             true,
+		Insurance_rate:       1.2,
+		Nb_payments_total:    14,
+	}
+
+	schedule := loan.Schedule()
+	if len(schedule.Installments) != 14 {
+		t.Fatalf("installments = %+v", schedule.Installments)
+	}
+	for i, installment := range schedule.Installments {
+		if deferred := i < 2; installment.Deferred != deferred {
+			t.Errorf("installment %d: deferred = %v", installment.Number, installment.Deferred)
+		}
+		if installment.Insurance.StringFixed(2) != "1.20" {
+			t.Errorf("installment %d: insurance = %s", installment.Number, installment.Insurance.StringFixed(2))
+		}
+	}
+	if repayment := schedule.Installments[2]; repayment.Date != "2024-04-15" || repayment.Capital.StringFixed(2) != "100.00" {
+		t.Errorf("Wrong first repayment %+v", repayment)
+	}
+	if schedule.Installments[1].Outstanding_capital != loan.Total_amount {
+		t.Errorf("Capital refunded during the deferral: %+v", schedule.Installments[1])
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+
+	date := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	if got := addMonths(date, 13).Format("2006-01-02"); got != "2025-02-28" {
+		t.Errorf("31/01/2024 + 13 months = %s", got)
+	}
+}
@@ -0,0 +1,208 @@
+package loan
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Installments of a simulated repayment when the duration is reduced, to stop a mensuality which does not
+// refund the interest
+const maxSimulatedPayments = 1200
+
+// Simulate an early repayment or a refinancing of a loan, from its next installment
+func SimulateLoan(w http.ResponseWriter, r *http.Request) {
+
+	loanAccountId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var request SimulationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateSimulation(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	l, err := ReadLoan(loanAccountId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Loan does not exist", http.StatusNotFound)
+			return
+		}
+		config.Logger.Error().Err(err).Int("loan_account_id", loanAccountId).Msg("Cannot read loan")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	simulation, err := l.Simulate(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonBody, err := json.Marshal(simulation)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal simulation")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Compare the installments left with the ones after the repayment or the refinancing
+func (l Loan) Simulate(request SimulationRequest) (Simulation, error) {
+
+	if l.IsRevolving() {
+		return Simulation{}, errors.New("a revolving credit cannot be simulated")
+	}
+	// Each tranche has its own rate and duration, the simulation only knows the ones of the loan
+	if l.tranches != nil {
+		return Simulation{}, errors.New("a loan split in tranches cannot be simulated")
+	}
+
+	schedule := l.Schedule()
+	next := min(int(l.Nb_payments_done), len(schedule.Installments))
+	if next == len(schedule.Installments) {
+		return Simulation{}, errors.New("the loan is already refunded")
+	}
+
+	simulation := Simulation{
+		Outstanding_capital: l.Total_amount,
+		Current:             newSchedule(l.Loan_account_id, schedule.Installments[next:]),
+		Fees:                request.Fees,
+	}
+	if next > 0 {
+		simulation.Outstanding_capital = schedule.Installments[next-1].Outstanding_capital
+	}
+
+	monthlyRate := float64(l.Rate) / 100 / 12
+	insurance := l.insurance()
+	remainingPayments := len(simulation.Current.Installments)
+
+	var installments []Installment
+	switch request.Scenario {
+	case ScenarioRepayment:
+		simulation.Repaid_capital = request.Amount
+		if simulation.Repaid_capital.IsZero() || simulation.Repaid_capital > simulation.Outstanding_capital {
+			simulation.Repaid_capital = simulation.Outstanding_capital
+		}
+		capital := simulation.Outstanding_capital - simulation.Repaid_capital
+
+		if request.Mode == ModeReduceInstallment {
+			installments = amortize(capital, monthlyRate, annuity(capital, monthlyRate, remainingPayments), remainingPayments, insurance)
+		} else {
+			// The capital and interest part of the installments left is kept
+			mensuality := simulation.Current.Installments[0].Capital + simulation.Current.Installments[0].Interest
+			if simulation.Current.Installments[0].Deferred {
+				mensuality = annuity(simulation.Outstanding_capital, monthlyRate, remainingPayments)
+			}
+			installments = amortize(capital, monthlyRate, mensuality, maxSimulatedPayments, insurance)
+		}
+
+	case ScenarioRefinancing:
+		simulation.Repaid_capital = simulation.Outstanding_capital
+		newRate := request.Rate / 100 / 12
+		installments = amortize(simulation.Outstanding_capital, newRate, annuity(simulation.Outstanding_capital, newRate, int(request.Duration)), int(request.Duration), insurance)
+	}
+
+	for i := range installments {
+		installments[i].Number = i + 1
+		installments[i].Date = l.installmentDate(next + i)
+	}
+	simulation.New = newSchedule(l.Loan_account_id, installments)
+
+	simulation.Indemnity = indemnity(request, simulation.Repaid_capital, simulation.Outstanding_capital, float64(l.Rate))
+	simulation.Interest_saved = simulation.Current.Total_interest - simulation.New.Total_interest
+	simulation.Net_saving = simulation.Interest_saved + simulation.Current.Total_insurance - simulation.New.Total_insurance - simulation.Indemnity - simulation.Fees
+	simulation.Break_even_month = breakEvenMonth(simulation.Current.Installments, simulation.New.Installments, simulation.Indemnity+simulation.Fees)
+
+	return simulation, nil
+}
+
+// Indemnity paid on the capital repaid early. The legal one is 6 months of interest on the capital repaid,
+// at most 3% of the outstanding capital
+func indemnity(request SimulationRequest, repaidCapital, outstandingCapital money.Amount, yearlyRate float64) money.Amount {
+
+	switch request.Indemnity {
+	case IndemnityNone:
+		return 0
+	case IndemnityRate:
+		return repaidCapital.Mul(request.Indemnity_rate / 100)
+	}
+	return min(repaidCapital.Mul(yearlyRate/100/12*6), outstandingCapital.Mul(0.03))
+}
+
+// First installment at which the payments saved since the simulation cover its costs, 0 if they never do
+func breakEvenMonth(current, simulated []Installment, costs money.Amount) int {
+
+	var saved money.Amount
+	for i := range max(len(current), len(simulated)) {
+		if i < len(current) {
+			saved += current[i].Payment
+		}
+		if i < len(simulated) {
+			saved -= simulated[i].Payment
+		}
+		if saved > 0 && saved >= costs {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Check the user input and set default values
+func validateSimulation(request *SimulationRequest) error {
+
+	switch request.Scenario {
+	case ScenarioRepayment:
+		if request.Amount < 0 {
+			return errors.New("amount must be positive")
+		}
+		if request.Mode == "" {
+			request.Mode = ModeReduceDuration
+		}
+		if request.Mode != ModeReduceDuration && request.Mode != ModeReduceInstallment {
+			return errors.New("mode must be reduce_duration or reduce_installment")
+		}
+	case ScenarioRefinancing:
+		if request.Duration == 0 {
+			return errors.New("duration is required")
+		}
+		if request.Rate < 0 {
+			return errors.New("rate must be positive")
+		}
+	default:
+		return errors.New("scenario must be repayment or refinancing")
+	}
+
+	if request.Fees < 0 {
+		return errors.New("fees must be positive")
+	}
+
+	if request.Indemnity == "" {
+		request.Indemnity = IndemnityLegal
+	}
+	switch request.Indemnity {
+	case IndemnityLegal, IndemnityNone:
+	case IndemnityRate:
+		if request.Indemnity_rate < 0 {
+			return errors.New("indemnity_rate must be positive")
+		}
+	default:
+		return errors.New("indemnity must be legal, none or rate")
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package loan
+
+import (
+	"testing"
+
+	"financialApp/money"
+)
+
+// 100 000 at 12% in 12 installments, 2 of them paid
+var simulatedLoan = Loan{
+	Total_amount:      money.MustParse("100000"),
+	Subscription_date: "2024-01-31",
+	Rate:              12,
+	Nb_payments_total: 12,
+	Nb_payments_done:  2,
+}
+
+func TestSimulateRepayment(t *testing.T) {
+
+	request := SimulationRequest{Scenario: ScenarioRepayment, Amount: money.MustParse("40000")}
+	if err := validateSimulation(&request); err != nil {
+		t.Fatal(err)
+	}
+
+	simulation, err := simulatedLoan.Simulate(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(simulation.Current.Installments) != 10 || simulation.Outstanding_capital.StringFixed(2) != "84151.39" {
+		t.Fatalf("Wrong current schedule %+v", simulation.Current)
+	}
+	// Same installment, fewer of them
+	if len(simulation.New.Installments) != 6 || simulation.New.Installments[0].Date != "2024-04-30" {
+		t.Errorf("Wrong new schedule %+v", simulation.New.Installments)
+	}
+	if simulation.New.Total_capital.StringFixed(2) != "44151.39" {
+		t.Errorf("new capital = %s", simulation.New.Total_capital.StringFixed(2))
+	}
+	// 6 months of interest on 40 000 is less than 3% of the outstanding capital
+	if got := simulation.Indemnity.StringFixed(2); got != "2400.00" {
+		t.Errorf("indemnity = %s", got)
+	}
+	if simulation.Interest_saved <= 0 || simulation.Break_even_month != 6 {
+		t.Errorf("interest saved = %s, break even = %d", simulation.Interest_saved.StringFixed(2), simulation.Break_even_month)
+	}
+
+	request = SimulationRequest{Scenario: ScenarioRepayment, Amount: money.MustParse("40000"), Mode: ModeReduceInstallment, Indemnity: IndemnityNone}
+	simulation, _ = simulatedLoan.Simulate(request)
+	if len(simulation.New.Installments) != 10 || !simulation.Indemnity.IsZero() || simulation.Break_even_month != 1 {
+		t.Errorf("Wrong reduced installment %+v", simulation)
+	}
+
+	// Total repayment
+	simulation, _ = simulatedLoan.Simulate(SimulationRequest{Scenario: ScenarioRepayment, Indemnity: IndemnityNone})
+	if len(simulation.New.Installments) != 0 || simulation.Repaid_capital != simulation.Outstanding_capital {
+		t.Errorf("Wrong total repayment %+v", simulation)
+	}
+}
+
+func TestSimulateRefinancing(t *testing.T) {
+
+	request := SimulationRequest{Scenario: ScenarioRefinancing, Rate: 6, Duration: 10, Fees: money.MustParse("500"), Indemnity: IndemnityRate, Indemnity_rate: 1}
+	if err := validateSimulation(&request); err != nil {
+		t.Fatal(err)
+	}
+
+	simulation, err := simulatedLoan.Simulate(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(simulation.New.Installments) != 10 || simulation.New.Total_capital != simulation.Outstanding_capital {
+		t.Errorf("Wrong new schedule %+v", simulation.New)
+	}
+	if got := simulation.Indemnity.StringFixed(2); got != "841.51" {
+		t.Errorf("indemnity = %s", got)
+	}
+	if simulation.Net_saving != simulation.Interest_saved-simulation.Indemnity-simulation.Fees {
+		t.Errorf("net saving = %s", simulation.Net_saving.StringFixed(2))
+	}
+	if simulation.Break_even_month == 0 {
+		t.Errorf("refinancing at a lower rate is never recovered")
+	}
+}
+
+func TestValidateSimulation(t *testing.T) {
+
+	for _, request := range []SimulationRequest{
+		{Scenario: "unknown"},
+		{Scenario: ScenarioRepayment, Mode: "unknown"},
+		{Scenario: ScenarioRefinancing, Rate: 3},
+		{Scenario: ScenarioRepayment, Indemnity: "unknown"},
+	} {
+		if err := validateSimulation(&request); err == nil {
+			t.Errorf("%+v is valid", request)
+		}
+	}
+}
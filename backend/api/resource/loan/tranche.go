@@ -0,0 +1,435 @@
+package loan
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Add a tranche to a loan
+func CreateTranche(w http.ResponseWriter, r *http.Request) {
+
+	loanAccountId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	loanExists, err := exists(loanAccountId)
+	if err != nil {
+		config.Logger.Error().Err(err).Int("loan_account_id", loanAccountId).Msg("Cannot check the loan")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if !loanExists {
+		http.Error(w, "Loan does not exist", http.StatusNotFound)
+		return
+	}
+
+	var tranche Tranche
+	if err := json.NewDecoder(r.Body).Decode(&tranche); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateTranche(&tranche); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tranche.Loan_account_id = loanAccountId
+	tranche.Rate_changes = []RateChange{}
+
+	var query string = "INSERT INTO loanTranche (loan_account_id, tranche_name, amount, rate, rate_cap, payments, deferred_payments, first_payment_date, insurance_amount) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := config.DB.Exec(query, tranche.Loan_account_id, tranche.Name, tranche.Amount, tranche.Rate, tranche.Rate_cap, tranche.Payments, tranche.Deferred_payments, tranche.First_payment_date, tranche.Insurance_amount)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get tranche id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	tranche.Tranche_id = int(id)
+
+	jsonBody, err := json.Marshal(tranche)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal tranche")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+// Get the tranches of a loan with their rate changes
+func GetTranches(w http.ResponseWriter, r *http.Request) {
+
+	loanAccountId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	tranches, err := readTranches(loanAccountId)
+	if err != nil {
+		config.Logger.Error().Err(err).Int("loan_account_id", loanAccountId).Msg("Cannot read tranches")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if len(tranches) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	jsonBody, err := json.Marshal(tranches)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal tranches")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Delete a tranche and its rate changes
+func DeleteTranche(w http.ResponseWriter, r *http.Request) {
+
+	trancheId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var query string = "DELETE FROM loanTranche WHERE tranche_id=?"
+	result, err := config.DB.Exec(query, trancheId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		http.Error(w, "Tranche does not exist", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Set a new rate of a variable rate tranche, from a date
+func CreateRateChange(w http.ResponseWriter, r *http.Request) {
+
+	trancheId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var change RateChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := time.Parse("2006-01-02", change.Date); err != nil {
+		http.Error(w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if change.Rate < 0 {
+		http.Error(w, "rate must be positive", http.StatusBadRequest)
+		return
+	}
+	change.Tranche_id = trancheId
+
+	var trancheExists bool
+	var query string = "SELECT EXISTS (SELECT 1 FROM loanTranche WHERE tranche_id=?)"
+	if err := config.DB.QueryRow(query, trancheId).Scan(&trancheExists); err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if !trancheExists {
+		http.Error(w, "Tranche does not exist", http.StatusNotFound)
+		return
+	}
+
+	query = "INSERT INTO loanRateChange (tranche_id, change_date, rate) VALUES (?, ?, ?)"
+	result, err := config.DB.Exec(query, change.Tranche_id, change.Date, change.Rate)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get rate change id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	change.Change_id = int(id)
+
+	jsonBody, err := json.Marshal(change)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal rate change")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+func DeleteRateChange(w http.ResponseWriter, r *http.Request) {
+
+	changeId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var query string = "DELETE FROM loanRateChange WHERE change_id=?"
+	result, err := config.DB.Exec(query, changeId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		http.Error(w, "Rate change does not exist", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Build the installments of a tranche. The installment is computed again for the remaining payments
+// each time the rate changes, a variable rate never going above its cap
+func (t Tranche) Schedule(today time.Time) Schedule {
+
+	installments := []Installment{}
+
+	firstPayment, err := time.Parse("2006-01-02", t.First_payment_date)
+	if err != nil || t.Payments == 0 || t.Amount <= 0 {
+		schedule := newSchedule(t.Loan_account_id, installments)
+		schedule.Name = t.Name
+		return schedule
+	}
+
+	changes := append([]RateChange{}, t.Rate_changes...)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Date < changes[j].Date
+	})
+
+	total := int(t.Deferred_payments + t.Payments)
+	remainingCapital := t.Amount
+	var mensuality money.Amount
+	previousRate := -1.0
+
+	for i := range total {
+		date := addMonths(firstPayment, i)
+		rate := t.rateAt(changes, date)
+		monthlyRate := rate / 100 / 12
+
+		installment := Installment{
+			Number:    i + 1,
+			Date:      date.Format("2006-01-02"),
+			Interest:  remainingCapital.Mul(monthlyRate),
+			Insurance: t.Insurance_amount,
+			Deferred:  i < int(t.Deferred_payments),
+			Paid:      !date.After(today),
+		}
+
+		if !installment.Deferred {
+			if rate != previousRate {
+				mensuality = annuity(remainingCapital, monthlyRate, total-i)
+				previousRate = rate
+			}
+			installment.Capital = mensuality - installment.Interest
+			if i == total-1 || installment.Capital > remainingCapital {
+				installment.Capital = remainingCapital
+			}
+		}
+		remainingCapital -= installment.Capital
+
+		installment.Payment = installment.Capital + installment.Interest + installment.Insurance
+		installment.Outstanding_capital = remainingCapital
+
+		installments = append(installments, installment)
+	}
+
+	schedule := newSchedule(t.Loan_account_id, installments)
+	schedule.Name = t.Name
+	return schedule
+}
+
+// Rate of the tranche at a date: the last rate change before it, capped
+func (t Tranche) rateAt(changes []RateChange, date time.Time) float64 {
+
+	rate := t.Rate
+	for _, change := range changes {
+		if change.Date > date.Format("2006-01-02") {
+			break
+		}
+		rate = change.Rate
+	}
+
+	if t.Rate_cap > 0 {
+		rate = min(rate, t.Rate_cap)
+	}
+	return rate
+}
+
+// Sum the schedules of the tranches by date. The outstanding capital of a date includes the tranches
+// which have not started yet
+func mergeSchedules(loanAccountId int, schedules []Schedule) Schedule {
+
+	byDate := make(map[string]*Installment)
+	var dates []string
+
+	for _, schedule := range schedules {
+		for _, installment := range schedule.Installments {
+			merged, ok := byDate[installment.Date]
+			if !ok {
+				merged = &Installment{Date: installment.Date, Deferred: true, Paid: true}
+				byDate[installment.Date] = merged
+				dates = append(dates, installment.Date)
+			}
+			merged.Payment += installment.Payment
+			merged.Capital += installment.Capital
+			merged.Interest += installment.Interest
+			merged.Insurance += installment.Insurance
+			merged.Deferred = merged.Deferred && installment.Deferred
+			merged.Paid = merged.Paid && installment.Paid
+		}
+	}
+	sort.Strings(dates)
+
+	installments := []Installment{}
+	for i, date := range dates {
+		installment := *byDate[date]
+		installment.Number = i + 1
+		for _, schedule := range schedules {
+			installment.Outstanding_capital += outstandingAt(schedule, date)
+		}
+		installments = append(installments, installment)
+	}
+
+	merged := newSchedule(loanAccountId, installments)
+	merged.Tranches = schedules
+	return merged
+}
+
+// Keep the schedule of the tranches of the loan, which gives its capital instead of the loan row
+func (l *Loan) loadTranches() error {
+
+	tranches, err := readTranches(l.Loan_account_id)
+	if err != nil || len(tranches) == 0 {
+		return err
+	}
+
+	var schedules []Schedule
+	for _, tranche := range tranches {
+		schedules = append(schedules, tranche.Schedule(time.Now()))
+	}
+	merged := mergeSchedules(l.Loan_account_id, schedules)
+	l.tranches = &merged
+	return nil
+}
+
+// Read the tranches of a loan with their rate changes
+func readTranches(loanAccountId int) ([]Tranche, error) {
+
+	var tranches []Tranche
+
+	var query string = "SELECT tranche_id, loan_account_id, tranche_name, amount, rate, rate_cap, payments, deferred_payments, first_payment_date, insurance_amount FROM loanTranche WHERE loan_account_id=? ORDER BY first_payment_date, tranche_id"
+	rows, err := config.DB.Query(query, loanAccountId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make(map[int]int)
+	for rows.Next() {
+		var tranche Tranche
+		if err := rows.Scan(&tranche.Tranche_id, &tranche.Loan_account_id, &tranche.Name, &tranche.Amount, &tranche.Rate, &tranche.Rate_cap, &tranche.Payments, &tranche.Deferred_payments, &tranche.First_payment_date, &tranche.Insurance_amount); err != nil {
+			return nil, err
+		}
+		tranche.Rate_changes = []RateChange{}
+		indexes[tranche.Tranche_id] = len(tranches)
+		tranches = append(tranches, tranche)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(tranches) == 0 {
+		return tranches, nil
+	}
+
+	query = "SELECT c.change_id, c.tranche_id, c.change_date, c.rate FROM loanRateChange c INNER JOIN loanTranche t ON c.tranche_id = t.tranche_id WHERE t.loan_account_id=? ORDER BY c.change_date"
+	changeRows, err := config.DB.Query(query, loanAccountId)
+	if err != nil {
+		return nil, err
+	}
+	defer changeRows.Close()
+
+	for changeRows.Next() {
+		var change RateChange
+		if err := changeRows.Scan(&change.Change_id, &change.Tranche_id, &change.Date, &change.Rate); err != nil {
+			return nil, err
+		}
+		if index, ok := indexes[change.Tranche_id]; ok {
+			tranches[index].Rate_changes = append(tranches[index].Rate_changes, change)
+		}
+	}
+
+	return tranches, changeRows.Err()
+}
+
+func exists(loanAccountId int) (bool, error) {
+
+	var exists bool
+	var query string = "SELECT EXISTS (SELECT 1 FROM loan WHERE loan_account_id=?)"
+	err := config.DB.QueryRow(query, loanAccountId).Scan(&exists)
+	return exists, err
+}
+
+// Check the user input
+func validateTranche(tranche *Tranche) error {
+
+	if tranche.Name == "" {
+		return errors.New("name is required")
+	}
+	if tranche.Amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	if tranche.Rate < 0 || tranche.Rate_cap < 0 {
+		return errors.New("rate must be positive")
+	}
+	if tranche.Rate_cap > 0 && tranche.Rate_cap < tranche.Rate {
+		return errors.New("rate_cap must be above the rate")
+	}
+	if tranche.Payments == 0 {
+		return errors.New("payments is required")
+	}
+	if _, err := time.Parse("2006-01-02", tranche.First_payment_date); err != nil {
+		return errors.New("first_payment_date must be YYYY-MM-DD")
+	}
+	if tranche.Insurance_amount < 0 {
+		return errors.New("insurance_amount must be positive")
+	}
+	return nil
+}
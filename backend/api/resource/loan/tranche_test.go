@@ -0,0 +1,102 @@
+package loan
+
+import (
+	"testing"
+	"time"
+
+	"financialApp/money"
+)
+
+func TestTrancheSchedule(t *testing.T) {
+
+	today := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	// Zero-rate loan refunded after a deferral of 2 installments
+	zeroRate := Tranche{Name: "PTZ", Amount: money.MustParse("1200"), Payments: 12, Deferred_payments: 2, First_payment_date: "2024-01-31"}
+	schedule := zeroRate.Schedule(today)
+	if len(schedule.Installments) != 14 || schedule.Total_interest != 0 || schedule.Total_capital != zeroRate.Amount {
+		t.Fatalf("Wrong zero-rate schedule %+v", schedule)
+	}
+	if first := schedule.Installments[0]; !first.Deferred || !first.Capital.IsZero() || !first.Paid || first.Date != "2024-01-31" {
+		t.Errorf("Wrong first installment %+v", first)
+	}
+	if third := schedule.Installments[2]; third.Deferred || third.Capital.StringFixed(2) != "100.00" || third.Paid || third.Date != "2024-03-31" {
+		t.Errorf("Wrong third installment %+v", third)
+	}
+
+	// Variable rate going from 12% to 24%, capped at 18%
+	variable := Tranche{
+		Amount:             money.MustParse("12000"),
+		Rate:               12,
+		Rate_cap:           18,
+		Payments:           12,
+		First_payment_date: "2024-01-15",
+		Rate_changes:       []RateChange{{Date: "2024-07-01", Rate: 24}},
+	}
+	schedule = variable.Schedule(today)
+	if got := schedule.Installments[5].Interest.Div(schedule.Installments[4].Outstanding_capital); got < 0.0099 || got > 0.0101 {
+		t.Errorf("June rate = %v", got)
+	}
+	if got := schedule.Installments[6].Interest.Div(schedule.Installments[5].Outstanding_capital); got < 0.0149 || got > 0.0151 {
+		t.Errorf("July rate = %v, want the cap", got)
+	}
+	// The installment is computed again with the new rate, and still refunds the capital in 12 payments
+	if schedule.Installments[6].Payment <= schedule.Installments[5].Payment || !schedule.Installments[11].Outstanding_capital.IsZero() {
+		t.Errorf("Wrong installments after the rate change %+v", schedule.Installments[5:])
+	}
+}
+
+func TestMergeSchedules(t *testing.T) {
+
+	today := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	main := Tranche{Name: "Main", Amount: money.MustParse("1000"), Payments: 2, First_payment_date: "2024-01-31"}.Schedule(today)
+	later := Tranche{Name: "Employer", Amount: money.MustParse("500"), Payments: 2, First_payment_date: "2024-02-29"}.Schedule(today)
+
+	merged := mergeSchedules(1, []Schedule{main, later})
+
+	want := []struct {
+		date, payment, outstanding string
+	}{
+		{"2024-01-31", "500.00", "1000.00"}, // the employer loan is not refunded yet
+		{"2024-02-29", "750.00", "250.00"},
+		{"2024-03-29", "250.00", "0.00"},
+	}
+	if len(merged.Installments) != len(want) || len(merged.Tranches) != 2 {
+		t.Fatalf("merged = %+v", merged)
+	}
+	for i, installment := range merged.Installments {
+		if installment.Date != want[i].date || installment.Payment.StringFixed(2) != want[i].payment || installment.Outstanding_capital.StringFixed(2) != want[i].outstanding {
+			t.Errorf("Wrong installment %d: got %+v want %v", i, installment, want[i])
+		}
+	}
+	if merged.Total_capital.StringFixed(2) != "1500.00" {
+		t.Errorf("total capital = %s", merged.Total_capital.StringFixed(2))
+	}
+}
+
+func TestLoanWithTranches(t *testing.T) {
+
+	today := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	main := Tranche{Name: "Main", Amount: money.MustParse("1000"), Payments: 2, First_payment_date: "2024-01-31"}.Schedule(today)
+	later := Tranche{Name: "Employer", Amount: money.MustParse("500"), Payments: 2, First_payment_date: "2024-02-29"}.Schedule(today)
+	merged := mergeSchedules(1, []Schedule{main, later})
+
+	// The loan row is ignored once the loan is split in tranches
+	l := Loan{Loan_account_id: 1, Total_amount: money.MustParse("99999"), Rate: 5, Nb_payments_total: 240, Subscription_date: "2023-12-01", tranches: &merged}
+
+	if got := l.OutstandingCapitalAt(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)).StringFixed(2); got != "1000.00" {
+		t.Errorf("Outstanding capital = %s, want 1000.00", got)
+	}
+	if got := l.OutstandingCapitalAt(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)).StringFixed(2); got != "250.00" {
+		t.Errorf("Outstanding capital = %s, want 250.00", got)
+	}
+	if got := l.OutstandingCapitalAt(time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Outstanding capital before the subscription = %s", got.StringFixed(2))
+	}
+	if schedule := l.Schedule(); len(schedule.Tranches) != 2 {
+		t.Errorf("Expected the schedule of the tranches, got %+v", schedule)
+	}
+	if _, err := l.Simulate(SimulationRequest{Scenario: ScenarioRepayment}); err == nil {
+		t.Error("Expected an error when simulating a loan split in tranches")
+	}
+}
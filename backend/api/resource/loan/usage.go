@@ -0,0 +1,136 @@
+package loan
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Implemented by *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Get the amounts used and available of a revolving credit over time, with the used part of its limit
+func GetUsage(w http.ResponseWriter, r *http.Request) {
+
+	loanAccountId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	l, err := ReadLoan(loanAccountId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Loan does not exist", http.StatusNotFound)
+			return
+		}
+		config.Logger.Error().Err(err).Int("loan_account_id", loanAccountId).Msg("Cannot read loan")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if !l.IsRevolving() {
+		http.Error(w, "Only a revolving credit has a usage", http.StatusBadRequest)
+		return
+	}
+
+	usages := l.usages
+	for i := range usages {
+		usages[i].Utilization = utilization(l.Total_amount, usages[i].Used_amount, usages[i].Available_amount)
+	}
+
+	if len(usages) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	jsonBody, err := json.Marshal(usages)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal usages")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Read the uses of a revolving credit, oldest first
+func readUsages(loanAccountId int) ([]Usage, error) {
+
+	var usages []Usage
+
+	var query string = "SELECT usage_date, available_amount, used_amount FROM loanUsage WHERE loan_account_id=? ORDER BY usage_date"
+	rows, err := config.DB.Query(query, loanAccountId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var usage Usage
+		if err := rows.Scan(&usage.Date, &usage.Available_amount, &usage.Used_amount); err != nil {
+			return nil, err
+		}
+		usages = append(usages, usage)
+	}
+
+	return usages, rows.Err()
+}
+
+// Keep the uses of a revolving credit, which give its capital at a past date
+func (l *Loan) loadUsages() error {
+
+	if !l.IsRevolving() {
+		return nil
+	}
+
+	var err error
+	l.usages, err = readUsages(l.Loan_account_id)
+	return err
+}
+
+// Amount used by a revolving credit at a date: the last use recorded until then. The history starts with
+// the first use recorded, and without any the current use is kept
+func (l Loan) usedAt(date string) money.Amount {
+
+	if len(l.usages) == 0 {
+		return l.Used_amount
+	}
+
+	used := l.usages[0].Used_amount
+	for _, usage := range l.usages {
+		if usage.Date > date {
+			break
+		}
+		used = usage.Used_amount
+	}
+	return used
+}
+
+// Record the use of a revolving credit at a date. A sync of the same day replaces the use of the day
+func RecordUsage(db execer, l Loan, date string) error {
+
+	query := "INSERT INTO loanUsage (loan_account_id, usage_date, available_amount, used_amount) VALUES (?, ?, ?, ?)"
+	query += " AS new(a, b, Navailable_amount, Nused_amount) ON DUPLICATE KEY UPDATE available_amount=Navailable_amount, used_amount=Nused_amount"
+
+	_, err := db.Exec(query, l.Loan_account_id, date, l.Available_amount, l.Used_amount)
+	return err
+}
+
+// Used part of the limit of a revolving credit, in %. Without limit, it is the used and the available amounts
+func utilization(limit, used, available money.Amount) float64 {
+
+	if limit <= 0 {
+		limit = used + available
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return used.Div(limit) * 100
+}
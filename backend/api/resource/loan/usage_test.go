@@ -0,0 +1,64 @@
+package loan
+
+import (
+	"testing"
+	"time"
+
+	"financialApp/money"
+)
+
+func TestUtilization(t *testing.T) {
+
+	tests := []struct {
+		limit, used, available string
+		want                   float64
+	}{
+		{limit: "3000", used: "750", available: "2250", want: 25},
+		{limit: "0", used: "500", available: "1500", want: 25}, // limit unknown
+		{limit: "0", used: "0", available: "0", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := utilization(money.MustParse(tt.limit), money.MustParse(tt.used), money.MustParse(tt.available)); got != tt.want {
+			t.Errorf("utilization(%s, %s, %s) = %v, want %v", tt.limit, tt.used, tt.available, got, tt.want)
+		}
+	}
+}
+
+func TestRevolvingCapital(t *testing.T) {
+
+	loan := Loan{
+		Loan_type:         TypeRevolving,
+		Total_amount:      money.MustParse("3000"),
+		Used_amount:       money.MustParse("750"),
+		Subscription_date: "2022-05-01 00:00:00",
+		Nb_payments_total: 0,
+	}
+
+	if got := loan.OutstandingCapital().StringFixed(2); got != "750.00" {
+		t.Errorf("outstanding capital = %s", got)
+	}
+	if schedule := loan.Schedule(); len(schedule.Installments) != 0 {
+		t.Errorf("revolving credit schedule = %+v", schedule)
+	}
+
+	loan.usages = []Usage{
+		{Date: "2023-01-10", Used_amount: money.MustParse("200")},
+		{Date: "2023-06-10", Used_amount: money.MustParse("1200")},
+		{Date: "2024-01-10", Used_amount: money.MustParse("750")},
+	}
+	for _, tt := range []struct {
+		date time.Time
+		want string
+	}{
+		{date: time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC), want: "0.00"}, // before the subscription
+		{date: time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC), want: "200.00"},
+		{date: time.Date(2023, 6, 10, 0, 0, 0, 0, time.UTC), want: "1200.00"},
+		{date: time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC), want: "1200.00"},
+		{date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), want: "750.00"},
+	} {
+		if got := loan.OutstandingCapitalAt(tt.date).StringFixed(2); got != tt.want {
+			t.Errorf("%s: capital = %s, want %s", tt.date.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,478 @@
+package manual
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"financialApp/api/resource/events"
+	"financialApp/api/resource/investment"
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// https://docs.powens.com/api-reference/products/data-aggregation/bank-account-types#accounttypename-values
+var accountTypes = []string{
+	"article83", "capitalisation", "card", "checking",
+	"crowdlending", "deposit", "ldds", "lifeinsurance",
+	"loan", "madelin", "market", "pea", "pee", "per",
+	"perco", "perp", "real_estate", "rsp", "savings", "unknown",
+}
+
+func CreateManualAccount(w http.ResponseWriter, r *http.Request) {
+
+	var account ManualAccount
+	err := json.NewDecoder(r.Body).Decode(&account)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateAccount(&account); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The id is allocated and the account created in the same transaction, so that concurrent creations get different ids
+	dbTx, err := config.DB.Begin()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot begin transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer dbTx.Rollback()
+
+	account.Account_id, err = NextId(dbTx, "SELECT account_id FROM bankAccount ORDER BY account_id LIMIT 1 FOR UPDATE")
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get next manual account id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	var query string = "INSERT INTO bankAccount (account_id, user_id, bank_original_name, bank_number, original_name, balance, last_update, iban, currency, account_type, usage_type) VALUES (?, 0, ?, '', ?, ?, ?, '', ?, ?, '')"
+	_, err = dbTx.Exec(query, account.Account_id, account.Bank_name, account.Name, account.Initial_balance, time.Now().Format("2006-01-02 15:04:05"), account.Currency, account.Account_type)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	query = "INSERT INTO manualAccount (account_id, initial_balance, creation_date) VALUES (?, ?, ?)"
+	_, err = dbTx.Exec(query, account.Account_id, account.Initial_balance, account.Creation_date)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot commit transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if err := Refresh(account.Account_id); err != nil {
+		config.Logger.Error().Err(err).Int("account_id", account.Account_id).Msg("Cannot refresh manual account")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	account.Balance = account.Initial_balance
+	events.Publish(events.TypeAccountChanged, events.AccountChanged{Account_id: account.Account_id, Action: events.ActionCreated})
+
+	jsonBody, err := json.Marshal(account)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal manual account")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+func GetManualAccounts(w http.ResponseWriter, r *http.Request) {
+
+	var accounts []ManualAccount
+
+	var query string = "SELECT bankAccount.account_id, bankAccount.bank_original_name, bankAccount.original_name, bankAccount.account_type, bankAccount.currency, manualAccount.initial_balance, manualAccount.creation_date, bankAccount.balance FROM manualAccount INNER JOIN bankAccount ON manualAccount.account_id = bankAccount.account_id ORDER BY bankAccount.original_name"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var account ManualAccount
+		if err := rows.Scan(&account.Account_id, &account.Bank_name, &account.Name, &account.Account_type, &account.Currency, &account.Initial_balance, &account.Creation_date, &account.Balance); err != nil {
+			config.Logger.Error().Err(err).Msg("Cannot scan row")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		accounts = append(accounts, account)
+	}
+	if err := rows.Err(); err != nil {
+		config.Logger.Error().Err(err).Msg("Error in rows")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(accounts)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal manual accounts")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+func UpdateManualAccount(w http.ResponseWriter, r *http.Request) {
+
+	accountId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var account ManualAccount
+	if err := json.NewDecoder(r.Body).Decode(&account); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateAccount(&account); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var query string = "UPDATE manualAccount SET initial_balance=?, creation_date=? WHERE account_id=?"
+	result, err := config.DB.Exec(query, account.Initial_balance, account.Creation_date, accountId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 && !isManual(accountId) {
+		http.Error(w, "Manual account does not exist", http.StatusNotFound)
+		return
+	}
+
+	query = "UPDATE bankAccount SET bank_original_name=?, original_name=?, currency=?, account_type=? WHERE account_id=?"
+	_, err = config.DB.Exec(query, account.Bank_name, account.Name, account.Currency, account.Account_type, accountId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if err := Refresh(accountId); err != nil {
+		config.Logger.Error().Err(err).Int("account_id", accountId).Msg("Cannot refresh manual account")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	events.Publish(events.TypeAccountChanged, events.AccountChanged{Account_id: accountId, Action: events.ActionUpdated})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func DeleteManualAccount(w http.ResponseWriter, r *http.Request) {
+
+	accountId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	if !isManual(accountId) {
+		http.Error(w, "Manual account does not exist", http.StatusNotFound)
+		return
+	}
+
+	// Everything is deleted, or nothing if a query fails
+	dbTx, err := config.DB.Begin()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot begin transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer dbTx.Rollback()
+
+	// Order matters because of foreign keys. The history of the invests is deleted with them
+	for _, query := range []string{
+		"DELETE FROM incomeTag WHERE tx_id IN (SELECT tx_id FROM tx WHERE account_id=?)",
+		"DELETE FROM tx WHERE account_id=?",
+		"DELETE FROM investOperation WHERE account_id=?",
+		"DELETE FROM invest WHERE account_id=?",
+		"DELETE FROM cryptoOperation WHERE account_id=?",
+		"DELETE FROM historyValue WHERE bank_account_id=?",
+		"DELETE FROM manualAccount WHERE account_id=?",
+		"DELETE FROM bankAccount WHERE account_id=?",
+	} {
+		if _, err := dbTx.Exec(query, accountId); err != nil {
+			config.Logger.Error().Err(err).Msg(query)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot commit transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	events.Publish(events.TypeAccountChanged, events.AccountChanged{Account_id: accountId, Action: events.ActionDeleted})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Add a tx to a manual account and update its balance and history
+func CreateManualTransaction(w http.ResponseWriter, r *http.Request) {
+
+	accountId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	if !isManual(accountId) {
+		http.Error(w, "Manual account does not exist", http.StatusNotFound)
+		return
+	}
+
+	var tx ManualTransaction
+	if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tx.Account_id = accountId
+
+	// Accept a date alone or a datetime
+	parsedDate, err := time.Parse("2006-01-02 15:04:05", tx.Date)
+	if err != nil {
+		parsedDate, err = time.Parse("2006-01-02", tx.Date)
+		if err != nil {
+			http.Error(w, "Wrong date. Must be YYYY-MM-DD or YYYY-MM-DD HH:MM:SS", http.StatusBadRequest)
+			return
+		}
+	}
+	tx.Date = parsedDate.Format("2006-01-02 15:04:05")
+
+	if tx.Transaction_type == "" {
+		tx.Transaction_type = "manual"
+	}
+
+	dbTx, err := config.DB.Begin()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot begin transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer dbTx.Rollback()
+
+	tx.Id, err = NextId(dbTx, "SELECT tx_id FROM tx ORDER BY tx_id LIMIT 1 FOR UPDATE")
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get next manual tx id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	var query string = "INSERT INTO tx (tx_id, user_id, account_id, tx_date, tx_value, tx_type, original_wording) VALUES (?, 0, ?, ?, ?, ?, ?)"
+	_, err = dbTx.Exec(query, tx.Id, tx.Account_id, tx.Date, tx.Value, tx.Transaction_type, tx.Original_wording)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot commit transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if err := Refresh(accountId); err != nil {
+		config.Logger.Error().Err(err).Int("account_id", accountId).Msg("Cannot refresh manual account")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	events.Publish(events.TypeTransactionChanged, events.TransactionChanged{Transaction_id: tx.Id, Account_id: accountId, Action: events.ActionCreated})
+
+	jsonBody, err := json.Marshal(tx)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal manual tx")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+// Recalculate the balance and the history of a manual account from its initial balance and its txs.
+// Does nothing if the account is not a manual account
+func Refresh(accountId int) error {
+
+	var initialBalance money.Amount
+	var creationDate string
+
+	var query string = "SELECT initial_balance, creation_date FROM manualAccount WHERE account_id=?"
+	if err := config.DB.QueryRow(query, accountId).Scan(&initialBalance, &creationDate); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	query = "SELECT DATE(tx_date), SUM(tx_value) FROM tx WHERE account_id=? GROUP BY DATE(tx_date) ORDER BY DATE(tx_date)"
+	rows, err := config.DB.Query(query, accountId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var dailySums []balancePoint
+	for rows.Next() {
+		var point balancePoint
+		if err := rows.Scan(&point.date, &point.balance); err != nil {
+			return err
+		}
+		dailySums = append(dailySums, point)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	points := computeBalancePoints(initialBalance, creationDate, dailySums)
+
+	dbTx, err := config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+
+	if _, err := dbTx.Exec("DELETE FROM historyValue WHERE bank_account_id=?", accountId); err != nil {
+		return err
+	}
+
+	// One point per day until today, or until the last tx if it is in the future
+	var historyValues []investment.HistoryValue
+	for _, point := range points {
+		historyValues = append(historyValues, investment.HistoryValue{BankAccountId: accountId, Valuation: point.balance, DateValuation: point.date})
+	}
+	until := max(time.Now().Format("2006-01-02"), points[len(points)-1].date)
+
+	if err := investment.RecordBalances(dbTx, investment.DailyValues(historyValues, until)); err != nil {
+		return err
+	}
+
+	query = "UPDATE bankAccount SET balance=?, last_update=? WHERE account_id=?"
+	if _, err := dbTx.Exec(query, points[len(points)-1].balance, time.Now().Format("2006-01-02 15:04:05"), accountId); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// Build one history point for the creation date and one for each day with txs:
+// the balance of a day is the initial balance plus every tx made until this day (included)
+func computeBalancePoints(initialBalance money.Amount, creationDate string, dailySums []balancePoint) []balancePoint {
+
+	points := []balancePoint{}
+
+	balance := initialBalance
+	creationAdded := false
+
+	for _, sum := range dailySums {
+		if !creationAdded && sum.date > creationDate {
+			points = append(points, balancePoint{date: creationDate, balance: balance})
+			creationAdded = true
+		}
+		if sum.date == creationDate {
+			creationAdded = true
+		}
+		balance += sum.balance
+		points = append(points, balancePoint{date: sum.date, balance: balance})
+	}
+
+	if !creationAdded {
+		points = append(points, balancePoint{date: creationDate, balance: balance})
+	}
+
+	return points
+}
+
+// Check the user input and set default values
+func validateAccount(account *ManualAccount) error {
+
+	if account.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if account.Account_type == "" {
+		account.Account_type = "unknown"
+	}
+	if !slices.Contains(accountTypes, account.Account_type) {
+		return errors.New("unsupported account type " + account.Account_type)
+	}
+
+	if account.Bank_name == "" {
+		account.Bank_name = "Manual"
+	}
+
+	if account.Currency == "" {
+		account.Currency = "EUR"
+	}
+
+	if account.Creation_date == "" {
+		account.Creation_date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", account.Creation_date); err != nil {
+		return errors.New("wrong creation date, must be YYYY-MM-DD")
+	}
+
+	return nil
+}
+
+// Implemented by *sql.DB and *sql.Tx
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// Get the next negative id from a query reading the lowest id with "ORDER BY id LIMIT 1 FOR UPDATE".
+// The lock is held until the end of the transaction, which must also insert the row with the new id.
+// Also used by other resources stored in bankAccount without being a Powens account
+func NextId(db querier, query string) (int, error) {
+
+	var minId int
+	if err := db.QueryRow(query).Scan(&minId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return -1, nil
+		}
+		return 0, err
+	}
+
+	if minId > 0 {
+		return -1, nil
+	}
+	return minId - 1, nil
+}
+
+func isManual(accountId int) bool {
+
+	var exists bool
+	var query string = "SELECT EXISTS (SELECT 1 FROM manualAccount WHERE account_id=?)"
+	if err := config.DB.QueryRow(query, accountId).Scan(&exists); err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		return false
+	}
+	return exists
+}
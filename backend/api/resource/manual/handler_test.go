@@ -0,0 +1,39 @@
+package manual
+
+import (
+	"reflect"
+	"testing"
+
+	"financialApp/money"
+)
+
+func TestComputeBalancePoints(t *testing.T) {
+
+	dailySums := []balancePoint{
+		{date: "2024-01-01", balance: money.MustParse("-20")},
+		{date: "2024-01-05", balance: money.MustParse("50")},
+		{date: "2024-01-10", balance: money.MustParse("-10")},
+	}
+
+	got := computeBalancePoints(money.MustParse("100"), "2024-01-03", dailySums)
+	want := []balancePoint{
+		{date: "2024-01-01", balance: money.MustParse("80")},
+		{date: "2024-01-03", balance: money.MustParse("80")},
+		{date: "2024-01-05", balance: money.MustParse("130")},
+		{date: "2024-01-10", balance: money.MustParse("120")},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Wrong balance points: got %v want %v", got, want)
+	}
+}
+
+func TestComputeBalancePointsWithoutTx(t *testing.T) {
+
+	got := computeBalancePoints(money.MustParse("100"), "2024-01-03", nil)
+	want := []balancePoint{{date: "2024-01-03", balance: money.MustParse("100")}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Wrong balance points: got %v want %v", got, want)
+	}
+}
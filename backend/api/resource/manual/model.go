@@ -0,0 +1,31 @@
+package manual
+
+import "financialApp/money"
+
+// Manual accounts are stored in bankAccount like Powens accounts, so they are taken into account everywhere.
+// Powens ids are positive: manual accounts and manual txs use negative ids so they never collide
+
+type ManualAccount struct {
+	Account_id      int          `json:"id"`
+	Bank_name       string       `json:"bank_original_name"`
+	Name            string       `json:"original_name"`
+	Account_type    string       `json:"type"`
+	Currency        string       `json:"currency"`
+	Initial_balance money.Amount `json:"initial_balance"`
+	Creation_date   string       `json:"creation_date"` // YYYY-MM-DD, date of the initial balance
+	Balance         money.Amount `json:"balance"`       // Computed: initial balance + sum of the account txs
+}
+
+type ManualTransaction struct {
+	Id               int          `json:"id"`
+	Account_id       int          `json:"id_account"`
+	Date             string       `json:"date"` // YYYY-MM-DD HH:MM:SS
+	Value            money.Amount `json:"value"`
+	Transaction_type string       `json:"type"`
+	Original_wording string       `json:"original_wording"`
+}
+
+type balancePoint struct {
+	date    string
+	balance money.Amount
+}
@@ -0,0 +1,318 @@
+package networth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"financialApp/api/resource/bank"
+	"financialApp/api/resource/fx"
+	"financialApp/api/resource/investment"
+	"financialApp/api/resource/loan"
+	"financialApp/config"
+	"financialApp/money"
+)
+
+type account struct {
+	accountType string
+	currency    string
+	balance     money.Amount
+}
+
+// Get the assets by group, the liabilities (outstanding capital of the loans, card debt) and the net worth, in the base currency.
+// The history can be limited to the last month or year (?period=month or year)
+func GetNetWorth(w http.ResponseWriter, r *http.Request) {
+
+	now := time.Now()
+	var since time.Time
+	switch r.URL.Query().Get("period") {
+	case "", "all":
+	case "month":
+		since = now.AddDate(0, -1, 0)
+	case "year":
+		since = now.AddDate(-1, 0, 0)
+	default:
+		http.Error(w, "period must be all, month or year", http.StatusBadRequest)
+		return
+	}
+
+	converter, err := fx.LoadConverter()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot load fx rates")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	accounts, err := readAccounts()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read accounts")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	loans, err := loan.ReadLoans()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read loans")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	propertyValues, err := readPropertyValues()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read property values")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	// Loan accounts are liabilities through the loan table, unless Powens sent no loan details
+	loanAccounts := make(map[int]bool)
+	for _, l := range loans {
+		loanAccounts[l.Loan_account_id] = true
+	}
+
+	netWorth := NetWorth{Currency: converter.Base()}
+	assets := map[string]money.Amount{}
+	liabilities := map[string]money.Amount{}
+
+	for accountId, account := range accounts {
+		value, err := converter.ToBase(account.balance, account.currency, now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		switch {
+		case bank.AccountGroup(account.accountType) != "":
+			assets[bank.AccountGroup(account.accountType)] += value
+		case account.accountType == "card":
+			liabilities[groupCards] -= value
+		case account.accountType == "loan" && !loanAccounts[accountId]:
+			liabilities[groupLoans] -= value
+		}
+	}
+	for _, l := range loans {
+		value, err := converter.ToBase(l.OutstandingCapital(), accounts[l.Loan_account_id].currency, now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		liabilities[groupLoans] += value
+	}
+	for _, value := range lastValues(propertyValues) {
+		assets[groupRealEstate] += value
+	}
+
+	for _, name := range []string{bank.GroupStocks, bank.GroupBank, bank.GroupSavings, bank.GroupCrypto, groupRealEstate} {
+		netWorth.Assets = append(netWorth.Assets, Group{Name: name, Value: assets[name]})
+		netWorth.Total_assets += assets[name]
+	}
+	for _, name := range []string{groupLoans, groupCards} {
+		netWorth.Liabilities = append(netWorth.Liabilities, Group{Name: name, Value: liabilities[name]})
+		netWorth.Total_liabilities += liabilities[name]
+	}
+	netWorth.Net_worth = netWorth.Total_assets - netWorth.Total_liabilities
+
+	// History
+	assetValues, debtValues, err := readHistoryValues(converter, accounts, loanAccounts)
+	if errors.Is(err, fx.ErrNoRate) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read history values")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	// The currency of each loan has a rate, the conversion of its outstanding capital succeeded above
+	loanCapital := func(date time.Time) money.Amount {
+		var capital money.Amount
+		for _, l := range loans {
+			value, _ := converter.ToBase(l.OutstandingCapitalAt(date), accounts[l.Loan_account_id].currency, date)
+			capital += value
+		}
+		return capital
+	}
+
+	netWorth.History = buildHistory(
+		[][]investment.HistoryValuePoint{investment.SumHistoryValues(assetValues), investment.SumHistoryValues(dailyPropertyValues(propertyValues, now.Format("2006-01-02")))},
+		investment.SumHistoryValues(debtValues),
+		loanCapital,
+		since,
+	)
+
+	jsonBody, err := json.Marshal(netWorth)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal net worth")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Add the asset series and subtract the debts and the loan capital of each day, from the first known day
+func buildHistory(assetSeries [][]investment.HistoryValuePoint, debts []investment.HistoryValuePoint, loanCapital func(time.Time) money.Amount, since time.Time) []Point {
+
+	assets := make(map[time.Time]money.Amount)
+	liabilities := make(map[time.Time]money.Amount)
+	for _, series := range assetSeries {
+		for _, point := range series {
+			assets[point.DateValuation] += point.Valuation
+		}
+	}
+	for _, point := range debts {
+		liabilities[point.DateValuation] += point.Valuation
+	}
+
+	var dates []time.Time
+	for date := range assets {
+		dates = append(dates, date)
+	}
+	for date := range liabilities {
+		if _, ok := assets[date]; !ok {
+			dates = append(dates, date)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+
+	var points []Point
+	for _, date := range dates {
+		if date.Before(since) {
+			continue
+		}
+		point := Point{
+			Date:        date.Format("2006-01-02"),
+			Assets:      assets[date],
+			Liabilities: liabilities[date] + loanCapital(date),
+		}
+		point.Net_worth = point.Assets - point.Liabilities
+		points = append(points, point)
+	}
+
+	return points
+}
+
+// Last value of each property
+func lastValues(propertyValues []investment.HistoryValue) map[int]money.Amount {
+
+	values := make(map[int]money.Amount)
+	for _, value := range propertyValues {
+		values[value.BankAccountId] = value.Valuation
+	}
+	return values
+}
+
+// Properties are only valued at their purchase and their estimations: each value is kept until the next one
+func dailyPropertyValues(propertyValues []investment.HistoryValue, until string) []investment.HistoryValue {
+
+	properties := make(map[int][]investment.HistoryValue)
+	var propertyIds []int
+	for _, value := range propertyValues {
+		if _, ok := properties[value.BankAccountId]; !ok {
+			propertyIds = append(propertyIds, value.BankAccountId)
+		}
+		properties[value.BankAccountId] = append(properties[value.BankAccountId], value)
+	}
+
+	var daily []investment.HistoryValue
+	for _, propertyId := range propertyIds {
+		daily = append(daily, investment.DailyValues(properties[propertyId], until)...)
+	}
+	return daily
+}
+
+func readAccounts() (map[int]account, error) {
+
+	accounts := make(map[int]account)
+
+	var query string = "SELECT account_id, account_type, currency, balance FROM bankAccount"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var accountId int
+		var account account
+		if err := rows.Scan(&accountId, &account.accountType, &account.currency, &account.balance); err != nil {
+			return nil, err
+		}
+		accounts[accountId] = account
+	}
+
+	return accounts, rows.Err()
+}
+
+// Read the history of the accounts in the base currency, split between assets and debts.
+// Debts are card accounts and loan accounts without loan details, as positive amounts owed
+func readHistoryValues(converter *fx.Converter, accounts map[int]account, loanAccounts map[int]bool) ([]investment.HistoryValue, []investment.HistoryValue, error) {
+
+	var assetValues, debtValues []investment.HistoryValue
+
+	var query string = "SELECT bank_account_id, valuation, date_valuation FROM historyValue ORDER BY date_valuation"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var historyValue investment.HistoryValue
+		if err := rows.Scan(&historyValue.BankAccountId, &historyValue.Valuation, &historyValue.DateValuation); err != nil {
+			return nil, nil, err
+		}
+
+		date, err := time.Parse("2006-01-02", historyValue.DateValuation)
+		if err != nil {
+			return nil, nil, err
+		}
+		account := accounts[historyValue.BankAccountId]
+		historyValue.Currency = account.currency
+		historyValue.Valuation, err = converter.ToBase(historyValue.Valuation, account.currency, date)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch {
+		case bank.AccountGroup(account.accountType) != "":
+			assetValues = append(assetValues, historyValue)
+		case account.accountType == "card", account.accountType == "loan" && !loanAccounts[historyValue.BankAccountId]:
+			historyValue.Valuation = historyValue.Valuation.Neg()
+			debtValues = append(debtValues, historyValue)
+		}
+	}
+
+	return assetValues, debtValues, rows.Err()
+}
+
+// Read the value of the part owned of each property: its purchase price, then its estimations.
+// The property id is used as account id
+func readPropertyValues() ([]investment.HistoryValue, error) {
+
+	var propertyValues []investment.HistoryValue
+
+	var query string = `SELECT p.property_id, p.purchase_price, p.purchase_date, p.ownership_share FROM realEstate p
+		UNION ALL SELECT v.property_id, v.valuation, v.date_valuation, p.ownership_share FROM realEstateValue v INNER JOIN realEstate p ON v.property_id = p.property_id
+		ORDER BY 3`
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var propertyValue investment.HistoryValue
+		var ownershipShare float64
+		if err := rows.Scan(&propertyValue.BankAccountId, &propertyValue.Valuation, &propertyValue.DateValuation, &ownershipShare); err != nil {
+			return nil, err
+		}
+		propertyValue.Valuation = propertyValue.Valuation.Mul(ownershipShare / 100)
+		propertyValues = append(propertyValues, propertyValue)
+	}
+
+	return propertyValues, rows.Err()
+}
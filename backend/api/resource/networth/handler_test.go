@@ -0,0 +1,51 @@
+package networth
+
+import (
+	"testing"
+	"time"
+
+	"financialApp/api/resource/investment"
+	"financialApp/money"
+)
+
+func TestBuildHistory(t *testing.T) {
+
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	accounts := []investment.HistoryValuePoint{
+		{DateValuation: day(1), Valuation: money.MustParse("1000")},
+		{DateValuation: day(2), Valuation: money.MustParse("1100")},
+		{DateValuation: day(3), Valuation: money.MustParse("1200")},
+	}
+	properties := []investment.HistoryValuePoint{
+		{DateValuation: day(2), Valuation: money.MustParse("200000")},
+		{DateValuation: day(3), Valuation: money.MustParse("200000")},
+	}
+	cards := []investment.HistoryValuePoint{
+		{DateValuation: day(3), Valuation: money.MustParse("300")},
+	}
+	// The loan was subscribed with the property
+	loanCapital := func(date time.Time) money.Amount {
+		if date.Before(day(2)) {
+			return 0
+		}
+		return money.MustParse("150000")
+	}
+
+	points := buildHistory([][]investment.HistoryValuePoint{accounts, properties}, cards, loanCapital, time.Time{})
+	want := []string{"1000.00", "51100.00", "50900.00"}
+	if len(points) != len(want) {
+		t.Fatalf("points = %+v", points)
+	}
+	for i, point := range points {
+		if got := point.Net_worth.StringFixed(2); got != want[i] {
+			t.Errorf("%s: net worth = %s, want %s", point.Date, got, want[i])
+		}
+	}
+
+	if points := buildHistory([][]investment.HistoryValuePoint{accounts, properties}, cards, loanCapital, day(2)); len(points) != 2 || points[0].Date != "2024-01-02" {
+		t.Errorf("points since the 2nd = %+v", points)
+	}
+}
@@ -0,0 +1,34 @@
+package networth
+
+import "financialApp/money"
+
+// Groups of assets and liabilities which are not account groups
+const (
+	groupRealEstate = "Real estate"
+	groupLoans      = "Loans"
+	groupCards      = "Card debt"
+)
+
+// Sum of a group of assets or liabilities, in the base currency. Liabilities are positive amounts owed
+type Group struct {
+	Name  string       `json:"name"`
+	Value money.Amount `json:"value"`
+}
+
+type Point struct {
+	Date        string       `json:"date"` // YYYY-MM-DD
+	Assets      money.Amount `json:"assets"`
+	Liabilities money.Amount `json:"liabilities"`
+	Net_worth   money.Amount `json:"net_worth"`
+}
+
+// What is owned minus what is owed, today and in the past
+type NetWorth struct {
+	Currency          string       `json:"currency"`
+	Assets            []Group      `json:"assets"`
+	Liabilities       []Group      `json:"liabilities"`
+	Total_assets      money.Amount `json:"total_assets"`
+	Total_liabilities money.Amount `json:"total_liabilities"`
+	Net_worth         money.Amount `json:"net_worth"`
+	History           []Point      `json:"history"`
+}
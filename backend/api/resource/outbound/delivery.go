@@ -0,0 +1,170 @@
+package outbound
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"financialApp/config"
+)
+
+// Headers of a delivery. The signature lets the receiver check that the event comes from Freenahi
+const (
+	HeaderEvent     = "X-Freenahi-Event"
+	HeaderDelivery  = "X-Freenahi-Delivery"
+	HeaderTimestamp = "X-Freenahi-Timestamp"
+	HeaderSignature = "X-Freenahi-Signature" // "sha256=" + Sign(secret, timestamp, body)
+)
+
+// Longest wait between two attempts
+const maxRetryDelay = 24 * time.Hour
+
+// Wakes the delivery loop up when an event is queued
+var wake = make(chan struct{}, 1)
+
+// Start the delivery loop, which sends the queued events and retries the failed ones
+func Init() {
+
+	client := &http.Client{Timeout: config.Conf.Outbound.Timeout}
+	interval := min(config.Conf.Outbound.RetryDelay, time.Minute)
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := deliverDue(client, time.Now()); err != nil {
+				config.Logger.Error().Err(err).Msg("Outbound webhook delivery failed")
+			}
+			select {
+			case <-wake:
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func wakeUp() {
+	select {
+	case wake <- struct{}{}:
+	default: // already woken up
+	}
+}
+
+// Delivery waiting to be sent, with its webhook
+type pendingDelivery struct {
+	id        int
+	eventId   string
+	eventType string
+	payload   []byte
+	attempts  int
+	url       string
+	secret    string
+}
+
+// Send every delivery whose attempt is due, and plan the next attempt of the failed ones
+func deliverDue(client *http.Client, now time.Time) error {
+
+	var deliveries []pendingDelivery
+
+	var query string = "SELECT d.delivery_id, d.event_id, d.event_type, d.payload, d.attempts, w.url, w.secret FROM outboundDelivery d INNER JOIN outboundWebhook w ON d.webhook_id = w.webhook_id WHERE d.delivery_status=? AND d.next_attempt_date<=? ORDER BY d.delivery_id"
+	rows, err := config.DB.Query(query, StatusPending, now.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d pendingDelivery
+		if err := rows.Scan(&d.id, &d.eventId, &d.eventType, &d.payload, &d.attempts, &d.url, &d.secret); err != nil {
+			return err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, d := range deliveries {
+		code, err := deliver(client, d, now)
+		d.attempts++
+
+		status, errorMessage, nextAttempt := StatusSent, "", sql.NullString{}
+		if err != nil {
+			errorMessage = err.Error()
+			if d.attempts >= config.Conf.Outbound.MaxAttempts {
+				status = StatusFailed
+			} else {
+				status = StatusPending
+				nextAttempt = sql.NullString{String: now.Add(retryDelay(config.Conf.Outbound.RetryDelay, d.attempts)).Format("2006-01-02 15:04:05"), Valid: true}
+			}
+			config.Logger.Warn().Err(err).Int("delivery_id", d.id).Int("attempts", d.attempts).Str("status", status).Msg("Outbound webhook attempt failed")
+		}
+
+		query = "UPDATE outboundDelivery SET delivery_status=?, attempts=?, response_code=?, error_message=?, last_attempt_date=?, next_attempt_date=? WHERE delivery_id=?"
+		if _, err := config.DB.Exec(query, status, d.attempts, code, errorMessage, now.Format("2006-01-02 15:04:05"), nextAttempt, d.id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Post the payload of a delivery, signed with the secret of its webhook. Return the status code of the answer, 0 without answer
+func deliver(client *http.Client, d pendingDelivery, now time.Time) (int, error) {
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(d.payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Freenahi-Webhook")
+	req.Header.Set(HeaderEvent, d.eventType)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(d.id))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(d.secret, timestamp, d.payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.New("webhook returned " + resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Hex HMAC-SHA256 of "timestamp.body" with the secret of a webhook. Signing the timestamp lets the receiver
+// refuse an old event sent again
+func Sign(secret, timestamp string, body []byte) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Wait before the attempt following a number of failed attempts: the delay is doubled after each failure
+func retryDelay(delay time.Duration, attempts int) time.Duration {
+
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
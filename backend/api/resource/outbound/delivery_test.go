@@ -0,0 +1,83 @@
+package outbound
+
+import (
+	"crypto/hmac"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeliver(t *testing.T) {
+
+	now := time.Unix(1710000000, 0)
+	payload := []byte(`{"id":"evt_1","type":"connection.synced"}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		// What a receiver does to check an event
+		timestamp := r.Header.Get(HeaderTimestamp)
+		expected := "sha256=" + Sign("a-secret-of-16-chars", timestamp, body)
+		if !hmac.Equal([]byte(r.Header.Get(HeaderSignature)), []byte(expected)) {
+			t.Errorf("Wrong signature %s", r.Header.Get(HeaderSignature))
+		}
+		if timestamp != "1710000000" || r.Header.Get(HeaderEvent) != "connection.synced" || r.Header.Get(HeaderDelivery) != "7" {
+			t.Errorf("Wrong headers %v", r.Header)
+		}
+		if r.URL.Path == "/down" {
+			http.Error(w, "", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	delivery := pendingDelivery{id: 7, eventId: "evt_1", eventType: EventConnectionSynced, payload: payload, url: server.URL + "/hook", secret: "a-secret-of-16-chars"}
+
+	code, err := deliver(server.Client(), delivery, now)
+	if err != nil || code != http.StatusOK {
+		t.Errorf("Expected a delivery, got %d %v", code, err)
+	}
+
+	// An answer other than 2xx is a failed attempt, with its status
+	delivery.url = server.URL + "/down"
+	code, err = deliver(server.Client(), delivery, now)
+	if err == nil || code != http.StatusServiceUnavailable {
+		t.Errorf("Expected a failure, got %d %v", code, err)
+	}
+
+	// Without answer, there is no status
+	delivery.url = "http://127.0.0.1:1/hook"
+	if code, err := deliver(server.Client(), delivery, now); err == nil || code != 0 {
+		t.Errorf("Expected a failure without status, got %d %v", code, err)
+	}
+}
+
+func TestSign(t *testing.T) {
+
+	// echo -n '1710000000.{}' | openssl dgst -sha256 -hmac secret
+	if got := Sign("secret", "1710000000", []byte("{}")); got != "618c90fd440f8668cbd569a24c01bda2f984abf9423581a030fc224e9e80c656" {
+		t.Errorf("Wrong signature %s", got)
+	}
+	if Sign("secret", "1710000000", []byte("{}")) == Sign("secret", "1710000001", []byte("{}")) {
+		t.Error("The timestamp must be signed")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{40, 24 * time.Hour},
+	}
+	for _, test := range tests {
+		if got := retryDelay(time.Minute, test.attempts); got != test.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", test.attempts, got, test.want)
+		}
+	}
+}
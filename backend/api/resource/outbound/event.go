@@ -0,0 +1,128 @@
+package outbound
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"financialApp/api/resource/bank"
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Balance of the accounts of a sync before it is processed, to compute the deltas. New accounts are absent
+func ReadBalances(accounts []bank.BankAccountWebhook) (map[int]money.Amount, error) {
+
+	balances := make(map[int]money.Amount)
+	if len(accounts) == 0 {
+		return balances, nil
+	}
+
+	vals := []any{}
+	for _, account := range accounts {
+		vals = append(vals, account.Account_id)
+	}
+
+	var query string = "SELECT account_id, balance FROM bankAccount WHERE account_id IN (?" + strings.Repeat(", ?", len(vals)-1) + ")"
+	rows, err := config.DB.Query(query, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var accountId int
+		var balance money.Amount
+		if err := rows.Scan(&accountId, &balance); err != nil {
+			return nil, err
+		}
+		balances[accountId] = balance
+	}
+
+	return balances, rows.Err()
+}
+
+// Send the summary of a processed sync to every enabled webhook
+func PublishSync(connectionId int, accounts []bank.BankAccountWebhook, previousBalances map[int]money.Amount) {
+
+	event := newEvent(EventConnectionSynced, NewSyncEvent(connectionId, accounts, previousBalances), time.Now())
+
+	webhooks, err := readWebhooks(true)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read outbound webhooks")
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if _, err := enqueue(webhook.Webhook_id, event, time.Now()); err != nil {
+			config.Logger.Error().Err(err).Int("webhook_id", webhook.Webhook_id).Msg("Cannot queue event")
+		}
+	}
+	wakeUp()
+}
+
+// Summary of a sync. Accounts without new transaction and with the same balance are left out
+func NewSyncEvent(connectionId int, accounts []bank.BankAccountWebhook, previousBalances map[int]money.Amount) SyncEvent {
+
+	event := SyncEvent{Connection_id: connectionId, Accounts: []AccountChange{}, Transactions: []TransactionNew{}}
+
+	for _, account := range accounts {
+		previous, known := previousBalances[account.Account_id]
+
+		change := AccountChange{
+			Account_id:       account.Account_id,
+			Name:             account.Original_name,
+			Currency:         account.Currency.Id,
+			Previous_balance: previous,
+			Balance:          account.Balance,
+			Delta:            account.Balance.Sub(previous),
+			New_transactions: len(account.Transactions),
+			New_account:      !known,
+		}
+		if known && change.Delta.IsZero() && change.New_transactions == 0 {
+			continue
+		}
+		event.Accounts = append(event.Accounts, change)
+
+		for _, tx := range account.Transactions {
+			event.Transactions = append(event.Transactions, TransactionNew{
+				Transaction_id: tx.Id,
+				Account_id:     account.Account_id,
+				Date:           tx.Date,
+				Value:          tx.Value,
+				Wording:        tx.Original_wording,
+				Type:           tx.Transaction_type,
+			})
+		}
+	}
+
+	return event
+}
+
+func newEvent(eventType string, data any, now time.Time) Event {
+
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return Event{Id: "evt_" + hex.EncodeToString(id), Type: eventType, Created: now.UTC().Format(time.RFC3339), Data: data}
+}
+
+// Record the delivery of an event to a webhook, sent by the next run of the delivery loop
+func enqueue(webhookId int, event Event, now time.Time) (int, error) {
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	var query string = "INSERT INTO outboundDelivery (webhook_id, event_id, event_type, payload, delivery_status, attempts, created_date, next_attempt_date) VALUES (?, ?, ?, ?, ?, 0, ?, ?)"
+	result, err := config.DB.Exec(query, webhookId, event.Id, event.Type, payload, StatusPending, now.Format("2006-01-02 15:04:05"), now.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
@@ -0,0 +1,67 @@
+package outbound
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"financialApp/api/resource/bank"
+	"financialApp/api/resource/miscellaneous"
+	"financialApp/api/resource/transaction"
+	"financialApp/money"
+)
+
+func TestNewSyncEvent(t *testing.T) {
+
+	accounts := []bank.BankAccountWebhook{
+		{Account_id: 1, Original_name: "Checking", Balance: money.MustParse("900"), Currency: miscellaneous.Currency{Id: "EUR"},
+			Transactions: []transaction.Transaction{{Id: 10, Value: money.MustParse("-100"), Original_wording: "GROCERIES", Date: "2024-03-09", Transaction_type: "card"}}},
+		{Account_id: 2, Original_name: "Savings", Balance: money.MustParse("5000")}, // unchanged
+		{Account_id: 3, Original_name: "New PEA", Balance: money.MustParse("250")},
+	}
+	previous := map[int]money.Amount{1: money.MustParse("1000"), 2: money.MustParse("5000")}
+
+	event := NewSyncEvent(42, accounts, previous)
+
+	if event.Connection_id != 42 || len(event.Accounts) != 2 || len(event.Transactions) != 1 {
+		t.Fatalf("Wrong event %+v", event)
+	}
+	checking, pea := event.Accounts[0], event.Accounts[1]
+	if checking.Delta != money.MustParse("-100") || checking.Previous_balance != money.MustParse("1000") || checking.New_transactions != 1 || checking.New_account {
+		t.Errorf("Wrong change %+v", checking)
+	}
+	if pea.Account_id != 3 || !pea.New_account || pea.Delta != money.MustParse("250") {
+		t.Errorf("Wrong new account %+v", pea)
+	}
+	if tx := event.Transactions[0]; tx.Transaction_id != 10 || tx.Account_id != 1 || tx.Wording != "GROCERIES" || tx.Type != "card" {
+		t.Errorf("Wrong transaction %+v", tx)
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	first, second := newEvent(EventPing, nil, now), newEvent(EventPing, nil, now)
+
+	if !strings.HasPrefix(first.Id, "evt_") || first.Id == second.Id || first.Created != "2024-03-10T08:00:00Z" {
+		t.Errorf("Wrong events %+v %+v", first, second)
+	}
+}
+
+func TestValidateWebhook(t *testing.T) {
+
+	webhook := Webhook{Url: " https://example.com/hook ", Description: " Home "}
+	if err := validateWebhook(&webhook); err != nil || webhook.Url != "https://example.com/hook" || webhook.Description != "Home" {
+		t.Errorf("Wrong webhook %+v %v", webhook, err)
+	}
+
+	for _, webhook := range []Webhook{
+		{Url: "ftp://example.com"},
+		{Url: "example.com/hook"},
+		{Url: "https://example.com", Secret: "short"},
+	} {
+		if err := validateWebhook(&webhook); err == nil {
+			t.Errorf("Expected an error for %+v", webhook)
+		}
+	}
+}
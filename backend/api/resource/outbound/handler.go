@@ -0,0 +1,325 @@
+package outbound
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"financialApp/config"
+)
+
+const defaultDeliveryLimit = 100
+
+// Register a webhook. The secret is returned in the answer only, keep it to check the signatures
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+
+	// A webhook is enabled unless told otherwise
+	webhook := Webhook{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateWebhook(&webhook); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		rand.Read(secret)
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	webhook.Created_date = time.Now().Format("2006-01-02 15:04:05")
+
+	var query string = "INSERT INTO outboundWebhook (url, secret, description, enabled, created_date) VALUES (?, ?, ?, ?, ?)"
+	result, err := config.DB.Exec(query, webhook.Url, webhook.Secret, webhook.Description, webhook.Enabled, webhook.Created_date)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get webhook id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	webhook.Webhook_id = int(id)
+
+	jsonBody, err := json.Marshal(webhook)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal webhook")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+// Get the webhooks, without their secret
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+
+	webhooks, err := readWebhooks(false)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read webhooks")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if len(webhooks) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	jsonBody, err := json.Marshal(webhooks)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal webhooks")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Update a webhook. Without secret in the body, the current one is kept
+func UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+
+	webhookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	if !exists(webhookId) {
+		http.Error(w, "Webhook does not exist", http.StatusNotFound)
+		return
+	}
+
+	webhook := Webhook{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateWebhook(&webhook); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var query string = "UPDATE outboundWebhook SET url=?, secret=IF(?='', secret, ?), description=?, enabled=? WHERE webhook_id=?"
+	_, err = config.DB.Exec(query, webhook.Url, webhook.Secret, webhook.Secret, webhook.Description, webhook.Enabled, webhookId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete a webhook and its deliveries
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+
+	webhookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var query string = "DELETE FROM outboundWebhook WHERE webhook_id=?"
+	result, err := config.DB.Exec(query, webhookId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		http.Error(w, "Webhook does not exist", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Queue a ping event for a webhook, to check that it receives the events and their signature
+func PingWebhook(w http.ResponseWriter, r *http.Request) {
+
+	webhookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	if !exists(webhookId) {
+		http.Error(w, "Webhook does not exist", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	event := newEvent(EventPing, map[string]int{"webhook_id": webhookId}, now)
+	deliveryId, err := enqueue(webhookId, event, now)
+	if err != nil {
+		config.Logger.Error().Err(err).Int("webhook_id", webhookId).Msg("Cannot queue ping")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	wakeUp()
+
+	payload, _ := json.Marshal(event)
+	jsonBody, err := json.Marshal(Delivery{
+		Delivery_id:       deliveryId,
+		Webhook_id:        webhookId,
+		Event_id:          event.Id,
+		Event_type:        event.Type,
+		Payload:           payload,
+		Status:            StatusPending,
+		Created_date:      now.Format("2006-01-02 15:04:05"),
+		Next_attempt_date: now.Format("2006-01-02 15:04:05"),
+	})
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal delivery")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+// Get the last deliveries of a webhook, most recent first. Optional query parameters: ?status=<pending, sent or failed>&limit=<number of deliveries, 100 by default>
+func GetDeliveries(w http.ResponseWriter, r *http.Request) {
+
+	webhookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != StatusPending && status != StatusSent && status != StatusFailed {
+		http.Error(w, "status must be pending, sent or failed", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			http.Error(w, "Wrong limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !exists(webhookId) {
+		http.Error(w, "Webhook does not exist", http.StatusNotFound)
+		return
+	}
+
+	var deliveries []Delivery
+
+	var query string = "SELECT delivery_id, webhook_id, event_id, event_type, payload, delivery_status, attempts, response_code, error_message, created_date, last_attempt_date, next_attempt_date FROM outboundDelivery WHERE webhook_id=? AND (?='' OR delivery_status=?) ORDER BY delivery_id DESC LIMIT ?"
+	rows, err := config.DB.Query(query, webhookId, status, status, limit)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery Delivery
+		var payload []byte
+		var lastAttempt, nextAttempt sql.NullString
+		if err := rows.Scan(&delivery.Delivery_id, &delivery.Webhook_id, &delivery.Event_id, &delivery.Event_type, &payload, &delivery.Status, &delivery.Attempts, &delivery.Response_code, &delivery.Error, &delivery.Created_date, &lastAttempt, &nextAttempt); err != nil {
+			config.Logger.Error().Err(err).Msg("Cannot scan row")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		delivery.Payload = payload
+		delivery.Last_attempt_date = lastAttempt.String
+		delivery.Next_attempt_date = nextAttempt.String
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		config.Logger.Error().Err(err).Msg("Error in rows")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if len(deliveries) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	jsonBody, err := json.Marshal(deliveries)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal deliveries")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Read every webhook, or only the enabled ones
+func readWebhooks(onlyEnabled bool) ([]Webhook, error) {
+
+	var webhooks []Webhook
+
+	var query string = "SELECT webhook_id, url, secret, description, enabled, created_date FROM outboundWebhook WHERE (?=FALSE OR enabled) ORDER BY webhook_id"
+	rows, err := config.DB.Query(query, onlyEnabled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var webhook Webhook
+		if err := rows.Scan(&webhook.Webhook_id, &webhook.Url, &webhook.Secret, &webhook.Description, &webhook.Enabled, &webhook.Created_date); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func exists(webhookId int) bool {
+
+	var exists bool
+	var query string = "SELECT EXISTS (SELECT 1 FROM outboundWebhook WHERE webhook_id=?)"
+	if err := config.DB.QueryRow(query, webhookId).Scan(&exists); err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		return false
+	}
+	return exists
+}
+
+// Check the user input
+func validateWebhook(webhook *Webhook) error {
+
+	webhook.Url = strings.TrimSpace(webhook.Url)
+	parsed, err := url.Parse(webhook.Url)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("url must be an http or https URL")
+	}
+
+	webhook.Secret = strings.TrimSpace(webhook.Secret)
+	if webhook.Secret != "" && len(webhook.Secret) < 16 {
+		return errors.New("secret must have at least 16 characters")
+	}
+
+	webhook.Description = strings.TrimSpace(webhook.Description)
+	return nil
+}
@@ -0,0 +1,82 @@
+package outbound
+
+import (
+	"encoding/json"
+
+	"financialApp/money"
+)
+
+// Types of events
+const (
+	EventConnectionSynced = "connection.synced"
+	EventPing             = "ping" // sent on request, to check a webhook
+)
+
+// Delivery of an event to a webhook
+const (
+	StatusPending = "pending" // waiting for its first or next attempt
+	StatusSent    = "sent"
+	StatusFailed  = "failed" // every attempt failed
+)
+
+// URL receiving the events, signed with the secret.
+// Ex: {"url": "http://homeassistant.local:8123/api/webhook/freenahi", "description": "Home Assistant"}
+type Webhook struct {
+	Webhook_id   int    `json:"id"`
+	Url          string `json:"url"`
+	Secret       string `json:"secret,omitempty"` // generated if absent at creation. Only returned at creation
+	Description  string `json:"description"`
+	Enabled      bool   `json:"enabled"` // true if absent at creation
+	Created_date string `json:"created_date"`
+}
+
+// Body posted to the webhooks
+type Event struct {
+	Id      string `json:"id"`
+	Type    string `json:"type"`
+	Created string `json:"created"` // RFC 3339
+	Data    any    `json:"data"`    // SyncEvent for connection.synced
+}
+
+// Summary of a sync: the accounts whose balance changed or with new transactions
+type SyncEvent struct {
+	Connection_id int              `json:"connection_id"`
+	Accounts      []AccountChange  `json:"accounts"`
+	Transactions  []TransactionNew `json:"transactions"`
+}
+
+type AccountChange struct {
+	Account_id       int          `json:"account_id"`
+	Name             string       `json:"name"`
+	Currency         string       `json:"currency"`
+	Previous_balance money.Amount `json:"previous_balance"` // 0 for a new account
+	Balance          money.Amount `json:"balance"`
+	Delta            money.Amount `json:"delta"`
+	New_transactions int          `json:"new_transactions"`
+	New_account      bool         `json:"new_account"`
+}
+
+type TransactionNew struct {
+	Transaction_id int          `json:"id"`
+	Account_id     int          `json:"account_id"`
+	Date           string       `json:"date"`
+	Value          money.Amount `json:"value"`
+	Wording        string       `json:"wording"`
+	Type           string       `json:"type"`
+}
+
+// Attempts to send an event to a webhook
+type Delivery struct {
+	Delivery_id       int             `json:"id"`
+	Webhook_id        int             `json:"webhook_id"`
+	Event_id          string          `json:"event_id"`
+	Event_type        string          `json:"event_type"`
+	Payload           json.RawMessage `json:"payload"`
+	Status            string          `json:"status"` // pending, sent or failed
+	Attempts          int             `json:"attempts"`
+	Response_code     int             `json:"response_code"` // of the last attempt, 0 without answer
+	Error             string          `json:"error,omitempty"`
+	Created_date      string          `json:"created_date"`
+	Last_attempt_date string          `json:"last_attempt_date,omitempty"`
+	Next_attempt_date string          `json:"next_attempt_date,omitempty"` // empty once sent or failed
+}
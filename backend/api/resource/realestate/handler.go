@@ -0,0 +1,364 @@
+package realestate
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"financialApp/api/resource/loan"
+	"financialApp/config"
+	"financialApp/money"
+)
+
+func CreateProperty(w http.ResponseWriter, r *http.Request) {
+
+	var property Property
+	if err := json.NewDecoder(r.Body).Decode(&property); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateProperty(&property); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The property is created with its first value, or not at all
+	dbTx, err := config.DB.Begin()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot begin transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer dbTx.Rollback()
+
+	var query string = "INSERT INTO realEstate (property_name, purchase_price, purchase_date, fees, ownership_share, monthly_rent, loan_account_id) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	result, err := dbTx.Exec(query, property.Name, property.Purchase_price, property.Purchase_date, property.Fees, property.Ownership_share, property.Monthly_rent, property.Loan_account_id)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get property id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	property.Property_id = int(id)
+
+	// The purchase price is the first known value of the property
+	query = "INSERT INTO realEstateValue (property_id, valuation, date_valuation) VALUES (?, ?, ?)"
+	_, err = dbTx.Exec(query, property.Property_id, property.Purchase_price, property.Purchase_date)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot commit transaction")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	property.Value = property.Purchase_price
+
+	if err := fillEquity(&property); err != nil {
+		config.Logger.Error().Err(err).Int("property_id", property.Property_id).Msg("Cannot compute equity")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(property)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal property")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+func GetProperties(w http.ResponseWriter, r *http.Request) {
+
+	var properties []Property
+
+	// The value of a property is its last valuation
+	var query string = `SELECT p.property_id, p.property_name, p.purchase_price, p.purchase_date, p.fees, p.ownership_share, p.monthly_rent, p.loan_account_id,
+		COALESCE((SELECT v.valuation FROM realEstateValue v WHERE v.property_id = p.property_id ORDER BY v.date_valuation DESC, v.value_id DESC LIMIT 1), p.purchase_price)
+		FROM realEstate p ORDER BY p.property_name`
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var property Property
+		var loanAccountId sql.NullInt64
+		if err := rows.Scan(&property.Property_id, &property.Name, &property.Purchase_price, &property.Purchase_date, &property.Fees, &property.Ownership_share, &property.Monthly_rent, &loanAccountId, &property.Value); err != nil {
+			config.Logger.Error().Err(err).Msg("Cannot scan row")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		if loanAccountId.Valid {
+			id := int(loanAccountId.Int64)
+			property.Loan_account_id = &id
+		}
+		properties = append(properties, property)
+	}
+	if err := rows.Err(); err != nil {
+		config.Logger.Error().Err(err).Msg("Error in rows")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range properties {
+		if err := fillEquity(&properties[i]); err != nil {
+			config.Logger.Error().Err(err).Int("property_id", properties[i].Property_id).Msg("Cannot compute equity")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	jsonBody, err := json.Marshal(properties)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal properties")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+func UpdateProperty(w http.ResponseWriter, r *http.Request) {
+
+	propertyId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	if !exists(propertyId) {
+		http.Error(w, "Property does not exist", http.StatusNotFound)
+		return
+	}
+
+	var property Property
+	if err := json.NewDecoder(r.Body).Decode(&property); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateProperty(&property); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var query string = "UPDATE realEstate SET property_name=?, purchase_price=?, purchase_date=?, fees=?, ownership_share=?, monthly_rent=?, loan_account_id=? WHERE property_id=?"
+	_, err = config.DB.Exec(query, property.Name, property.Purchase_price, property.Purchase_date, property.Fees, property.Ownership_share, property.Monthly_rent, property.Loan_account_id, propertyId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func DeleteProperty(w http.ResponseWriter, r *http.Request) {
+
+	propertyId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	if !exists(propertyId) {
+		http.Error(w, "Property does not exist", http.StatusNotFound)
+		return
+	}
+
+	// Order matters because of foreign keys
+	for _, query := range []string{
+		"DELETE FROM realEstateValue WHERE property_id=?",
+		"DELETE FROM realEstate WHERE property_id=?",
+	} {
+		if _, err := config.DB.Exec(query, propertyId); err != nil {
+			config.Logger.Error().Err(err).Msg(query)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Add an estimated value to a property
+func CreatePropertyValue(w http.ResponseWriter, r *http.Request) {
+
+	propertyId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	if !exists(propertyId) {
+		http.Error(w, "Property does not exist", http.StatusNotFound)
+		return
+	}
+
+	var value PropertyValue
+	if err := json.NewDecoder(r.Body).Decode(&value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	value.Property_id = propertyId
+
+	if value.Date_valuation == "" {
+		value.Date_valuation = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", value.Date_valuation); err != nil {
+		http.Error(w, "Wrong date, must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	var query string = "INSERT INTO realEstateValue (property_id, valuation, date_valuation) VALUES (?, ?, ?)"
+	result, err := config.DB.Exec(query, value.Property_id, value.Valuation, value.Date_valuation)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get property value id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	value.Value_id = int(id)
+
+	jsonBody, err := json.Marshal(value)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal property value")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+// Get the estimated values of a property, sorted by date
+func GetPropertyValues(w http.ResponseWriter, r *http.Request) {
+
+	propertyId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var values []PropertyValue
+
+	var query string = "SELECT value_id, property_id, valuation, date_valuation FROM realEstateValue WHERE property_id=? ORDER BY date_valuation, value_id"
+	rows, err := config.DB.Query(query, propertyId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value PropertyValue
+		if err := rows.Scan(&value.Value_id, &value.Property_id, &value.Valuation, &value.Date_valuation); err != nil {
+			config.Logger.Error().Err(err).Msg("Cannot scan row")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		config.Logger.Error().Err(err).Msg("Error in rows")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	jsonBody, err := json.Marshal(values)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal property values")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+func validateProperty(property *Property) error {
+
+	if property.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if property.Purchase_date == "" {
+		property.Purchase_date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", property.Purchase_date); err != nil {
+		return errors.New("wrong purchase date, must be YYYY-MM-DD")
+	}
+
+	if property.Ownership_share == 0 {
+		property.Ownership_share = 100
+	}
+	if property.Ownership_share < 0 || property.Ownership_share > 100 {
+		return errors.New("ownership share must be a percentage between 0 and 100")
+	}
+
+	if property.Loan_account_id != nil {
+		if _, err := loan.ReadLoan(*property.Loan_account_id); err != nil {
+			return errors.New("loan " + strconv.Itoa(*property.Loan_account_id) + " does not exist")
+		}
+	}
+
+	return nil
+}
+
+// Fill the outstanding capital of the linked loan, and the equity of the property
+func fillEquity(property *Property) error {
+
+	property.Outstanding_capital = 0
+
+	if property.Loan_account_id != nil {
+		// The foreign key keeps the loan as long as a property is linked to it
+		linkedLoan, err := loan.ReadLoan(*property.Loan_account_id)
+		if err != nil {
+			return err
+		}
+		property.Outstanding_capital = linkedLoan.OutstandingCapital()
+	}
+
+	property.Equity = computeEquity(property.Value, property.Ownership_share, property.Outstanding_capital)
+	return nil
+}
+
+// Equity is the part of the property owned by the user minus what remains to be paid to the bank
+func computeEquity(value money.Amount, ownershipShare float32, outstandingCapital money.Amount) money.Amount {
+	return value.Mul(float64(ownershipShare)/100) - outstandingCapital
+}
+
+func exists(propertyId int) bool {
+
+	var exists bool
+	var query string = "SELECT EXISTS (SELECT 1 FROM realEstate WHERE property_id=?)"
+	if err := config.DB.QueryRow(query, propertyId).Scan(&exists); err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		return false
+	}
+	return exists
+}
@@ -0,0 +1,29 @@
+package realestate
+
+import (
+	"testing"
+
+	"financialApp/money"
+)
+
+func TestComputeEquity(t *testing.T) {
+
+	tests := []struct {
+		value              string
+		ownershipShare     float32
+		outstandingCapital string
+		want               string
+	}{
+		{value: "300000", ownershipShare: 100, outstandingCapital: "0", want: "300000.00"},
+		{value: "300000", ownershipShare: 50, outstandingCapital: "100000", want: "50000.00"},
+		{value: "200000", ownershipShare: 100, outstandingCapital: "250000", want: "-50000.00"},
+		{value: "333333.33", ownershipShare: 25, outstandingCapital: "0.01", want: "83333.3225"},
+	}
+
+	for _, test := range tests {
+		got := computeEquity(money.MustParse(test.value), test.ownershipShare, money.MustParse(test.outstandingCapital))
+		if got.String() != test.want {
+			t.Errorf("Wrong equity: got %v want %v", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package realestate
+
+import "financialApp/money"
+
+// A property owned (fully or partly) by the user, entered manually
+type Property struct {
+	Property_id     int          `json:"id"`
+	Name            string       `json:"name"`
+	Purchase_price  money.Amount `json:"purchase_price"`
+	Purchase_date   string       `json:"purchase_date"` // YYYY-MM-DD
+	Fees            money.Amount `json:"fees"`          // notary, agency...
+	Ownership_share float32      `json:"ownership_share"`
+	Monthly_rent    money.Amount `json:"monthly_rent"`
+	Loan_account_id *int         `json:"loan_account_id"` // optional, account_id of the loan which finances the property
+
+	// Computed, not stored
+	Value               money.Amount `json:"value"`               // last estimated value of the whole property
+	Outstanding_capital money.Amount `json:"outstanding_capital"` // capital which remains to be paid on the linked loan
+	Equity              money.Amount `json:"equity"`              // value * ownership share - outstanding capital
+}
+
+// Estimated value of a property at a given date
+type PropertyValue struct {
+	Value_id       int          `json:"id"`
+	Property_id    int          `json:"id_property"`
+	Valuation      money.Amount `json:"valuation"`
+	Date_valuation string       `json:"date_valuation"` // YYYY-MM-DD
+}
@@ -0,0 +1,439 @@
+package taxlot
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"financialApp/api/resource/transaction"
+	"financialApp/config"
+	"financialApp/money"
+)
+
+// Implemented by *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Add a buy or a sell of a security to an investment account
+func CreateOperation(w http.ResponseWriter, r *http.Request) {
+
+	var operation Operation
+	if err := json.NewDecoder(r.Body).Decode(&operation); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateOperation(&operation); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	var query string = "SELECT EXISTS (SELECT 1 FROM bankAccount WHERE account_id=?)"
+	if err := config.DB.QueryRow(query, operation.Account_id).Scan(&exists); err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Account does not exist", http.StatusNotFound)
+		return
+	}
+
+	// Cannot sell more than what is held
+	if operation.Operation_type == "sell" {
+		operations, err := readOperations(operation.Account_id)
+		if err != nil {
+			config.Logger.Error().Err(err).Msg("Cannot read invest operations")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+
+		var held float64
+		lots, _ := computeLots(operations, methodFifo)
+		for _, lot := range lots {
+			if lot.Code == operation.Code && (lot.Code != "" || lot.Label == operation.Label) {
+				held += lot.Quantity
+			}
+		}
+		if operation.Quantity > held+quantityEpsilon {
+			http.Error(w, "Cannot sell more than the quantity held", http.StatusBadRequest)
+			return
+		}
+	}
+
+	query = "INSERT INTO investOperation (account_id, invest_code, invest_label, operation_type, quantity, unit_price, fees, operation_date, source) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'manual')"
+	result, err := config.DB.Exec(query, operation.Account_id, operation.Code, operation.Label, operation.Operation_type, operation.Quantity, operation.Unit_price, operation.Fees, operation.Date)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot get operation id")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	operation.Operation_id = int(id)
+	operation.Source = "manual"
+
+	jsonBody, err := json.Marshal(operation)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal invest operation")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonBody)
+}
+
+// Get the operations of all accounts, or of one with ?account=id
+func GetOperations(w http.ResponseWriter, r *http.Request) {
+
+	accountId := 0
+	if value := r.URL.Query().Get("account"); value != "" {
+		var err error
+		accountId, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Wrong account id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	operations, err := readOperations(accountId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read invest operations")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if len(operations) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	jsonBody, err := json.Marshal(operations)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal invest operations")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+func DeleteOperation(w http.ResponseWriter, r *http.Request) {
+
+	operationId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Wrong id", http.StatusBadRequest)
+		return
+	}
+
+	var query string = "DELETE FROM investOperation WHERE operation_id=?"
+	result, err := config.DB.Exec(query, operationId)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		http.Error(w, "Operation does not exist", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Report the realized gains by year and the unrealized gains of each account.
+// Sales can be limited to a year (?year=2024). The cost of a sale is the weighted average cost of the quantity held
+// (?method=average, default, the French rule), or the cost of the oldest lots (?method=fifo)
+func GetGains(w http.ResponseWriter, r *http.Request) {
+
+	method := r.URL.Query().Get("method")
+	if method == "" {
+		method = methodAverage
+	}
+	if method != methodAverage && method != methodFifo {
+		http.Error(w, "method must be average or fifo", http.StatusBadRequest)
+		return
+	}
+
+	year := 0
+	if value := r.URL.Query().Get("year"); value != "" {
+		var err error
+		year, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Wrong year", http.StatusBadRequest)
+			return
+		}
+	}
+
+	operations, err := readOperations(0)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read invest operations")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if len(operations) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	accounts, err := readAccounts()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read accounts")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	unitValues, err := readUnitValues()
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read invest unit values")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	report := gains(operations, accounts, unitValues, method, year)
+
+	jsonBody, err := json.Marshal(report)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot marshal gains")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Write(jsonBody)
+}
+
+// Group the lots and sales by account, and value the lots held with the last unit value of the security
+func gains(operations []Operation, accounts map[int]AccountGains, unitValues map[lotKey]money.Amount, method string, year int) []AccountGains {
+
+	lots, sales := computeLots(operations, method)
+
+	var report []AccountGains
+	indexes := make(map[int]int)
+	accountGains := func(accountId int) *AccountGains {
+		index, ok := indexes[accountId]
+		if !ok {
+			account := accounts[accountId]
+			account.Account_id = accountId
+			account.Method = method
+			account.Taxable = !slices.Contains(wrapperAccountTypes, account.Account_type)
+			index = len(report)
+			indexes[accountId] = index
+			report = append(report, account)
+		}
+		return &report[index]
+	}
+
+	for _, lot := range lots {
+		account := accountGains(lot.Account_id)
+		key := lotKey{lot.Account_id, lot.Code}
+		if key.code == "" {
+			key.code = lot.Label
+		}
+		if unitValue, ok := unitValues[key]; ok {
+			lot.Unit_value = unitValue
+			lot.Unrealized = lot.Unit_value.Mul(lot.Quantity) - lot.Unit_cost.Mul(lot.Quantity)
+			account.Unrealized += lot.Unrealized
+		}
+		account.Lots = append(account.Lots, lot)
+	}
+
+	for _, sale := range sales {
+		account := accountGains(sale.Account_id)
+		if year != 0 && !strings.HasPrefix(sale.Date, strconv.Itoa(year)) {
+			continue
+		}
+		account.Sales = append(account.Sales, sale)
+	}
+	for i := range report {
+		report[i].Years = gainsByYear(report[i].Sales)
+	}
+
+	return report
+}
+
+// Get the result of the positions of an account fully sold by their operations, with the average cost.
+// Used to close the positions which disappear from a sync
+func PositionGains(accountId int) (map[string]money.Amount, error) {
+
+	operations, err := readOperations(accountId)
+	if err != nil {
+		return nil, err
+	}
+	return positionGains(operations, methodAverage), nil
+}
+
+// Turn the securities traded by Powens transactions into operations, and save them.
+// A transaction debiting the account is a buy, one crediting it is a sell. Fees are not detailed by Powens
+func SaveTransactionOperations(db execer, txs []transaction.Transaction) error {
+
+	query := "INSERT INTO investOperation (account_id, invest_code, invest_label, operation_type, quantity, unit_price, fees, operation_date, source, powens_tx_id) VALUES "
+	vals := []any{}
+	for _, tx := range txs {
+		for _, operation := range operationsFromTransaction(tx) {
+			query += "(?, ?, ?, ?, ?, ?, 0, ?, 'powens', ?),"
+			vals = append(vals, operation.Account_id, operation.Code, operation.Label, operation.Operation_type, operation.Quantity, operation.Unit_price, operation.Date, tx.Id)
+		}
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+	query = query[0 : len(query)-1]
+
+	// if duplicate entry, update the field by the new value
+	query += " AS new(a, b, Nlabel, Ntype, Nquantity, Nunit_price, c, Ndate, d, e)"
+	query += " ON DUPLICATE KEY UPDATE invest_label=Nlabel, operation_type=Ntype, quantity=Nquantity, unit_price=Nunit_price, operation_date=Ndate"
+
+	_, err := db.Exec(query, vals...)
+	return err
+}
+
+func operationsFromTransaction(tx transaction.Transaction) []Operation {
+
+	var operations []Operation
+	for _, investment := range tx.Investments {
+		quantity := math.Abs(investment.Quantity)
+		if quantity < quantityEpsilon {
+			continue
+		}
+
+		operation := Operation{
+			Account_id:     tx.Account_id,
+			Code:           investment.Code,
+			Label:          investment.Label,
+			Operation_type: "buy",
+			Quantity:       quantity,
+			Unit_price:     investment.Valuation.Abs().Mul(1 / quantity),
+			Date:           tx.Date,
+			Source:         "powens",
+		}
+		if tx.Value.Sign() > 0 {
+			operation.Operation_type = "sell"
+		}
+		if len(operation.Date) > 10 {
+			operation.Date = operation.Date[0:10]
+		}
+		operations = append(operations, operation)
+	}
+
+	return operations
+}
+
+func validateOperation(operation *Operation) error {
+
+	if operation.Account_id == 0 {
+		return errors.New("id_account is required")
+	}
+
+	operation.Code = strings.ToUpper(strings.TrimSpace(operation.Code))
+	operation.Label = strings.TrimSpace(operation.Label)
+	if operation.Code == "" && operation.Label == "" {
+		return errors.New("code or label is required")
+	}
+
+	if operation.Operation_type != "buy" && operation.Operation_type != "sell" {
+		return errors.New("type must be buy or sell")
+	}
+
+	if operation.Quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+	if operation.Unit_price < 0 || operation.Fees < 0 {
+		return errors.New("unit price and fees cannot be negative")
+	}
+
+	if operation.Date == "" {
+		operation.Date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", operation.Date); err != nil {
+		return errors.New("wrong date, must be YYYY-MM-DD")
+	}
+
+	return nil
+}
+
+// Read the operations of an account, or of all accounts with 0, in the order they happened
+func readOperations(accountId int) ([]Operation, error) {
+
+	var operations []Operation
+
+	var query string = "SELECT operation_id, account_id, invest_code, invest_label, operation_type, quantity, unit_price, fees, operation_date, source FROM investOperation WHERE ? = 0 OR account_id = ? ORDER BY operation_date, operation_id"
+	rows, err := config.DB.Query(query, accountId, accountId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var operation Operation
+		if err := rows.Scan(&operation.Operation_id, &operation.Account_id, &operation.Code, &operation.Label, &operation.Operation_type, &operation.Quantity, &operation.Unit_price, &operation.Fees, &operation.Date, &operation.Source); err != nil {
+			return nil, err
+		}
+		operations = append(operations, operation)
+	}
+
+	return operations, rows.Err()
+}
+
+// Read the name, type and currency of the accounts
+func readAccounts() (map[int]AccountGains, error) {
+
+	accounts := make(map[int]AccountGains)
+
+	var query string = "SELECT account_id, original_name, account_type, currency FROM bankAccount"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var account AccountGains
+		if err := rows.Scan(&account.Account_id, &account.Name, &account.Account_type, &account.Currency); err != nil {
+			return nil, err
+		}
+		accounts[account.Account_id] = account
+	}
+
+	return accounts, rows.Err()
+}
+
+// Read the last unit value of the open invests, by account and security
+func readUnitValues() (map[lotKey]money.Amount, error) {
+
+	unitValues := make(map[lotKey]money.Amount)
+
+	var query string = "SELECT account_id, invest_code, invest_label, unit_value FROM invest WHERE closed_date IS NULL"
+	rows, err := config.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key lotKey
+		var label string
+		var unitValue money.Amount
+		if err := rows.Scan(&key.accountId, &key.code, &label, &unitValue); err != nil {
+			return nil, err
+		}
+		if key.code == "" {
+			key.code = label
+		}
+		unitValues[key] = unitValue
+	}
+
+	return unitValues, rows.Err()
+}
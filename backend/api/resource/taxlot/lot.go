@@ -0,0 +1,172 @@
+package taxlot
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"financialApp/money"
+)
+
+// Quantities below are rounding errors of float quantities
+const quantityEpsilon = 1e-9
+
+// Powens account types which are tax wrappers: gains are taxed on withdrawal, not when a security is sold
+var wrapperAccountTypes = []string{"article83", "capitalisation", "lifeinsurance", "madelin", "pea", "pee", "per", "perco", "perp", "rsp"}
+
+type lotKey struct {
+	accountId int
+	code      string
+}
+
+// Replay the operations, sorted by date, and return the lots still held and the sales with their gain.
+// A sell of more than what is held only realizes the quantity held
+func computeLots(operations []Operation, method string) ([]Lot, []Sale) {
+
+	var keys []lotKey
+	held := make(map[lotKey][]Lot)
+	var sales []Sale
+
+	for _, operation := range operations {
+		// Securities without ISIN are identified by their label, like positions
+		key := lotKey{operation.Account_id, operation.Code}
+		if key.code == "" {
+			key.code = operation.Label
+		}
+		if _, ok := held[key]; !ok {
+			keys = append(keys, key)
+			held[key] = nil
+		}
+
+		switch operation.Operation_type {
+		case "buy":
+			cost := operation.Unit_price.Mul(operation.Quantity) + operation.Fees
+			lot := Lot{
+				Account_id: operation.Account_id,
+				Code:       operation.Code,
+				Label:      operation.Label,
+				Date:       operation.Date,
+				Quantity:   operation.Quantity,
+				Unit_cost:  cost.Mul(1 / operation.Quantity),
+			}
+
+			// With the average method, a single lot is kept at the average cost
+			if method == methodAverage && len(held[key]) == 1 {
+				previous := held[key][0]
+				quantity := previous.Quantity + lot.Quantity
+				previous.Unit_cost = (previous.Unit_cost.Mul(previous.Quantity) + cost).Mul(1 / quantity)
+				previous.Quantity = quantity
+				held[key][0] = previous
+			} else {
+				held[key] = append(held[key], lot)
+			}
+
+		case "sell":
+			sale := Sale{
+				Account_id: operation.Account_id,
+				Code:       operation.Code,
+				Label:      operation.Label,
+				Date:       operation.Date,
+			}
+
+			// Take the quantity from the oldest lots. There is a single one with the average method
+			remaining := operation.Quantity
+			lots := held[key]
+			for remaining > quantityEpsilon && len(lots) > 0 {
+				taken := math.Min(remaining, lots[0].Quantity)
+				sale.Cost += lots[0].Unit_cost.Mul(taken)
+				sale.Quantity += taken
+				remaining -= taken
+
+				lots[0].Quantity -= taken
+				if lots[0].Quantity < quantityEpsilon {
+					lots = lots[1:]
+				}
+			}
+			held[key] = lots
+
+			if sale.Quantity == 0 {
+				continue
+			}
+			sale.Proceeds = operation.Unit_price.Mul(sale.Quantity) - operation.Fees.Mul(sale.Quantity/operation.Quantity)
+			sale.Gain = sale.Proceeds - sale.Cost
+			sales = append(sales, sale)
+		}
+	}
+
+	var lots []Lot
+	for _, key := range keys {
+		lots = append(lots, held[key]...)
+	}
+
+	return lots, sales
+}
+
+// Sum the gains of the sales of each security since it was last bought while none was held, by ISIN,
+// or by label without ISIN. Only the securities fully sold are given: the result of their position is known
+func positionGains(operations []Operation, method string) map[string]money.Amount {
+
+	held := make(map[string]float64)
+	periods := make(map[string][]Operation)
+	for _, operation := range operations {
+		key := operation.Code
+		if key == "" {
+			key = operation.Label
+		}
+
+		switch operation.Operation_type {
+		case "buy":
+			if held[key] < quantityEpsilon {
+				periods[key] = nil
+			}
+			held[key] += operation.Quantity
+		case "sell":
+			held[key] = math.Max(held[key]-operation.Quantity, 0)
+		}
+		periods[key] = append(periods[key], operation)
+	}
+
+	gains := make(map[string]money.Amount)
+	for key, period := range periods {
+		if held[key] > quantityEpsilon {
+			continue
+		}
+		_, sales := computeLots(period, method)
+		if len(sales) == 0 {
+			continue
+		}
+		for _, sale := range sales {
+			gains[key] += sale.Gain
+		}
+	}
+
+	return gains
+}
+
+// Sum the sales by year, most recent first
+func gainsByYear(sales []Sale) []YearGains {
+
+	byYear := make(map[int]*YearGains)
+	for _, sale := range sales {
+		year, err := strconv.Atoi(sale.Date[0:4])
+		if err != nil {
+			continue
+		}
+		if byYear[year] == nil {
+			byYear[year] = &YearGains{Year: year}
+		}
+		byYear[year].Proceeds += sale.Proceeds
+		byYear[year].Cost += sale.Cost
+		byYear[year].Realized += sale.Gain
+	}
+
+	var years []YearGains
+	for _, gains := range byYear {
+		years = append(years, *gains)
+	}
+	sort.Slice(years, func(i, j int) bool {
+		return years[i].Year > years[j].Year
+	})
+
+	return years
+}
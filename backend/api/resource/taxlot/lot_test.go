@@ -0,0 +1,149 @@
+package taxlot
+
+import (
+	"testing"
+
+	"financialApp/api/resource/transaction"
+	"financialApp/money"
+)
+
+func TestComputeLots(t *testing.T) {
+
+	operations := []Operation{
+		{Account_id: 1, Code: "FR0000120271", Operation_type: "buy", Quantity: 10, Unit_price: money.MustParse("50"), Fees: money.MustParse("10"), Date: "2023-01-10"},
+		{Account_id: 1, Code: "FR0000120271", Operation_type: "buy", Quantity: 10, Unit_price: money.MustParse("70"), Date: "2023-06-10"},
+		{Account_id: 1, Code: "FR0000120271", Operation_type: "sell", Quantity: 15, Unit_price: money.MustParse("80"), Fees: money.MustParse("15"), Date: "2024-03-01"},
+		// Same security in another account, not mixed with the first one
+		{Account_id: 2, Code: "FR0000120271", Operation_type: "buy", Quantity: 5, Unit_price: money.MustParse("100"), Date: "2023-02-01"},
+	}
+
+	tests := []struct {
+		method   string
+		cost     string
+		gain     string
+		unitCost string
+		lotDate  string
+	}{
+		// 10 bought at 51 (fees included), 5 of the 10 bought at 70
+		{method: methodFifo, cost: "860.00", gain: "325.00", unitCost: "70.00", lotDate: "2023-06-10"},
+		// 15 at the average cost of 60.5
+		{method: methodAverage, cost: "907.50", gain: "277.50", unitCost: "60.50", lotDate: "2023-01-10"},
+	}
+
+	for _, tt := range tests {
+		lots, sales := computeLots(operations, tt.method)
+
+		if len(sales) != 1 {
+			t.Fatalf("%s: sales = %+v", tt.method, sales)
+		}
+		if got := sales[0].Proceeds.StringFixed(2); got != "1185.00" {
+			t.Errorf("%s: proceeds = %s, want 1185.00", tt.method, got)
+		}
+		if got := sales[0].Cost.StringFixed(2); got != tt.cost {
+			t.Errorf("%s: cost = %s, want %s", tt.method, got, tt.cost)
+		}
+		if got := sales[0].Gain.StringFixed(2); got != tt.gain {
+			t.Errorf("%s: gain = %s, want %s", tt.method, got, tt.gain)
+		}
+
+		if len(lots) != 2 {
+			t.Fatalf("%s: lots = %+v", tt.method, lots)
+		}
+		if lots[0].Quantity != 5 || lots[0].Unit_cost.StringFixed(2) != tt.unitCost || lots[0].Date != tt.lotDate {
+			t.Errorf("%s: remaining lot = %+v", tt.method, lots[0])
+		}
+		if lots[1].Account_id != 2 || lots[1].Quantity != 5 {
+			t.Errorf("%s: other account lot = %+v", tt.method, lots[1])
+		}
+	}
+}
+
+func TestGains(t *testing.T) {
+
+	operations := []Operation{
+		{Account_id: 1, Code: "FR0000120271", Operation_type: "buy", Quantity: 10, Unit_price: money.MustParse("50"), Date: "2023-01-10"},
+		{Account_id: 1, Code: "FR0000120271", Operation_type: "sell", Quantity: 4, Unit_price: money.MustParse("60"), Date: "2023-05-10"},
+		{Account_id: 1, Code: "FR0000120271", Operation_type: "sell", Quantity: 2, Unit_price: money.MustParse("40"), Date: "2024-05-10"},
+		{Account_id: 2, Label: "World fund", Operation_type: "buy", Quantity: 1, Unit_price: money.MustParse("100"), Date: "2024-01-10"},
+	}
+	accounts := map[int]AccountGains{
+		1: {Name: "Securities", Account_type: "market"},
+		2: {Name: "PEA", Account_type: "pea"},
+	}
+	unitValues := map[lotKey]money.Amount{
+		{1, "FR0000120271"}: money.MustParse("55"),
+		{2, "World fund"}:   money.MustParse("120"),
+	}
+
+	report := gains(operations, accounts, unitValues, methodAverage, 0)
+	if len(report) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	if !report[0].Taxable || report[1].Taxable {
+		t.Errorf("taxable = %v, %v", report[0].Taxable, report[1].Taxable)
+	}
+	if len(report[0].Years) != 2 || report[0].Years[0].Year != 2024 || report[0].Years[0].Realized.StringFixed(2) != "-20.00" || report[0].Years[1].Realized.StringFixed(2) != "40.00" {
+		t.Errorf("years = %+v", report[0].Years)
+	}
+	if got := report[0].Unrealized.StringFixed(2); got != "20.00" {
+		t.Errorf("unrealized = %s, want 20.00", got)
+	}
+	if got := report[1].Unrealized.StringFixed(2); got != "20.00" {
+		t.Errorf("unrealized = %s, want 20.00", got)
+	}
+
+	report = gains(operations, accounts, unitValues, methodAverage, 2023)
+	if len(report[0].Sales) != 1 || len(report[0].Years) != 1 || report[0].Years[0].Year != 2023 {
+		t.Errorf("sales of 2023 = %+v", report[0].Sales)
+	}
+}
+
+func TestOperationsFromTransaction(t *testing.T) {
+
+	tx := transaction.Transaction{
+		Id:         7,
+		Account_id: 3,
+		Date:       "2024-02-05",
+		Value:      money.MustParse("-1000"),
+		Investments: []transaction.TxInvestment{
+			{Code: "IE00B4L5Y983", Label: "World fund", Quantity: 8, Valuation: money.MustParse("-1000")},
+			{Code: "FR0000000000", Label: "Nothing traded", Quantity: 0},
+		},
+	}
+
+	operations := operationsFromTransaction(tx)
+	if len(operations) != 1 {
+		t.Fatalf("operations = %+v", operations)
+	}
+	if operations[0].Operation_type != "buy" || operations[0].Quantity != 8 || operations[0].Unit_price.StringFixed(2) != "125.00" || operations[0].Account_id != 3 {
+		t.Errorf("operation = %+v", operations[0])
+	}
+
+	tx.Value = money.MustParse("1000")
+	if operations := operationsFromTransaction(tx); operations[0].Operation_type != "sell" {
+		t.Errorf("operation = %+v", operations[0])
+	}
+}
+
+func TestPositionGains(t *testing.T) {
+
+	operations := []Operation{
+		// Sold, bought again and sold: only the last position is the one closed
+		{Account_id: 1, Code: "FR0000120271", Operation_type: "buy", Quantity: 10, Unit_price: money.MustParse("40"), Date: "2022-01-10"},
+		{Account_id: 1, Code: "FR0000120271", Operation_type: "sell", Quantity: 10, Unit_price: money.MustParse("45"), Date: "2022-06-10"},
+		{Account_id: 1, Code: "FR0000120271", Operation_type: "buy", Quantity: 10, Unit_price: money.MustParse("50"), Date: "2023-01-10"},
+		{Account_id: 1, Code: "FR0000120271", Operation_type: "buy", Quantity: 10, Unit_price: money.MustParse("70"), Date: "2023-06-10"},
+		{Account_id: 1, Code: "FR0000120271", Operation_type: "sell", Quantity: 5, Unit_price: money.MustParse("80"), Date: "2024-03-01"},
+		{Account_id: 1, Code: "FR0000120271", Operation_type: "sell", Quantity: 15, Unit_price: money.MustParse("60"), Fees: money.MustParse("10"), Date: "2024-04-01"},
+		// Still partly held
+		{Account_id: 1, Label: "Fonds euros", Operation_type: "buy", Quantity: 5, Unit_price: money.MustParse("100"), Date: "2023-02-01"},
+		{Account_id: 1, Label: "Fonds euros", Operation_type: "sell", Quantity: 2, Unit_price: money.MustParse("110"), Date: "2024-02-01"},
+	}
+
+	gains := positionGains(operations, methodAverage)
+
+	// Average cost of 60: 5 × (80 - 60) + 15 × (60 - 60) - 10
+	if len(gains) != 1 || gains["FR0000120271"].StringFixed(2) != "90.00" {
+		t.Errorf("gains = %v", gains)
+	}
+}
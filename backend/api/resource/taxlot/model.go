@@ -0,0 +1,68 @@
+package taxlot
+
+import "financialApp/money"
+
+// Methods giving the cost of the quantity sold
+const (
+	methodAverage = "average" // weighted average cost of the quantity held, the French rule for a securities account
+	methodFifo    = "fifo"    // cost of the oldest lots first
+)
+
+// Buy or sell of a security in an investment account, entered manually or sent by Powens with a transaction
+type Operation struct {
+	Operation_id   int          `json:"id"`
+	Account_id     int          `json:"id_account"`
+	Code           string       `json:"code"` // ISIN, like invest_code
+	Label          string       `json:"label"`
+	Operation_type string       `json:"type"` // buy or sell
+	Quantity       float64      `json:"quantity"`
+	Unit_price     money.Amount `json:"unitprice"`
+	Fees           money.Amount `json:"fees"`
+	Date           string       `json:"date"`   // YYYY-MM-DD
+	Source         string       `json:"source"` // manual or powens
+}
+
+// Quantity of a security bought at a given cost and still held
+type Lot struct {
+	Account_id int          `json:"id_account"`
+	Code       string       `json:"code"`
+	Label      string       `json:"label"`
+	Date       string       `json:"date"` // buy date, the first one still held with the average method
+	Quantity   float64      `json:"quantity"`
+	Unit_cost  money.Amount `json:"unitcost"` // fees included
+	Unit_value money.Amount `json:"unitvalue"`
+	Unrealized money.Amount `json:"unrealized"`
+}
+
+// Gain realized by a sell
+type Sale struct {
+	Account_id int          `json:"id_account"`
+	Code       string       `json:"code"`
+	Label      string       `json:"label"`
+	Date       string       `json:"date"`
+	Quantity   float64      `json:"quantity"`
+	Proceeds   money.Amount `json:"proceeds"` // fees deducted
+	Cost       money.Amount `json:"cost"`
+	Gain       money.Amount `json:"gain"`
+}
+
+type YearGains struct {
+	Year     int          `json:"year"`
+	Proceeds money.Amount `json:"proceeds"`
+	Cost     money.Amount `json:"cost"`
+	Realized money.Amount `json:"realized"`
+}
+
+// Realized gains by year and unrealized gains of an account
+type AccountGains struct {
+	Account_id   int          `json:"id_account"`
+	Name         string       `json:"original_name"`
+	Account_type string       `json:"account_type"`
+	Currency     string       `json:"currency"`
+	Method       string       `json:"method"`
+	Taxable      bool         `json:"taxable"` // false for tax wrappers (PEA, life insurance...): sells inside are not taxed, withdrawals are
+	Years        []YearGains  `json:"years"`
+	Unrealized   money.Amount `json:"unrealized"` // of lots with a known price
+	Lots         []Lot        `json:"lots"`
+	Sales        []Sale       `json:"sales"`
+}
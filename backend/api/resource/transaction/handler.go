@@ -1,10 +1,13 @@
 package transaction
 
 import (
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"strconv"
 
+	"financialApp/api/resource/events"
+	"financialApp/api/resource/manual"
 	"financialApp/config"
 )
 
@@ -23,6 +26,15 @@ func CreateTransaction(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	// The balance of a manual account is derived from its txs
+	if err := manual.Refresh(tx.Account_id); err != nil {
+		config.Logger.Error().Err(err).Int("account_id", tx.Account_id).Msg("Cannot refresh manual account")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	events.Publish(events.TypeTransactionChanged, events.TransactionChanged{Transaction_id: tx.Id, Account_id: tx.Account_id, Action: events.ActionCreated})
 }
 
 func ReadTransaction(w http.ResponseWriter, r *http.Request) {
@@ -96,6 +108,15 @@ func UpdateTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	accountId, err := refreshManualAccountOfTx(txId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(txId)
+	events.Publish(events.TypeTransactionChanged, events.TransactionChanged{Transaction_id: id, Account_id: accountId, Action: events.ActionUpdated})
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -103,7 +124,16 @@ func DeleteTransaction(w http.ResponseWriter, r *http.Request) {
 
 	txId := r.PathValue("id")
 
-	var query string = "DELETE from tx WHERE tx_id=?"
+	// Get the account before deleting the tx, needed to refresh manual accounts
+	var accountId int
+	var query string = "SELECT account_id FROM tx WHERE tx_id=?"
+	if err := config.DB.QueryRow(query, txId).Scan(&accountId); err != nil && err != sql.ErrNoRows {
+		config.Logger.Error().Err(err).Msg(query)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	query = "DELETE from tx WHERE tx_id=?"
 	_, err := config.DB.Exec(query, txId)
 	if err != nil {
 		config.Logger.Error().Err(err).Msg(query)
@@ -111,5 +141,34 @@ func DeleteTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := manual.Refresh(accountId); err != nil {
+		config.Logger.Error().Err(err).Int("account_id", accountId).Msg("Cannot refresh manual account")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(txId)
+	events.Publish(events.TypeTransactionChanged, events.TransactionChanged{Transaction_id: id, Account_id: accountId, Action: events.ActionDeleted})
+
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// Refresh the balance of the account of the given tx, if it is a manual account. Return the account of the tx, 0 if the tx does not exist
+func refreshManualAccountOfTx(txId string) (int, error) {
+
+	var accountId int
+	var query string = "SELECT account_id FROM tx WHERE tx_id=?"
+	if err := config.DB.QueryRow(query, txId).Scan(&accountId); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		config.Logger.Error().Err(err).Msg(query)
+		return 0, err
+	}
+
+	if err := manual.Refresh(accountId); err != nil {
+		config.Logger.Error().Err(err).Int("account_id", accountId).Msg("Cannot refresh manual account")
+		return 0, err
+	}
+	return accountId, nil
+}
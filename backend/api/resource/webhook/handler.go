@@ -6,6 +6,12 @@ import (
 	"strconv"
 	"time"
 
+	"financialApp/api/resource/alert"
+	"financialApp/api/resource/events"
+	"financialApp/api/resource/investment"
+	"financialApp/api/resource/loan"
+	"financialApp/api/resource/outbound"
+	"financialApp/api/resource/taxlot"
 	"financialApp/config"
 )
 
@@ -27,6 +33,14 @@ func ConnectionSynced(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Balances before the sync, for the deltas sent to the outbound webhooks
+	previousBalances, err := outbound.ReadBalances(conn.Connection.Accounts)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Cannot read balances before sync")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
 	for _, account := range conn.Connection.Accounts {
 
 		config.Logger.Trace().
@@ -51,14 +65,10 @@ func ConnectionSynced(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Add the current value of the account to history (used to draw graphs with historical data)
-		vals := []any{}
-		query = "INSERT INTO historyValue (bank_account_id, valuation, date_valuation) VALUES "
-		query += "(?, ?, ?),"
-		vals = append(vals, account.Account_id, account.Balance, account.Last_update)
-		query = query[0 : len(query)-1]
-		_, err = config.DB.Exec(query, vals...)
-		if err != nil {
-			config.Logger.Error().Err(err).Msg(query)
+		// A sync of the same day replaces the point of the day
+		historyValue := investment.HistoryValue{BankAccountId: account.Account_id, Valuation: account.Balance, DateValuation: account.Last_update}
+		if err := investment.RecordBalances(config.DB, []investment.HistoryValue{historyValue}); err != nil {
+			config.Logger.Error().Err(err).Int("account_id", account.Account_id).Msg("Cannot record balance")
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
@@ -70,12 +80,13 @@ func ConnectionSynced(w http.ResponseWriter, r *http.Request) {
 				Uint("nb_payments_done", account.Loan.Nb_payments_done).
 				Uint("nb_payments_left", account.Loan.Nb_payments_left).
 				Str("next_payment_date", account.Loan.Next_payment_date).
-				Float32("total_loan_amount", account.Loan.Total_amount).
+				Stringer("total_loan_amount", account.Loan.Total_amount).
 				Str("loan_type", account.Loan.Loan_type).
 				Msg("Loan update")
 
-			// Sometimes Powens does not calculate some values so we do it manually
-			if account.Loan.Nb_payments_total == 0 { // Manually calculate how much payments are left to pay
+			// Sometimes Powens does not calculate some values so we do it manually.
+			// A revolving credit has no payments count: it is used and refunded freely
+			if account.Loan.Nb_payments_total == 0 && !account.Loan.IsRevolving() { // Manually calculate how much payments are left to pay
 
 				// Manually calculate duration the ugly way
 				t1, err := time.Parse("2006-01-02 15:04:05", account.Loan.Maturity_date)
@@ -101,7 +112,7 @@ func ConnectionSynced(w http.ResponseWriter, r *http.Request) {
 
 			}
 
-			if account.Loan.Nb_payments_done == 0 {
+			if account.Loan.Nb_payments_done == 0 && !account.Loan.IsRevolving() {
 				account.Loan.Nb_payments_done = account.Loan.Nb_payments_total - account.Loan.Nb_payments_left
 				config.Logger.Trace().
 					Uint("Nb_payments_total", account.Loan.Nb_payments_total).
@@ -128,6 +139,16 @@ func ConnectionSynced(w http.ResponseWriter, r *http.Request) {
 				http.Error(w, "", http.StatusInternalServerError)
 				return
 			}
+
+			// Keep the use of a revolving credit over time
+			if account.Loan.IsRevolving() {
+				account.Loan.Loan_account_id = account.Account_id
+				if err := loan.RecordUsage(config.DB, account.Loan, account.Last_update); err != nil {
+					config.Logger.Error().Err(err).Int("account_id", account.Account_id).Msg("Cannot record revolving credit usage")
+					http.Error(w, "", http.StatusInternalServerError)
+					return
+				}
+			}
 		}
 
 		// Proceed with transactions
@@ -143,7 +164,7 @@ func ConnectionSynced(w http.ResponseWriter, r *http.Request) {
 					Str("date", tx.Date).
 					Str("original_wording", tx.Original_wording).
 					Int("tx_id", tx.Id).
-					Float32("value", tx.Value).
+					Stringer("value", tx.Value).
 					Msg("Tx update")
 
 				query += "(?, ?, ?, ?, ?, ?, ?),"
@@ -159,13 +180,20 @@ func ConnectionSynced(w http.ResponseWriter, r *http.Request) {
 				http.Error(w, "", http.StatusInternalServerError)
 				return
 			}
+
+			// Securities bought or sold by the txs become operations, for tax lots
+			if err := taxlot.SaveTransactionOperations(config.DB, account.Transactions); err != nil {
+				config.Logger.Error().Err(err).Int("account_id", account.Account_id).Msg("Cannot save invest operations")
+				http.Error(w, "", http.StatusInternalServerError)
+				return
+			}
 		}
 
 		// Proceed with invests
 		if len(account.Investments) != 0 {
 
 			// Bulk insert invests
-			query = "INSERT INTO invest (invest_id, account_id, invest_label, invest_code, invest_code_type, stock_symbol, quantity, unit_price, unit_value, valuation, diff, diff_percent, last_update) VALUES "
+			query = "INSERT INTO invest (invest_id, account_id, invest_label, invest_code, invest_code_type, stock_symbol, quantity, unit_price, unit_value, valuation, diff, diff_percent, last_update, price_source) VALUES "
 			vals := []any{}
 
 			for _, invest := range account.Investments {
@@ -175,12 +203,12 @@ func ConnectionSynced(w http.ResponseWriter, r *http.Request) {
 					Str("code", invest.Code).
 					Int("invest_id", invest.Invest_id).
 					Str("label", invest.Label).
-					Float32("unit_price", invest.Unit_price).
-					Float32("unit_value", invest.Unit_value).
-					Float32("valuation", invest.Valuation).
+					Stringer("unit_price", invest.Unit_price).
+					Stringer("unit_value", invest.Unit_value).
+					Stringer("valuation", invest.Valuation).
 					Msg("Investment update")
 
-				query += "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?),"
+				query += "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'powens'),"
 
 				vals = append(vals, invest.Invest_id, invest.Account_id, invest.Label, invest.Code, invest.Code_type, invest.Stock_symbol, invest.Quantity, invest.Unit_price, invest.Unit_value, invest.Valuation, invest.Diff, invest.Diff_percent, invest.Last_update)
 			}
@@ -189,8 +217,8 @@ func ConnectionSynced(w http.ResponseWriter, r *http.Request) {
 			query = query[0 : len(query)-1]
 
 			// if duplicate entry, update the field by the new value
-			query += "AS new(a, b, c, d, e, f, Nquantity, Nunit_price, Nunit_value, Nvaluation, Ndiff, Ndiff_percent, Nlast_update)"
-			query += "ON DUPLICATE KEY UPDATE quantity=Nquantity, unit_price=Nunit_price, unit_value=Nunit_value, valuation=Nvaluation, diff=Ndiff, diff_percent=Ndiff_percent, last_update=Nlast_update"
+			query += "AS new(a, b, c, d, e, f, Nquantity, Nunit_price, Nunit_value, Nvaluation, Ndiff, Ndiff_percent, Nlast_update, Nprice_source)"
+			query += "ON DUPLICATE KEY UPDATE quantity=Nquantity, unit_price=Nunit_price, unit_value=Nunit_value, valuation=Nvaluation, diff=Ndiff, diff_percent=Ndiff_percent, last_update=Nlast_update, price_source=Nprice_source, closed_date=NULL, realized_result=NULL"
 
 			_, err := config.DB.Exec(query, vals...)
 			if err != nil {
@@ -198,6 +226,40 @@ func ConnectionSynced(w http.ResponseWriter, r *http.Request) {
 				http.Error(w, "", http.StatusInternalServerError)
 				return
 			}
+
+			// Keep the state of each position for the day, the invest table only has the latest one
+			if err := investment.RecordHistory(config.DB, investment.HistoryPoints(account.Investments)); err != nil {
+				config.Logger.Error().Err(err).Msg("Cannot record invest history")
+				http.Error(w, "", http.StatusInternalServerError)
+				return
+			}
 		}
+
+		// Positions missing from the sync have been sold. Without the investments key, Powens sent nothing to compare with
+		if account.Investments != nil {
+			gains, err := taxlot.PositionGains(account.Account_id)
+			if err != nil {
+				config.Logger.Error().Err(err).Int("account_id", account.Account_id).Msg("Cannot compute the result of sold invests")
+				http.Error(w, "", http.StatusInternalServerError)
+				return
+			}
+			if err := investment.ClosePositions(account.Account_id, account.Investments, time.Now().Format("2006-01-02"), gains); err != nil {
+				config.Logger.Error().Err(err).Int("account_id", account.Account_id).Msg("Cannot close sold invests")
+				http.Error(w, "", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	// Alert rules are checked on the synced data. Notifications are sent without delaying the answer to Powens
+	go alert.CheckSync(conn.Connection.Accounts)
+	go outbound.PublishSync(conn.Connection.Id, conn.Connection.Accounts, previousBalances)
+
+	// Tell the connected apps to reload what the sync changed
+	synced := events.SyncCompleted{Connection_id: conn.Connection.Id, Account_ids: []int{}}
+	for _, account := range conn.Connection.Accounts {
+		synced.Account_ids = append(synced.Account_ids, account.Account_id)
+		synced.New_transactions += len(account.Transactions)
 	}
+	events.Publish(events.TypeSyncCompleted, synced)
 }
@@ -3,11 +3,23 @@ package router
 import (
 	"net/http"
 
+	"financialApp/api/resource/alert"
+	"financialApp/api/resource/allocation"
 	"financialApp/api/resource/auth"
 	"financialApp/api/resource/bank"
+	"financialApp/api/resource/crypto"
+	"financialApp/api/resource/events"
+	"financialApp/api/resource/export"
+	"financialApp/api/resource/fx"
+	"financialApp/api/resource/income"
 	"financialApp/api/resource/investment"
 	"financialApp/api/resource/loan"
+	"financialApp/api/resource/manual"
 	"financialApp/api/resource/miscellaneous"
+	"financialApp/api/resource/networth"
+	"financialApp/api/resource/outbound"
+	"financialApp/api/resource/realestate"
+	"financialApp/api/resource/taxlot"
 	"financialApp/api/resource/transaction"
 	"financialApp/api/resource/webhook"
 	"financialApp/api/resource/webview"
@@ -27,27 +39,107 @@ func New() *http.ServeMux {
 	router.HandleFunc("/", middleware.Log(middleware.Whitelisted(miscellaneous.NotFound)))
 
 	router.HandleFunc("POST /webhook/connection_synced/", middleware.Log(middleware.Whitelisted(webhook.ConnectionSynced)))
+	router.HandleFunc("GET /events/{$}", middleware.Log(middleware.Whitelisted(events.Stream)))
 
 	router.HandleFunc("GET /bank_account/", middleware.Log(middleware.Whitelisted(bank.GetAccounts)))
 	router.HandleFunc("GET /bank_account/sum/", middleware.Log(middleware.Whitelisted(bank.GetAccountSum)))
+	router.HandleFunc("GET /networth/", middleware.Log(middleware.Whitelisted(networth.GetNetWorth)))
 
 	router.HandleFunc("GET /investment/", middleware.Log(middleware.Whitelisted(investment.GetInvestments)))
+	router.HandleFunc("GET /investment/performance/{$}", middleware.Log(middleware.Whitelisted(investment.GetPerformance)))
+	router.HandleFunc("GET /investment/closed/{$}", middleware.Log(middleware.Whitelisted(investment.GetClosedInvestments)))
+	router.HandleFunc("GET /investment/{id}/history/{$}", middleware.Log(middleware.Whitelisted(investment.GetInvestmentHistory)))
+
+	router.HandleFunc("POST /benchmark/", middleware.Log(middleware.Whitelisted(investment.CreateBenchmark)))
+	router.HandleFunc("GET /benchmark/", middleware.Log(middleware.Whitelisted(investment.GetBenchmarks)))
+	router.HandleFunc("DELETE /benchmark/{id}", middleware.Log(middleware.Whitelisted(investment.DeleteBenchmark)))
+	router.HandleFunc("POST /benchmark/{id}/value/", middleware.Log(middleware.Whitelisted(investment.CreateBenchmarkValues)))
+	router.HandleFunc("GET /benchmark/{id}/comparison/", middleware.Log(middleware.Whitelisted(investment.GetBenchmarkComparison)))
 
 	router.HandleFunc("GET /history/", middleware.Log(middleware.Whitelisted(investment.ReadHistoryValues)))
+	router.HandleFunc("GET /history/series/", middleware.Log(middleware.Whitelisted(investment.GetHistorySeries)))
 	router.HandleFunc("GET /history/{id}", middleware.Log(middleware.Whitelisted(investment.ReadHistoryValue)))
 
 	router.HandleFunc("GET /loan/", middleware.Log(middleware.Whitelisted(loan.GetLoans)))
+	router.HandleFunc("GET /loan/{id}/schedule", middleware.Log(middleware.Whitelisted(loan.GetSchedule)))
+	router.HandleFunc("GET /loan/{id}/usage", middleware.Log(middleware.Whitelisted(loan.GetUsage)))
+	router.HandleFunc("POST /loan/{id}/simulation", middleware.Log(middleware.Whitelisted(loan.SimulateLoan)))
+	router.HandleFunc("POST /loan/{id}/tranche", middleware.Log(middleware.Whitelisted(loan.CreateTranche)))
+	router.HandleFunc("GET /loan/{id}/tranche", middleware.Log(middleware.Whitelisted(loan.GetTranches)))
+	router.HandleFunc("DELETE /loan/tranche/{id}", middleware.Log(middleware.Whitelisted(loan.DeleteTranche)))
+	router.HandleFunc("POST /loan/tranche/{id}/rate", middleware.Log(middleware.Whitelisted(loan.CreateRateChange)))
+	router.HandleFunc("DELETE /loan/rate/{id}", middleware.Log(middleware.Whitelisted(loan.DeleteRateChange)))
 
 	router.HandleFunc("POST /transaction/", middleware.Log(middleware.Whitelisted(transaction.CreateTransaction)))
 	router.HandleFunc("GET /transaction/", middleware.Log(middleware.Whitelisted(transaction.ReadTransaction)))
 	router.HandleFunc("PUT /transaction/{id}", middleware.Log(middleware.Whitelisted(transaction.UpdateTransaction)))
 	router.HandleFunc("DELETE /transaction/{id}", middleware.Log(middleware.Whitelisted(transaction.DeleteTransaction)))
 
+	router.HandleFunc("POST /manual_account/", middleware.Log(middleware.Whitelisted(manual.CreateManualAccount)))
+	router.HandleFunc("GET /manual_account/", middleware.Log(middleware.Whitelisted(manual.GetManualAccounts)))
+	router.HandleFunc("PUT /manual_account/{id}", middleware.Log(middleware.Whitelisted(manual.UpdateManualAccount)))
+	router.HandleFunc("DELETE /manual_account/{id}", middleware.Log(middleware.Whitelisted(manual.DeleteManualAccount)))
+	router.HandleFunc("POST /manual_account/{id}/transaction/", middleware.Log(middleware.Whitelisted(manual.CreateManualTransaction)))
+
+	router.HandleFunc("POST /real_estate/", middleware.Log(middleware.Whitelisted(realestate.CreateProperty)))
+	router.HandleFunc("GET /real_estate/", middleware.Log(middleware.Whitelisted(realestate.GetProperties)))
+	router.HandleFunc("PUT /real_estate/{id}", middleware.Log(middleware.Whitelisted(realestate.UpdateProperty)))
+	router.HandleFunc("DELETE /real_estate/{id}", middleware.Log(middleware.Whitelisted(realestate.DeleteProperty)))
+	router.HandleFunc("POST /real_estate/{id}/value/", middleware.Log(middleware.Whitelisted(realestate.CreatePropertyValue)))
+	router.HandleFunc("GET /real_estate/{id}/value/", middleware.Log(middleware.Whitelisted(realestate.GetPropertyValues)))
+
+	router.HandleFunc("POST /crypto/wallet/", middleware.Log(middleware.Whitelisted(crypto.CreateWallet)))
+	router.HandleFunc("GET /crypto/wallet/", middleware.Log(middleware.Whitelisted(crypto.GetWallets)))
+	router.HandleFunc("DELETE /crypto/wallet/{id}", middleware.Log(middleware.Whitelisted(crypto.DeleteWallet)))
+	router.HandleFunc("POST /crypto/wallet/{id}/operation/", middleware.Log(middleware.Whitelisted(crypto.CreateOperation)))
+	router.HandleFunc("GET /crypto/operation/", middleware.Log(middleware.Whitelisted(crypto.GetOperations)))
+	router.HandleFunc("GET /crypto/position/", middleware.Log(middleware.Whitelisted(crypto.GetPositions)))
+
+	router.HandleFunc("GET /security/", middleware.Log(middleware.Whitelisted(allocation.GetSecurities)))
+	router.HandleFunc("POST /security/", middleware.Log(middleware.Whitelisted(allocation.ImportSecurities)))
+	router.HandleFunc("PUT /security/{code}", middleware.Log(middleware.Whitelisted(allocation.UpdateSecurity)))
+	router.HandleFunc("DELETE /security/{code}", middleware.Log(middleware.Whitelisted(allocation.DeleteSecurity)))
+	router.HandleFunc("GET /allocation/", middleware.Log(middleware.Whitelisted(allocation.GetAllocation)))
+	router.HandleFunc("GET /rebalance/", middleware.Log(middleware.Whitelisted(allocation.GetRebalance)))
+	router.HandleFunc("GET /rebalance/target/{scope}", middleware.Log(middleware.Whitelisted(allocation.GetTargets)))
+	router.HandleFunc("PUT /rebalance/target/{scope}", middleware.Log(middleware.Whitelisted(allocation.UpdateTargets)))
+	router.HandleFunc("POST /taxlot/operation/", middleware.Log(middleware.Whitelisted(taxlot.CreateOperation)))
+	router.HandleFunc("GET /taxlot/operation/", middleware.Log(middleware.Whitelisted(taxlot.GetOperations)))
+	router.HandleFunc("DELETE /taxlot/operation/{id}", middleware.Log(middleware.Whitelisted(taxlot.DeleteOperation)))
+	router.HandleFunc("GET /taxlot/gains/", middleware.Log(middleware.Whitelisted(taxlot.GetGains)))
+	router.HandleFunc("GET /income/", middleware.Log(middleware.Whitelisted(income.GetIncomes)))
+	router.HandleFunc("GET /income/report/", middleware.Log(middleware.Whitelisted(income.GetReport)))
+	router.HandleFunc("GET /income/rule/", middleware.Log(middleware.Whitelisted(income.GetRules)))
+	router.HandleFunc("POST /income/rule/", middleware.Log(middleware.Whitelisted(income.CreateRule)))
+	router.HandleFunc("DELETE /income/rule/{id}", middleware.Log(middleware.Whitelisted(income.DeleteRule)))
+	router.HandleFunc("PUT /income/tag/{id}", middleware.Log(middleware.Whitelisted(income.UpdateTag)))
+	router.HandleFunc("DELETE /income/tag/{id}", middleware.Log(middleware.Whitelisted(income.DeleteTag)))
+
+	router.HandleFunc("GET /fx/base/", middleware.Log(middleware.Whitelisted(fx.GetBaseCurrency)))
+	router.HandleFunc("GET /fx/rate/", middleware.Log(middleware.Whitelisted(fx.GetRates)))
+	router.HandleFunc("POST /fx/rate/", middleware.Log(middleware.Whitelisted(fx.CreateRates)))
+
+	router.HandleFunc("GET /alert/rule/", middleware.Log(middleware.Whitelisted(alert.GetRules)))
+	router.HandleFunc("POST /alert/rule/", middleware.Log(middleware.Whitelisted(alert.CreateRule)))
+	router.HandleFunc("PUT /alert/rule/{id}", middleware.Log(middleware.Whitelisted(alert.UpdateRule)))
+	router.HandleFunc("DELETE /alert/rule/{id}", middleware.Log(middleware.Whitelisted(alert.DeleteRule)))
+	router.HandleFunc("POST /alert/rule/{id}/test", middleware.Log(middleware.Whitelisted(alert.TestRule)))
+	router.HandleFunc("GET /alert/history/", middleware.Log(middleware.Whitelisted(alert.GetHistory)))
+
+	router.HandleFunc("GET /outbound_webhook/", middleware.Log(middleware.Whitelisted(outbound.GetWebhooks)))
+	router.HandleFunc("POST /outbound_webhook/", middleware.Log(middleware.Whitelisted(outbound.CreateWebhook)))
+	router.HandleFunc("PUT /outbound_webhook/{id}", middleware.Log(middleware.Whitelisted(outbound.UpdateWebhook)))
+	router.HandleFunc("DELETE /outbound_webhook/{id}", middleware.Log(middleware.Whitelisted(outbound.DeleteWebhook)))
+	router.HandleFunc("POST /outbound_webhook/{id}/ping", middleware.Log(middleware.Whitelisted(outbound.PingWebhook)))
+	router.HandleFunc("GET /outbound_webhook/{id}/delivery/", middleware.Log(middleware.Whitelisted(outbound.GetDeliveries)))
+
 	router.HandleFunc("POST /auth/permanentUserToken/", middleware.Log(middleware.Whitelisted(auth.CreatePermanentUserToken)))
 	router.HandleFunc("GET /auth/permanentUserToken/", middleware.Log(middleware.Whitelisted(auth.GetPermanentUserToken)))
 	router.HandleFunc("DELETE /auth/permanentUserToken/", middleware.Log(middleware.Whitelisted(auth.DeletePermanentUserToken)))
 
 	router.HandleFunc("GET /webview/manageConnectionLink/", middleware.Log(middleware.Whitelisted(webview.GetManageLink)))
 
+	router.HandleFunc("GET /export/journal/", middleware.Log(middleware.Whitelisted(export.GetJournal)))
+
 	return router
 }
@@ -9,6 +9,12 @@ import (
 	"os/signal"
 	"syscall"
 
+	"financialApp/api/resource/alert"
+	"financialApp/api/resource/crypto"
+	"financialApp/api/resource/events"
+	"financialApp/api/resource/fx"
+	"financialApp/api/resource/investment"
+	"financialApp/api/resource/outbound"
 	"financialApp/api/router"
 	"financialApp/config"
 )
@@ -16,6 +22,11 @@ import (
 func main() {
 
 	config.Init()
+	crypto.Init()
+	investment.Init()
+	fx.Init()
+	alert.Init()
+	outbound.Init()
 
 	router := router.New()
 
@@ -27,6 +38,9 @@ func main() {
 		IdleTimeout:  config.Conf.Server.TimeoutIdle,
 	}
 
+	// Shutdown waits for the open connections, the event streams never end by themselves
+	server.RegisterOnShutdown(events.Close)
+
 	// Correct way to handle a server shutdown
 	// https://dev.to/mokiat/proper-http-shutdown-in-go-3fji
 
@@ -47,11 +47,65 @@ type ConfOther struct {
 	Language string `env:"OTHER_LANGUAGE,required"`
 }
 
+type ConfExport struct {
+	MappingFile string `env:"EXPORT_MAPPING_FILE"` // Optional JSON file mapping account types and tx types to journal account names
+}
+
+type ConfCrypto struct {
+	PriceProvider    string             `env:"CRYPTO_PRICE_PROVIDER" envDefault:"none"` // none, coingecko or static
+	StaticPrices     map[string]float64 `env:"CRYPTO_STATIC_PRICES"`                    // Used by the static provider. Ex: BTC:60000,ETH:3000
+	Currency         string             `env:"CRYPTO_CURRENCY" envDefault:"EUR"`
+	SnapshotInterval time.Duration      `env:"CRYPTO_SNAPSHOT_INTERVAL" envDefault:"24h"`
+}
+
+type ConfInvest struct {
+	PriceProvider  string        `env:"INVEST_PRICE_PROVIDER" envDefault:"none"` // none, csv or http
+	PriceCsvFile   string        `env:"INVEST_PRICE_CSV_FILE"`                   // Used by the csv provider. Lines are: code,price[,date]
+	PriceHttpUrl   string        `env:"INVEST_PRICE_HTTP_URL"`                   // Used by the http provider. {isin} and {symbol} are replaced
+	PriceHttpField string        `env:"INVEST_PRICE_HTTP_FIELD" envDefault:"price"`
+	PriceInterval  time.Duration `env:"INVEST_PRICE_INTERVAL" envDefault:"1h"`
+}
+
+type ConfFx struct {
+	BaseCurrency string        `env:"BASE_CURRENCY" envDefault:"EUR"`     // Currency of every aggregate (sums, history...)
+	RateProvider string        `env:"FX_RATE_PROVIDER" envDefault:"none"` // none, csv or frankfurter
+	RateCsvFile  string        `env:"FX_RATE_CSV_FILE"`                   // Used by the csv provider. Lines are: currency,rate
+	RateInterval time.Duration `env:"FX_RATE_INTERVAL" envDefault:"24h"`
+}
+
+type ConfAlert struct {
+	SmtpHost     string        `env:"ALERT_SMTP_HOST"` // Notifiers without settings are disabled
+	SmtpPort     int           `env:"ALERT_SMTP_PORT" envDefault:"587"`
+	SmtpUsername string        `env:"ALERT_SMTP_USER"`
+	SmtpPassword string        `env:"ALERT_SMTP_PASS"`
+	SmtpFrom     string        `env:"ALERT_SMTP_FROM"`
+	SmtpTo       []string      `env:"ALERT_SMTP_TO"`
+	WebhookUrl   string        `env:"ALERT_WEBHOOK_URL"`
+	NtfyUrl      string        `env:"ALERT_NTFY_URL" envDefault:"https://ntfy.sh"`
+	NtfyTopic    string        `env:"ALERT_NTFY_TOPIC"`
+	NtfyToken    string        `env:"ALERT_NTFY_TOKEN"`
+	GotifyUrl    string        `env:"ALERT_GOTIFY_URL"`
+	GotifyToken  string        `env:"ALERT_GOTIFY_TOKEN"`
+	Timeout      time.Duration `env:"ALERT_TIMEOUT" envDefault:"10s"`
+}
+
+type ConfOutbound struct {
+	MaxAttempts int           `env:"OUTBOUND_WEBHOOK_MAX_ATTEMPTS" envDefault:"5"`
+	RetryDelay  time.Duration `env:"OUTBOUND_WEBHOOK_RETRY_DELAY" envDefault:"1m"` // Doubled after each failed attempt
+	Timeout     time.Duration `env:"OUTBOUND_WEBHOOK_TIMEOUT" envDefault:"10s"`
+}
+
 type ConfStruct struct {
-	Server ConfServer
-	DB     ConfDB
-	Powens ConfPowens
-	Other  ConfOther
+	Server   ConfServer
+	DB       ConfDB
+	Powens   ConfPowens
+	Other    ConfOther
+	Export   ConfExport
+	Crypto   ConfCrypto
+	Invest   ConfInvest
+	Fx       ConfFx
+	Alert    ConfAlert
+	Outbound ConfOutbound
 }
 
 func Init() {
@@ -78,6 +132,24 @@ func Init() {
 	if err := env.Parse(&Conf.Other); err != nil {
 		Logger.Fatal().Err(err).Msg("Failed to load env for Other")
 	}
+	if err := env.Parse(&Conf.Export); err != nil {
+		Logger.Fatal().Err(err).Msg("Failed to load env for Export")
+	}
+	if err := env.Parse(&Conf.Crypto); err != nil {
+		Logger.Fatal().Err(err).Msg("Failed to load env for Crypto")
+	}
+	if err := env.Parse(&Conf.Invest); err != nil {
+		Logger.Fatal().Err(err).Msg("Failed to load env for Invest")
+	}
+	if err := env.Parse(&Conf.Fx); err != nil {
+		Logger.Fatal().Err(err).Msg("Failed to load env for Fx")
+	}
+	if err := env.Parse(&Conf.Alert); err != nil {
+		Logger.Fatal().Err(err).Msg("Failed to load env for Alert")
+	}
+	if err := env.Parse(&Conf.Outbound); err != nil {
+		Logger.Fatal().Err(err).Msg("Failed to load env for Outbound")
+	}
 
 	// Set log level according to env value SERVER_LOG_LEVEL
 	switch Conf.Server.LogLevel {
@@ -0,0 +1,32 @@
+DROP TABLE IF EXISTS alertHistory, alertRule;
+CREATE TABLE alertRule (
+    rule_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    rule_name VARCHAR(255) NOT NULL,
+    rule_type VARCHAR(50) NOT NULL,
+    account_id INT NOT NULL DEFAULT 0,
+    amount DECIMAL(19,4) NOT NULL DEFAULT 0,
+    wording VARCHAR(255) NOT NULL DEFAULT '',
+    days INT NOT NULL DEFAULT 0,
+    percent DOUBLE NOT NULL DEFAULT 0,
+    notifier VARCHAR(50) NOT NULL,
+    target VARCHAR(255) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+
+    PRIMARY KEY (`rule_id`)
+);
+
+CREATE TABLE alertHistory (
+    alert_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    rule_id INT UNSIGNED NOT NULL,
+    alert_key VARCHAR(100) NOT NULL,
+    alert_date DATETIME NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    message VARCHAR(1000) NOT NULL,
+    notifier VARCHAR(50) NOT NULL,
+    alert_status VARCHAR(20) NOT NULL,
+    error_message VARCHAR(1000) NOT NULL DEFAULT '',
+
+    PRIMARY KEY (`alert_id`),
+    UNIQUE (`rule_id`, `alert_key`),
+    FOREIGN KEY (`rule_id`) REFERENCES alertRule(`rule_id`) ON DELETE CASCADE
+);
@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS securityAllocation;
+CREATE TABLE securityAllocation (
+    invest_code VARCHAR(255) NOT NULL,
+    dimension VARCHAR(20) NOT NULL,
+    bucket VARCHAR(100) NOT NULL,
+    weight DOUBLE NOT NULL,
+
+    PRIMARY KEY (`invest_code`, `dimension`, `bucket`)
+);
+
+DROP TABLE IF EXISTS rebalanceTarget;
+CREATE TABLE rebalanceTarget (
+    scope VARCHAR(20) NOT NULL,
+    target_key VARCHAR(255) NOT NULL,
+    weight DOUBLE NOT NULL,
+
+    PRIMARY KEY (`scope`, `target_key`)
+);
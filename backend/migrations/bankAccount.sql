@@ -5,7 +5,7 @@ CREATE TABLE bankAccount (
     bank_original_name VARCHAR(255) NOT NULL,
     bank_number VARCHAR(255) NOT NULL,
     original_name VARCHAR(255) NOT NULL,
-    balance FLOAT NOT NULL,
+    balance DECIMAL(19,4) NOT NULL,
     last_update DATETIME NOT NULL,
     iban VARCHAR(255) NOT NULL,
     currency VARCHAR(255) NOT NULL,
@@ -0,0 +1,19 @@
+DROP TABLE IF EXISTS benchmarkValue, benchmark;
+CREATE TABLE benchmark (
+    benchmark_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    benchmark_name VARCHAR(255) NOT NULL,
+    invest_code VARCHAR(255) NOT NULL DEFAULT '',
+    stock_symbol VARCHAR(255) NOT NULL DEFAULT '',
+
+    PRIMARY KEY (`benchmark_id`),
+    UNIQUE (`benchmark_name`)
+);
+
+CREATE TABLE benchmarkValue (
+    benchmark_id INT UNSIGNED NOT NULL,
+    value_date DATE NOT NULL,
+    value DOUBLE NOT NULL,
+
+    PRIMARY KEY (`benchmark_id`, `value_date`),
+    FOREIGN KEY (`benchmark_id`) REFERENCES benchmark(`benchmark_id`) ON DELETE CASCADE
+);
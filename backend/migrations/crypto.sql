@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS cryptoOperation;
+CREATE TABLE cryptoOperation (
+    operation_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    account_id INT NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    operation_type VARCHAR(10) NOT NULL,
+    quantity DOUBLE NOT NULL,
+    unit_price DECIMAL(19,4) NOT NULL,
+    fees DECIMAL(19,4) NOT NULL DEFAULT 0,
+    operation_date DATE NOT NULL,
+
+    PRIMARY KEY (`operation_id`),
+    FOREIGN KEY (`account_id`) REFERENCES bankAccount(`account_id`)
+);
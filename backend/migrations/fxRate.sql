@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS fxRate;
+CREATE TABLE fxRate (
+    base_currency VARCHAR(3) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    rate_date DATE NOT NULL,
+    rate DOUBLE NOT NULL,
+    source VARCHAR(50) NOT NULL,
+
+    PRIMARY KEY (`base_currency`, `currency`, `rate_date`)
+);
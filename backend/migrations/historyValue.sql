@@ -2,9 +2,10 @@ DROP TABLE IF EXISTS historyValue;
 CREATE TABLE historyValue (
     history_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
     bank_account_id INT NOT NULL,
-    valuation FLOAT NOT NULL,
+    valuation DECIMAL(19,4) NOT NULL,
     date_valuation DATE NOT NULL,
 
     PRIMARY KEY (`history_id`),
+    UNIQUE (`bank_account_id`, `date_valuation`),
     FOREIGN KEY (`bank_account_id`) REFERENCES bankAccount(`account_id`)
 );
@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS incomeRule;
+CREATE TABLE incomeRule (
+    rule_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    income_type VARCHAR(20) NOT NULL,
+    tx_type VARCHAR(255) NOT NULL DEFAULT '',
+    pattern VARCHAR(255) NOT NULL DEFAULT '',
+    account_id INT NOT NULL DEFAULT 0,
+    invest_code VARCHAR(255) NOT NULL DEFAULT '',
+
+    PRIMARY KEY (`rule_id`)
+);
+
+DROP TABLE IF EXISTS incomeTag;
+CREATE TABLE incomeTag (
+    tx_id INT NOT NULL,
+    income_type VARCHAR(20) NOT NULL,
+    invest_code VARCHAR(255) NOT NULL DEFAULT '',
+
+    PRIMARY KEY (`tx_id`)
+);
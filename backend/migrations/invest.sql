@@ -1,4 +1,4 @@
-DROP TABLE IF EXISTS invest;
+DROP TABLE IF EXISTS investHistory, investPrice, invest;
 CREATE TABLE invest (
     invest_id INT NOT NULL,
     account_id INT NOT NULL,
@@ -7,13 +7,40 @@ CREATE TABLE invest (
     invest_code_type VARCHAR(255) NOT NULL,
     stock_symbol VARCHAR(255) NOT NULL,
     quantity FLOAT NOT NULL,
-    unit_price FLOAT NOT NULL,
-    unit_value FLOAT NOT NULL,
-    valuation FLOAT NOT NULL,
-    diff FLOAT NOT NULL,
+    unit_price DECIMAL(19,4) NOT NULL,
+    unit_value DECIMAL(19,4) NOT NULL,
+    valuation DECIMAL(19,4) NOT NULL,
+    diff DECIMAL(19,4) NOT NULL,
     diff_percent FLOAT NOT NULL,
     last_update VARCHAR(255) NOT NULL,
+    price_source VARCHAR(50) NOT NULL DEFAULT 'powens',
+    closed_date DATE NULL,
+    realized_result DECIMAL(19,4) NULL,
 
     PRIMARY KEY (`invest_id`),
     FOREIGN KEY (`account_id`) REFERENCES bankAccount(`account_id`)
 );
+
+CREATE TABLE investPrice (
+    price_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    invest_code VARCHAR(255) NOT NULL,
+    price DECIMAL(19,4) NOT NULL,
+    price_date DATETIME NOT NULL,
+    source VARCHAR(50) NOT NULL,
+
+    PRIMARY KEY (`price_id`),
+    INDEX (`invest_code`, `price_date`)
+);
+
+CREATE TABLE investHistory (
+    invest_id INT NOT NULL,
+    account_id INT NOT NULL,
+    history_date DATE NOT NULL,
+    quantity FLOAT NOT NULL,
+    unit_price DECIMAL(19,4) NOT NULL,
+    unit_value DECIMAL(19,4) NOT NULL,
+    valuation DECIMAL(19,4) NOT NULL,
+
+    PRIMARY KEY (`invest_id`, `history_date`),
+    FOREIGN KEY (`invest_id`) REFERENCES invest(`invest_id`) ON DELETE CASCADE
+);
@@ -0,0 +1,19 @@
+DROP TABLE IF EXISTS investOperation;
+CREATE TABLE investOperation (
+    operation_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    account_id INT NOT NULL,
+    invest_code VARCHAR(255) NOT NULL,
+    invest_label VARCHAR(255) NOT NULL,
+    operation_type VARCHAR(10) NOT NULL,
+    quantity DOUBLE NOT NULL,
+    unit_price DECIMAL(19,4) NOT NULL,
+    fees DECIMAL(19,4) NOT NULL DEFAULT 0,
+    operation_date DATE NOT NULL,
+    source VARCHAR(50) NOT NULL DEFAULT 'manual',
+    powens_tx_id INT NULL,
+
+    PRIMARY KEY (`operation_id`),
+    UNIQUE (`powens_tx_id`, `invest_code`),
+    INDEX (`account_id`, `invest_code`, `operation_date`),
+    FOREIGN KEY (`account_id`) REFERENCES bankAccount(`account_id`)
+);
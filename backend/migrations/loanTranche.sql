@@ -0,0 +1,26 @@
+DROP TABLE IF EXISTS loanRateChange, loanTranche;
+CREATE TABLE loanTranche (
+    tranche_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    loan_account_id INT NOT NULL,
+    tranche_name VARCHAR(255) NOT NULL,
+    amount DECIMAL(19,4) NOT NULL,
+    rate FLOAT NOT NULL,
+    rate_cap FLOAT NOT NULL DEFAULT 0,
+    payments INT UNSIGNED NOT NULL,
+    deferred_payments INT UNSIGNED NOT NULL DEFAULT 0,
+    first_payment_date DATE NOT NULL,
+    insurance_amount DECIMAL(19,4) NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (`tranche_id`),
+    FOREIGN KEY (`loan_account_id`) REFERENCES loan(`loan_account_id`)
+);
+
+CREATE TABLE loanRateChange (
+    change_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    tranche_id INT UNSIGNED NOT NULL,
+    change_date DATE NOT NULL,
+    rate FLOAT NOT NULL,
+
+    PRIMARY KEY (`change_id`),
+    FOREIGN KEY (`tranche_id`) REFERENCES loanTranche(`tranche_id`) ON DELETE CASCADE
+);
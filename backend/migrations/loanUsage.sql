@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS loanUsage;
+CREATE TABLE loanUsage (
+    usage_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    loan_account_id INT NOT NULL,
+    usage_date DATE NOT NULL,
+    available_amount DECIMAL(19,4) NOT NULL,
+    used_amount DECIMAL(19,4) NOT NULL,
+
+    PRIMARY KEY (`usage_id`),
+    UNIQUE (`loan_account_id`, `usage_date`),
+    FOREIGN KEY (`loan_account_id`) REFERENCES loan(`loan_account_id`)
+);
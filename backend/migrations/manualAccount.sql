@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS manualAccount;
+CREATE TABLE manualAccount (
+    account_id INT NOT NULL,
+    initial_balance DECIMAL(19,4) NOT NULL,
+    creation_date DATE NOT NULL,
+
+    PRIMARY KEY (`account_id`),
+    FOREIGN KEY (`account_id`) REFERENCES bankAccount(`account_id`)
+);
@@ -0,0 +1,30 @@
+DROP TABLE IF EXISTS outboundDelivery, outboundWebhook;
+CREATE TABLE outboundWebhook (
+    webhook_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_date DATETIME NOT NULL,
+
+    PRIMARY KEY (`webhook_id`)
+);
+
+CREATE TABLE outboundDelivery (
+    delivery_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    webhook_id INT UNSIGNED NOT NULL,
+    event_id VARCHAR(50) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    delivery_status VARCHAR(20) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    response_code INT NOT NULL DEFAULT 0,
+    error_message VARCHAR(1000) NOT NULL DEFAULT '',
+    created_date DATETIME NOT NULL,
+    last_attempt_date DATETIME,
+    next_attempt_date DATETIME,
+
+    PRIMARY KEY (`delivery_id`),
+    INDEX (`delivery_status`, `next_attempt_date`),
+    FOREIGN KEY (`webhook_id`) REFERENCES outboundWebhook(`webhook_id`) ON DELETE CASCADE
+);
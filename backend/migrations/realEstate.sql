@@ -0,0 +1,24 @@
+DROP TABLE IF EXISTS realEstateValue, realEstate;
+CREATE TABLE realEstate (
+    property_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    property_name VARCHAR(255) NOT NULL,
+    purchase_price DECIMAL(19,4) NOT NULL,
+    purchase_date DATE NOT NULL,
+    fees DECIMAL(19,4) NOT NULL,
+    ownership_share FLOAT NOT NULL DEFAULT 100,
+    monthly_rent DECIMAL(19,4) NOT NULL DEFAULT 0,
+    loan_account_id INT NULL,
+
+    PRIMARY KEY (`property_id`),
+    FOREIGN KEY (`loan_account_id`) REFERENCES loan(`loan_account_id`)
+);
+
+CREATE TABLE realEstateValue (
+    value_id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    property_id INT UNSIGNED NOT NULL,
+    valuation DECIMAL(19,4) NOT NULL,
+    date_valuation DATE NOT NULL,
+
+    PRIMARY KEY (`value_id`),
+    FOREIGN KEY (`property_id`) REFERENCES realEstate(`property_id`)
+);
@@ -4,7 +4,7 @@ CREATE TABLE tx (
     user_id INT NOT NULL,
     account_id INT NOT NULL,
     tx_date DATETIME NOT NULL,
-    tx_value FLOAT NOT NULL,
+    tx_value DECIMAL(19,4) NOT NULL,
     tx_type VARCHAR(255) NOT NULL,
     original_wording VARCHAR(255) NOT NULL,
     pinned BOOL NOT NULL DEFAULT FALSE,
@@ -0,0 +1,285 @@
+package money
+
+// Exact monetary amounts.
+// float32 cannot represent most decimal amounts: sums of balances drift by cents on large portfolios.
+// An Amount is an integer number of ten-thousandths of a currency unit, stored as DECIMAL(19,4) in the DB
+// and encoded in JSON as a number written with its exact decimal digits
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Number of decimals kept. 4 decimals are enough for unit prices of funds, and to round sums at the cent
+const Decimals = 4
+
+const scale = 10000
+
+type Amount int64
+
+var ErrSyntax = errors.New("invalid amount")
+
+// Convert a float, rounded half away from zero to the 4th decimal.
+// Only use it for values which are floats by nature: quantities multiplied by a price, rates...
+func FromFloat(f float64) Amount {
+	return Amount(math.Round(f * scale))
+}
+
+// Convert a number of cents
+func FromCents(cents int64) Amount {
+	return Amount(cents * (scale / 100))
+}
+
+// Parse a decimal amount like "-1234.56". Extra decimals are rounded half away from zero
+func Parse(s string) (Amount, error) {
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrSyntax
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	// Exponents are sent by some JSON encoders for very small or big values
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, ErrSyntax
+		}
+		if negative {
+			f = -f
+		}
+		return FromFloat(f), nil
+	}
+
+	integerPart, fractionalPart, _ := strings.Cut(s, ".")
+	if integerPart == "" && fractionalPart == "" {
+		return 0, ErrSyntax
+	}
+
+	var units int64
+	if integerPart != "" {
+		var err error
+		units, err = strconv.ParseInt(integerPart, 10, 64)
+		if err != nil || units < 0 {
+			return 0, ErrSyntax
+		}
+	}
+
+	var fraction int64
+	roundUp := false
+	for i, digit := range fractionalPart {
+		if digit < '0' || digit > '9' {
+			return 0, ErrSyntax
+		}
+		if i < Decimals {
+			fraction = fraction*10 + int64(digit-'0')
+		} else if i == Decimals {
+			roundUp = digit >= '5'
+		}
+	}
+	for i := len(fractionalPart); i < Decimals; i++ {
+		fraction *= 10
+	}
+
+	if units > math.MaxInt64/scale-1 {
+		return 0, ErrSyntax
+	}
+
+	value := units*scale + fraction
+	if roundUp {
+		value++
+	}
+	if negative {
+		value = -value
+	}
+	return Amount(value), nil
+}
+
+// Parse an amount which is known to be valid, like a constant. Panics otherwise
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(fmt.Sprintf("money: cannot parse %q", s))
+	}
+	return a
+}
+
+func (a Amount) Add(b Amount) Amount {
+	return a + b
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	return a - b
+}
+
+func (a Amount) Neg() Amount {
+	return -a
+}
+
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// Multiply by a quantity or a rate, rounded to the 4th decimal
+func (a Amount) Mul(f float64) Amount {
+	return FromFloat(float64(a) * f / scale)
+}
+
+// Ratio between two amounts. Returns 0 if b is zero
+func (a Amount) Div(b Amount) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+func (a Amount) IsZero() bool {
+	return a == 0
+}
+
+func (a Amount) Sign() int {
+	switch {
+	case a > 0:
+		return 1
+	case a < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// Approximate value, for graphs and statistics only
+func (a Amount) Float64() float64 {
+	return float64(a) / scale
+}
+
+// Round to the given number of decimals (0 to 4), half away from zero
+func (a Amount) Round(decimals int) Amount {
+
+	if decimals >= Decimals {
+		return a
+	}
+
+	step := int64(1)
+	for range Decimals - max(decimals, 0) {
+		step *= 10
+	}
+
+	value := int64(a)
+	remainder := value % step
+	value -= remainder
+	if remainder*2 >= step {
+		value += step
+	} else if remainder*2 <= -step {
+		value -= step
+	}
+	return Amount(value)
+}
+
+// Write the amount with exactly the given number of decimals (0 to 4), rounded half away from zero.
+// Ex: 1234.5 with 2 decimals gives "1234.50"
+func (a Amount) StringFixed(decimals int) string {
+
+	decimals = min(max(decimals, 0), Decimals)
+	rounded := int64(a.Round(decimals))
+
+	sign := ""
+	if rounded < 0 {
+		sign = "-"
+		rounded = -rounded
+	}
+
+	units := rounded / scale
+	fraction := fmt.Sprintf("%04d", rounded%scale)[:decimals]
+
+	if decimals == 0 {
+		return fmt.Sprintf("%s%d", sign, units)
+	}
+	return fmt.Sprintf("%s%d.%s", sign, units, fraction)
+}
+
+// Write the amount with at least 2 decimals, and up to 4 if needed. Ex: "12.50", "3.1415"
+func (a Amount) String() string {
+	s := a.StringFixed(Decimals)
+	for strings.HasSuffix(s, "0") && len(s)-strings.Index(s, ".") > 3 {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// Encode the amount as a JSON number, without going through a float
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// Decode a JSON number or a JSON string
+func (a *Amount) UnmarshalJSON(data []byte) error {
+
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return fmt.Errorf("money: cannot unmarshal %s: %w", data, err)
+	}
+	*a = parsed
+	return nil
+}
+
+// Read a DECIMAL column. The MySQL driver gives its text representation
+func (a *Amount) Scan(src any) error {
+
+	switch value := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case []byte:
+		parsed, err := Parse(string(value))
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	case string:
+		parsed, err := Parse(value)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	case int64:
+		*a = Amount(value * scale)
+		return nil
+	case float64:
+		*a = FromFloat(value)
+		return nil
+	case float32:
+		*a = FromFloat(float64(value))
+		return nil
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+}
+
+// Write the amount as text, so the DECIMAL column gets every digit
+func (a Amount) Value() (driver.Value, error) {
+	return a.StringFixed(Decimals), nil
+}
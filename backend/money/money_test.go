@@ -0,0 +1,125 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+
+	tests := []struct {
+		input string
+		want  Amount
+	}{
+		{input: "0", want: 0},
+		{input: "1234.56", want: 12345600},
+		{input: "-0.1", want: -1000},
+		{input: "+7", want: 70000},
+		{input: ".5", want: 5000},
+		{input: "0.00005", want: 1},    // rounded half away from zero
+		{input: "-0.00005", want: -1},  // rounded half away from zero
+		{input: "0.00004999", want: 0}, // extra decimals dropped
+		{input: "1.5e3", want: 15000000},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(test.input)
+		if err != nil || got != test.want {
+			t.Errorf("Wrong parse of %s: got %v, %v want %v", test.input, int64(got), err, int64(test.want))
+		}
+	}
+
+	for _, input := range []string{"", "-", ".", "1.2.3", "12a", "1,5", "--1"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestSumIsExact(t *testing.T) {
+
+	// 0.1 cannot be represented by a float: summed a million times it drifts
+	var sum Amount
+	for range 1000000 {
+		sum = sum.Add(MustParse("0.1"))
+	}
+	if sum != MustParse("100000") {
+		t.Errorf("Wrong sum: got %v want 100000.00", sum)
+	}
+}
+
+func TestStringFixed(t *testing.T) {
+
+	tests := []struct {
+		amount   Amount
+		decimals int
+		want     string
+	}{
+		{amount: MustParse("1234.5"), decimals: 2, want: "1234.50"},
+		{amount: MustParse("-0.005"), decimals: 2, want: "-0.01"},
+		{amount: MustParse("-0.004"), decimals: 2, want: "0.00"},
+		{amount: MustParse("2.5"), decimals: 0, want: "3"},
+		{amount: MustParse("3.1415"), decimals: 4, want: "3.1415"},
+	}
+
+	for _, test := range tests {
+		if got := test.amount.StringFixed(test.decimals); got != test.want {
+			t.Errorf("Wrong string: got %v want %v", got, test.want)
+		}
+	}
+
+	if got := MustParse("12.5").String(); got != "12.50" {
+		t.Errorf("Wrong string: got %v want 12.50", got)
+	}
+	if got := MustParse("3.1415").String(); got != "3.1415" {
+		t.Errorf("Wrong string: got %v want 3.1415", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+
+	var value struct {
+		Number Amount `json:"number"`
+		Text   Amount `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(`{"number": 9007199254740.99, "text": "-42.1"}`), &value); err != nil {
+		t.Fatal(err)
+	}
+	if value.Number != MustParse("9007199254740.99") || value.Text != MustParse("-42.1") {
+		t.Errorf("Wrong decoded amounts: %+v", value)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != `{"number":9007199254740.99,"text":-42.10}` {
+		t.Errorf("Wrong encoded amounts: %s", encoded)
+	}
+}
+
+func TestScan(t *testing.T) {
+
+	var a Amount
+	if err := a.Scan([]byte("1234.5600")); err != nil || a != MustParse("1234.56") {
+		t.Errorf("Wrong scanned DECIMAL: got %v, %v", a, err)
+	}
+	if err := a.Scan(int64(3)); err != nil || a != MustParse("3") {
+		t.Errorf("Wrong scanned integer: got %v, %v", a, err)
+	}
+
+	value, err := MustParse("-0.5").Value()
+	if err != nil || value != "-0.5000" {
+		t.Errorf("Wrong DB value: got %v, %v", value, err)
+	}
+}
+
+func TestMul(t *testing.T) {
+
+	if got := MustParse("19.99").Mul(3); got != MustParse("59.97") {
+		t.Errorf("Wrong product: got %v want 59.97", got)
+	}
+	if got := MustParse("100").Div(MustParse("400")); got != 0.25 {
+		t.Errorf("Wrong ratio: got %v want 0.25", got)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
 	"freenahiFront/internal/settings"
 
 	"fyne.io/fyne/v2"
@@ -49,22 +50,23 @@ const (
 var columnSort = [numberOfColumns]int{}
 
 type BankAccount struct {
-	Id                 int     `json:"id"`
-	Number             string  `json:"number"`
-	Bank_Original_name string  `json:"bank_original_name"`
-	Original_name      string  `json:"original_name"`
-	Balance            float32 `json:"balance"`
-	Last_update        string  `json:"last_update"`
-	Iban               string  `json:"iban"`
-	Currency           string  `json:"currency"`
-	Account_type       string  `json:"type"`
-	Usage              string  `json:"usage"`
+	Id                 int          `json:"id"`
+	Number             string       `json:"number"`
+	Bank_Original_name string       `json:"bank_original_name"`
+	Original_name      string       `json:"original_name"`
+	Balance            money.Amount `json:"balance"`
+	Last_update        string       `json:"last_update"`
+	Iban               string       `json:"iban"`
+	Currency           string       `json:"currency"`
+	Account_type       string       `json:"type"`
+	Usage              string       `json:"usage"`
+	Balance_base       money.Amount `json:"balance_base"` // balance converted to the base currency by the backend
 }
 
 // Create the transaction screen
-func NewAccountScreen(app fyne.App) fyne.CanvasObject {
+func NewAccountScreen(app fyne.App, win fyne.Window) fyne.CanvasObject {
 
-	accountTable := createAccountTable(app)
+	accountTable, reloadAccounts := createAccountTable(app)
 
 	manageButton := widget.NewButton(lang.L("Manage account with Powens"), func() {
 
@@ -95,8 +97,20 @@ func NewAccountScreen(app fyne.App) fyne.CanvasObject {
 		}
 	})
 
+	// Accounts which are not handled by Powens (cash, foreign banks, etc...)
+	manualAccountButton := widget.NewButtonWithIcon(lang.L("Add manual account"), theme.ContentAddIcon(), func() {
+		showManualAccountDialog(app, win, reloadAccounts)
+	})
+
+	manualTransactionButton := widget.NewButtonWithIcon(lang.L("Add manual transaction"), theme.ContentAddIcon(), func() {
+		showManualTransactionDialog(app, win, reloadAccounts)
+	})
+
 	screen := container.NewBorder(
-		container.NewVBox(manageButton, widget.NewSeparator()),
+		container.NewVBox(
+			container.NewGridWithColumns(3, manageButton, manualAccountButton, manualTransactionButton),
+			widget.NewSeparator(),
+		),
 		nil,
 		nil,
 		nil,
@@ -106,8 +120,8 @@ func NewAccountScreen(app fyne.App) fyne.CanvasObject {
 	return screen
 }
 
-// Create the table of transaction
-func createAccountTable(app fyne.App) *fyne.Container {
+// Create the table of accounts. The returned function reloads the table data
+func createAccountTable(app fyne.App) (*fyne.Container, func()) {
 
 	// These values are used later to set column width sizes, which are the max between the header and an actual value
 	testIconSize := widget.NewIcon(theme.RadioButtonCheckedIcon()).MinSize().Width
@@ -234,7 +248,7 @@ func createAccountTable(app fyne.App) *fyne.Container {
 				accountNameItem.Content.(*widget.Label).SetText(bankAccounts[id.Row].Original_name)
 
 			case valueColumn:
-				value := fmt.Sprintf("%.2f", bankAccounts[id.Row].Balance)
+				value := bankAccounts[id.Row].Balance.StringFixed(2)
 
 				if bankAccounts[id.Row].Balance < 0 {
 					valueItem.Importance = widget.WarningImportance
@@ -361,18 +375,19 @@ func createAccountTable(app fyne.App) *fyne.Container {
 	accountTable.SetColumnWidth(accountNumberColumn, float32(math.Max(float64(testNumberLabelSize), float64(numberHeaderSize))))
 
 	// Reload button reloads data by querying the backend
-	reloadButton := widget.NewButton("", func() {
+	reload := func() {
 		bankAccounts = GetBankAccounts(app, "")
 		accountTable.Refresh()
 
 		// Reset header sorting if any
 		columnSort[0] = numberOfSorts
 		applySort(0, accountTable, bankAccounts)
-	})
+	}
+	reloadButton := widget.NewButton("", reload)
 
 	reloadButton.Icon = theme.ViewRefreshIcon()
 
-	return container.NewBorder(nil, container.NewBorder(nil, nil, nil, reloadButton, nil), nil, nil, accountTable)
+	return container.NewBorder(nil, container.NewBorder(nil, nil, nil, reloadButton, nil), nil, nil, accountTable), reload
 
 }
 
@@ -0,0 +1,270 @@
+package account
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
+	"freenahiFront/internal/settings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/data/validation"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	amountRegex   = `^-?[0-9]+([.,][0-9]{1,2})?$`
+	dateRegex     = `^[0-9]{4}-[0-9]{2}-[0-9]{2}$`
+	currencyRegex = `^[A-Z]{3}$`
+)
+
+// https://docs.powens.com/api-reference/products/data-aggregation/bank-account-types#accounttypename-values
+var manualAccountTypes = []string{
+	"checking", "savings", "deposit", "card", "market", "pea", "lifeinsurance", "real_estate", "unknown",
+}
+
+// Account which is not handled by Powens, created by the user
+type ManualAccount struct {
+	Id              int          `json:"id"`
+	Bank_name       string       `json:"bank_original_name"`
+	Name            string       `json:"original_name"`
+	Account_type    string       `json:"type"`
+	Currency        string       `json:"currency"`
+	Initial_balance money.Amount `json:"initial_balance"`
+	Creation_date   string       `json:"creation_date"`
+	Balance         money.Amount `json:"balance"`
+}
+
+type ManualTransaction struct {
+	Date             string       `json:"date"`
+	Value            money.Amount `json:"value"`
+	Original_wording string       `json:"original_wording"`
+}
+
+// Display a form to create a manual account
+func showManualAccountDialog(app fyne.App, win fyne.Window, onCreated func()) {
+
+	nameItem := widget.NewEntry()
+	nameItem.Validator = validation.NewRegexp(`^.{1,255}$`, lang.L("Required"))
+
+	bankNameItem := widget.NewEntry()
+	bankNameItem.SetPlaceHolder(lang.L("Manual"))
+
+	// Display translated types but send the Powens type to the backend
+	var typeLabels []string
+	for _, accountType := range manualAccountTypes {
+		typeLabels = append(typeLabels, lang.L(accountType))
+	}
+	typeItem := widget.NewSelect(typeLabels, nil)
+	typeItem.SetSelectedIndex(0)
+
+	currencyItem := widget.NewEntry()
+	currencyItem.SetText("EUR")
+	currencyItem.Validator = validation.NewRegexp(currencyRegex, lang.L("Regex currency"))
+
+	balanceItem := widget.NewEntry()
+	balanceItem.SetText("0")
+	balanceItem.Validator = validation.NewRegexp(amountRegex, lang.L("Regex amount"))
+
+	dateItem := widget.NewEntry()
+	dateItem.SetText(time.Now().Format("2006-01-02"))
+	dateItem.Validator = validation.NewRegexp(dateRegex, lang.L("Regex date"))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Account name"), nameItem),
+		widget.NewFormItem(lang.L("Plateform name"), bankNameItem),
+		widget.NewFormItem(lang.L("Type"), typeItem),
+		widget.NewFormItem(lang.L("Currency"), currencyItem),
+		widget.NewFormItem(lang.L("Initial balance"), balanceItem),
+		widget.NewFormItem(lang.L("Date"), dateItem),
+	}
+
+	d := dialog.NewForm(lang.L("Add manual account"), lang.L("Create"), lang.L("Cancel"), items, func(b bool) {
+		if !b {
+			return
+		}
+
+		balance, _ := money.Parse(commaToDot(balanceItem.Text))
+
+		account := ManualAccount{
+			Name:            nameItem.Text,
+			Bank_name:       bankNameItem.Text,
+			Account_type:    manualAccountTypes[typeItem.SelectedIndex()],
+			Currency:        currencyItem.Text,
+			Initial_balance: balance,
+			Creation_date:   dateItem.Text,
+		}
+
+		if err := createManualAccount(app, account); err != nil {
+			helper.Logger.Error().Err(err).Msg("Cannot create manual account")
+			dialog.ShowError(err, win)
+			return
+		}
+		onCreated()
+	}, win)
+
+	d.Resize(fyne.NewSize(d.MinSize().Width*1.5, d.MinSize().Height))
+	d.Show()
+}
+
+// Display a form to add a tx to a manual account
+func showManualTransactionDialog(app fyne.App, win fyne.Window, onCreated func()) {
+
+	accounts, err := getManualAccounts(app)
+	if err != nil {
+		helper.Logger.Error().Err(err).Msg("Cannot get manual accounts")
+		dialog.ShowError(err, win)
+		return
+	}
+
+	if len(accounts) == 0 {
+		dialog.ShowInformation(lang.L("Add manual transaction"), lang.L("No manual account"), win)
+		return
+	}
+
+	var accountNames []string
+	for _, account := range accounts {
+		accountNames = append(accountNames, account.Bank_name+": "+account.Name)
+	}
+	accountItem := widget.NewSelect(accountNames, nil)
+	accountItem.SetSelectedIndex(0)
+
+	dateItem := widget.NewEntry()
+	dateItem.SetText(time.Now().Format("2006-01-02"))
+	dateItem.Validator = validation.NewRegexp(dateRegex, lang.L("Regex date"))
+
+	valueItem := widget.NewEntry()
+	valueItem.SetPlaceHolder("-12.50")
+	valueItem.Validator = validation.NewRegexp(amountRegex, lang.L("Regex amount"))
+
+	detailsItem := widget.NewEntry()
+	detailsItem.Validator = validation.NewRegexp(`^.{1,255}$`, lang.L("Required"))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Account name"), accountItem),
+		widget.NewFormItem(lang.L("Date"), dateItem),
+		widget.NewFormItem(lang.L("Value"), valueItem),
+		widget.NewFormItem(lang.L("Details"), detailsItem),
+	}
+
+	d := dialog.NewForm(lang.L("Add manual transaction"), lang.L("Create"), lang.L("Cancel"), items, func(b bool) {
+		if !b {
+			return
+		}
+
+		value, _ := money.Parse(commaToDot(valueItem.Text))
+
+		tx := ManualTransaction{
+			Date:             dateItem.Text,
+			Value:            value,
+			Original_wording: detailsItem.Text,
+		}
+
+		if err := createManualTransaction(app, accounts[accountItem.SelectedIndex()].Id, tx); err != nil {
+			helper.Logger.Error().Err(err).Msg("Cannot create manual transaction")
+			dialog.ShowError(err, win)
+			return
+		}
+		onCreated()
+	}, win)
+
+	d.Resize(fyne.NewSize(d.MinSize().Width*1.5, d.MinSize().Height))
+	d.Show()
+}
+
+// Accept both "12.5" and "12,5"
+func commaToDot(value string) string {
+	return strings.ReplaceAll(value, ",", ".")
+}
+
+// Call the backend endpoint GET "/manual_account/" and retrieve manual accounts
+func getManualAccounts(app fyne.App) ([]ManualAccount, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/manual_account/", backendProtocol, backendIp, backendPort)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []ManualAccount
+	if err := json.Unmarshal(body, &accounts); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// Call the backend endpoint POST "/manual_account/" to create a manual account
+func createManualAccount(app fyne.App, account ManualAccount) error {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/manual_account/", backendProtocol, backendIp, backendPort)
+
+	jsonBody, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(resp.Status + ": " + string(body))
+	}
+
+	return nil
+}
+
+// Call the backend endpoint POST "/manual_account/{id}/transaction/" to add a tx to a manual account
+func createManualTransaction(app fyne.App, accountId int, tx ManualTransaction) error {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/manual_account/%d/transaction/", backendProtocol, backendIp, backendPort, accountId)
+
+	jsonBody, err := json.Marshal(tx)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(resp.Status + ": " + string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/settings"
+)
+
+// Types of the events sent by the backend on /events/
+const (
+	TypeSyncCompleted      = "sync_completed"
+	TypeTransactionChanged = "transaction_changed"
+	TypeAccountChanged     = "account_changed"
+
+	// Not sent by the backend: the stream is back after a disconnection, events may have been missed
+	TypeReconnected = "reconnected"
+)
+
+// The backend sends a heartbeat every 30 seconds. Without anything for longer, the connection is considered lost
+const idleTimeout = 90 * time.Second
+
+// Event received from the backend. Data is the JSON body of the event
+type Event struct {
+	Id   string
+	Type string
+	Data string
+}
+
+var (
+	mu        sync.Mutex
+	listeners = make(map[string][]func(Event))
+)
+
+// Call f for each event of one of the given types. f is not called on the UI thread,
+// use fyne.Do to update widgets
+func On(f func(Event), types ...string) {
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, eventType := range types {
+		listeners[eventType] = append(listeners[eventType], f)
+	}
+}
+
+func dispatch(event Event) {
+
+	mu.Lock()
+	callbacks := listeners[event.Type]
+	mu.Unlock()
+
+	helper.Logger.Debug().Str("type", event.Type).Str("data", event.Data).Msg("Backend event")
+	for _, f := range callbacks {
+		f(event)
+	}
+}
+
+// Listen to the backend event stream for the whole life of the app. The connection is opened again
+// after the backend polling interval when it is lost
+func Listen(app fyne.App) {
+
+	connected := false
+
+	for {
+		backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+		backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+		backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+		url := backendProtocol + "://" + backendIp + ":" + backendPort + "/events/"
+
+		err := subscribe(url, func() {
+			// Events sent while disconnected are lost, everything has to be reloaded
+			if connected {
+				dispatch(Event{Type: TypeReconnected})
+			}
+			connected = true
+		}, dispatch)
+		helper.Logger.Debug().Err(err).Msg("Backend event stream closed")
+
+		pollingInterval := app.Preferences().IntWithFallback(settings.PreferenceBackendPollingInterval, settings.BackendPollingIntervalDefault)
+		time.Sleep(time.Duration(pollingInterval) * time.Second)
+	}
+}
+
+// Open the event stream and read it until the connection is lost. onConnect is called once the stream is open
+func subscribe(url string, onConnect func(), onEvent func(Event)) error {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	// No client timeout, the stream is open as long as the app runs
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(resp.Status + ": " + string(body))
+	}
+	onConnect()
+
+	// Each line received, heartbeats included, proves that the connection is alive
+	idle := time.AfterFunc(idleTimeout, cancel)
+	defer idle.Stop()
+
+	return read(resp.Body, func() { idle.Reset(idleTimeout) }, onEvent)
+}
+
+// Parse a Server-Sent Events stream. See https://html.spec.whatwg.org/multipage/server-sent-events.html
+func read(r io.Reader, onLine func(), onEvent func(Event)) error {
+
+	var event Event
+	var data []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		onLine()
+		line := scanner.Text()
+
+		// An empty line ends the event
+		if line == "" {
+			if len(data) > 0 {
+				if event.Type == "" {
+					event.Type = "message"
+				}
+				event.Data = strings.Join(data, "\n")
+				onEvent(event)
+			}
+			event, data = Event{}, nil
+			continue
+		}
+
+		// Comment, used as heartbeat
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			event.Id = value
+		case "event":
+			event.Type = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
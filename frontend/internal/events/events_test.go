@@ -0,0 +1,31 @@
+package events
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+
+	stream := "retry: 5000\n\n" +
+		": heartbeat\n\n" +
+		"id: 1\nevent: transaction_changed\ndata: {\"transaction_id\":42}\n\n" +
+		"data: first\ndata: second\n\n" +
+		"id: 3\nevent: sync_completed\ndata: {}\n"
+
+	var events []Event
+	lines := 0
+	read(strings.NewReader(stream), func() { lines++ }, func(event Event) { events = append(events, event) })
+
+	want := []Event{
+		{Id: "1", Type: TypeTransactionChanged, Data: "{\"transaction_id\":42}"},
+		{Type: "message", Data: "first\nsecond"},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("Got %+v, want %+v", events, want)
+	}
+	if lines != 14 {
+		t.Errorf("Got %d lines, want 14", lines)
+	}
+}
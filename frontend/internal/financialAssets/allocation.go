@@ -0,0 +1,105 @@
+package financialassets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
+	"freenahiFront/internal/settings"
+)
+
+// Part of the wealth in a bucket of a dimension (asset class, region or sector)
+type AllocationSlice struct {
+	Name  string       `json:"name"`
+	Value money.Amount `json:"value"`
+	Share float64      `json:"share"`
+}
+
+// Create a doughnut of the wealth breakdown, with a radio to choose the dimension.
+// The returned function draws the doughnut again with fresh data
+func newAllocationContainer(app fyne.App) (*fyne.Container, func()) {
+
+	doughnutSize := fyne.NewSize(300, 300)
+	doughnutContainer := container.NewStack()
+	dimension := "asset_class"
+
+	draw := func() {
+		doughnutContainer.RemoveAll()
+
+		breakdown, err := getAllocation(app, dimension)
+		if err != nil {
+			helper.Logger.Error().Err(err).Str("dimension", dimension).Msg("Cannot get allocation")
+			doughnutContainer.Add(widget.NewLabel(lang.L("Backend Error")))
+			return
+		}
+
+		var names []string
+		var values []float64
+		for _, slice := range breakdown {
+			names = append(names, fmt.Sprintf("%s %.0f%%", lang.L(slice.Name), slice.Share*100))
+			values = append(values, slice.Value.Float64())
+		}
+		doughnutContainer.Add(helper.DrawDoughnut(names, values, doughnutSize, "Allocation doughnut"))
+	}
+
+	radio := widget.NewRadioGroup([]string{lang.L("Asset class"), lang.L("Region"), lang.L("Sector")}, func(value string) {
+		switch value {
+		case lang.L("Region"):
+			dimension = "region"
+		case lang.L("Sector"):
+			dimension = "sector"
+		default:
+			dimension = "asset_class"
+		}
+		draw()
+	})
+	radio.Horizontal = true
+	radio.SetSelected(lang.L("Asset class"))
+
+	return container.NewBorder(container.NewCenter(radio), nil, nil, nil, doughnutContainer), draw
+}
+
+// Call the backend endpoint "/allocation/" and retrieve the wealth breakdown along a dimension
+func getAllocation(app fyne.App, dimension string) ([]AllocationSlice, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/allocation/?dimension=%s", backendProtocol, backendIp, backendPort, dimension)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status + ": " + string(body))
+	}
+
+	var breakdown []AllocationSlice
+	if err := json.Unmarshal(body, &breakdown); err != nil {
+		return nil, err
+	}
+
+	return breakdown, nil
+}
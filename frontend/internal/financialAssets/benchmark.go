@@ -0,0 +1,207 @@
+package financialassets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/settings"
+)
+
+type Benchmark struct {
+	Benchmark_id int    `json:"id"`
+	Name         string `json:"name"`
+	Code         string `json:"code"`
+	Stock_symbol string `json:"stock_symbol"`
+}
+
+// Portfolio and benchmark normalized to 100 at the start of the period
+type BenchmarkPoint struct {
+	Date      string  `json:"date"`
+	Portfolio float64 `json:"portfolio"`
+	Benchmark float64 `json:"benchmark"`
+	Relative  float64 `json:"relative"`
+}
+
+type BenchmarkComparison struct {
+	Benchmark        string           `json:"benchmark"`
+	Portfolio_return float64          `json:"portfolio_return"`
+	Benchmark_return float64          `json:"benchmark_return"`
+	Relative         float64          `json:"relative"`
+	Points           []BenchmarkPoint `json:"points"`
+}
+
+// Open a window comparing the portfolio with a registered benchmark
+func showBenchmarkWindow(app fyne.App) {
+
+	w := app.NewWindow(lang.L("Benchmark"))
+	w.CenterOnScreen()
+
+	benchmarks, err := getBenchmarks(app)
+	if err != nil {
+		helper.Logger.Error().Err(err).Msg("Cannot get benchmarks")
+		w.SetContent(widget.NewLabel(lang.L("Backend Error")))
+		w.Show()
+		return
+	}
+	if len(benchmarks) == 0 {
+		w.SetContent(widget.NewLabel(lang.L("No benchmark registered")))
+		w.Show()
+		return
+	}
+
+	var names []string
+	for _, benchmark := range benchmarks {
+		names = append(names, benchmark.Name)
+	}
+
+	graphSize := fyne.NewSize(600, 250)
+	comparisonContainer := container.NewVBox()
+
+	selectedBenchmark := benchmarks[0]
+	period := "all"
+
+	drawComparison := func() {
+		comparisonContainer.RemoveAll()
+
+		comparison, err := getBenchmarkComparison(app, selectedBenchmark.Benchmark_id, period)
+		if err != nil {
+			helper.Logger.Error().Err(err).Int("benchmark_id", selectedBenchmark.Benchmark_id).Msg("Cannot get benchmark comparison")
+			comparisonContainer.Add(widget.NewLabel(lang.L("Backend Error")))
+			return
+		}
+		if comparison == nil {
+			comparisonContainer.Add(widget.NewLabel(lang.L("Not enough history")))
+			return
+		}
+
+		var xLabel []string
+		var portfolio, benchmark []float64
+		for _, point := range comparison.Points {
+			xLabel = append(xLabel, point.Date)
+			portfolio = append(portfolio, point.Portfolio)
+			benchmark = append(benchmark, point.Benchmark)
+		}
+
+		summary := widget.NewLabel(fmt.Sprintf("%s: %.2f %%   %s: %.2f %%   %s: %+.2f %%",
+			lang.L("Portfolio"), comparison.Portfolio_return*100,
+			comparison.Benchmark, comparison.Benchmark_return*100,
+			lang.L("Relative performance"), comparison.Relative*100,
+		))
+		summary.Alignment = fyne.TextAlignCenter
+
+		comparisonContainer.Add(summary)
+		comparisonContainer.Add(helper.DrawLines(
+			[]string{lang.L("Portfolio"), comparison.Benchmark},
+			xLabel,
+			[][]float64{portfolio, benchmark},
+			graphSize,
+			"Benchmark comparison graph",
+		))
+	}
+
+	selectBenchmark := widget.NewSelect(names, func(name string) {
+		for _, benchmark := range benchmarks {
+			if benchmark.Name == name {
+				selectedBenchmark = benchmark
+			}
+		}
+		drawComparison()
+	})
+
+	radio := widget.NewRadioGroup([]string{lang.L("Month"), lang.L("Year"), lang.L("All")}, func(value string) {
+		switch value {
+		case lang.L("Month"):
+			period = "month"
+		case lang.L("Year"):
+			period = "year"
+		default:
+			period = "all"
+		}
+		drawComparison()
+	})
+	radio.Horizontal = true
+
+	// Set the period first, selecting the benchmark draws the graph
+	radio.Selected = lang.L("All")
+	selectBenchmark.SetSelected(selectedBenchmark.Name)
+
+	w.SetContent(container.NewBorder(container.NewHBox(selectBenchmark, radio), nil, nil, nil, comparisonContainer))
+	w.Show()
+}
+
+// Call the backend endpoint "/benchmark/" and retrieve the registered benchmarks
+func getBenchmarks(app fyne.App) ([]Benchmark, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/benchmark/", backendProtocol, backendIp, backendPort)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status + ": " + string(body))
+	}
+
+	var benchmarks []Benchmark
+	if err := json.Unmarshal(body, &benchmarks); err != nil {
+		return nil, err
+	}
+
+	return benchmarks, nil
+}
+
+// Call the backend endpoint "/benchmark/{id}/comparison/" for the whole portfolio
+func getBenchmarkComparison(app fyne.App, benchmarkId int, period string) (*BenchmarkComparison, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/benchmark/%d/comparison/?period=%s", backendProtocol, backendIp, backendPort, benchmarkId, period)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status + ": " + string(body))
+	}
+
+	var comparison BenchmarkComparison
+	if err := json.Unmarshal(body, &comparison); err != nil {
+		return nil, err
+	}
+
+	return &comparison, nil
+}
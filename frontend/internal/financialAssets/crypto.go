@@ -0,0 +1,447 @@
+package financialassets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/validation"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
+	"freenahiFront/internal/settings"
+)
+
+const quantityRegex = `^[0-9]+([.,][0-9]{1,8})?$`
+
+const ( // Crypto positions table
+	cryptoSymbolColumn int = iota
+	cryptoWalletColumn
+	cryptoQuantityColumn
+	cryptoUnitCostColumn
+	cryptoCurrentPriceColumn
+	cryptoValueColumn
+	cryptoProfitColumn
+	cryptoNumberOfColumns
+)
+
+type CryptoWallet struct {
+	Id       int          `json:"id"`
+	Platform string       `json:"bank_original_name"`
+	Name     string       `json:"original_name"`
+	Balance  money.Amount `json:"balance"`
+}
+
+type CryptoOperation struct {
+	Symbol         string       `json:"symbol"`
+	Operation_type string       `json:"type"`
+	Quantity       float64      `json:"quantity"`
+	Unit_price     money.Amount `json:"unitprice"`
+	Fees           money.Amount `json:"fees"`
+	Date           string       `json:"date"`
+}
+
+type CryptoPosition struct {
+	Account_id   int          `json:"id_account"`
+	Platform     string       `json:"bank_original_name"`
+	Name         string       `json:"original_name"`
+	Symbol       string       `json:"symbol"`
+	Quantity     float64      `json:"quantity"`
+	Unit_price   money.Amount `json:"unitprice"`
+	Unit_value   money.Amount `json:"unitvalue"`
+	Valuation    money.Amount `json:"valuation"`
+	Diff         money.Amount `json:"diff"`
+	Diff_percent float64      `json:"diff_percent"`
+}
+
+// Create the crypto tab: allocation by asset, history of the wallets and the table of positions
+func NewCryptoScreen(app fyne.App, win fyne.Window) *fyne.Container {
+
+	positions, err := getCryptoPositions(app)
+	if err != nil {
+		helper.Logger.Error().Err(err).Msg("Cannot get crypto positions")
+	}
+
+	positionsTable := newCustomTable(
+		func() (int, int) {
+			return len(positions), cryptoNumberOfColumns
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("Template")
+			label.Alignment = fyne.TextAlignCenter
+			return label
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+
+			label := o.(*widget.Label)
+			position := positions[id.Row]
+
+			switch id.Col {
+			case cryptoSymbolColumn:
+				label.SetText(position.Symbol)
+			case cryptoWalletColumn:
+				label.SetText(position.Platform + ": " + position.Name)
+			case cryptoQuantityColumn:
+				label.SetText(strconv.FormatFloat(position.Quantity, 'f', -1, 64))
+			case cryptoUnitCostColumn:
+				label.SetText(helper.ValueSpacer(position.Unit_price.StringFixed(2)))
+			case cryptoCurrentPriceColumn:
+				label.SetText(helper.ValueSpacer(position.Unit_value.StringFixed(2)))
+			case cryptoValueColumn:
+				label.SetText(helper.ValueSpacer(position.Valuation.StringFixed(2)))
+			case cryptoProfitColumn:
+				label.SetText(fmt.Sprintf("%s (%.2f %%)", helper.ValueSpacer(position.Diff.StringFixed(2)), position.Diff_percent*100))
+			}
+		},
+	)
+
+	positionsTable.ShowHeaderRow = true
+	positionsTable.CreateHeader = func() fyne.CanvasObject {
+		label := widget.NewLabel("000")
+		label.Alignment = fyne.TextAlignCenter
+		label.TextStyle.Bold = true
+		return label
+	}
+	positionsTable.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+
+		label := o.(*widget.Label)
+
+		switch id.Col {
+		case cryptoSymbolColumn:
+			label.SetText(lang.L("Name"))
+		case cryptoWalletColumn:
+			label.SetText(lang.L("Wallet"))
+		case cryptoQuantityColumn:
+			label.SetText(lang.L("Quantity"))
+		case cryptoUnitCostColumn:
+			label.SetText(lang.L("Unit cost"))
+		case cryptoCurrentPriceColumn:
+			label.SetText(lang.L("Current price"))
+		case cryptoValueColumn:
+			label.SetText(lang.L("Value"))
+		case cryptoProfitColumn:
+			label.SetText(lang.L("Profit"))
+		default:
+			helper.Logger.Fatal().Msg("Too much column in the crypto grid for header")
+		}
+	}
+
+	graphSize := fyne.NewSize(600, 150)
+	doughnutSize := fyne.NewSize(150, 150)
+
+	totalItem := widget.NewLabel("")
+	totalItem.Alignment = fyne.TextAlignCenter
+	totalItem.SizeName = theme.SizeNameHeadingText
+
+	// Contains the allocation doughnut, the history graph and the total. Rebuilt when reloading
+	topContainer := container.NewHBox()
+
+	fillTop := func() {
+		var total money.Amount
+
+		// Allocation by asset, whatever the wallet
+		var symbols []string
+		var valuations []float64
+		for _, position := range positions {
+			total += position.Valuation
+
+			index := -1
+			for i, symbol := range symbols {
+				if symbol == position.Symbol {
+					index = i
+				}
+			}
+			if index == -1 {
+				symbols = append(symbols, position.Symbol)
+				valuations = append(valuations, 0)
+				index = len(symbols) - 1
+			}
+			valuations[index] += position.Valuation.Float64()
+		}
+
+		totalItem.SetText(fmt.Sprintf("%s: %s %s", lang.L("Total"), helper.ValueSpacer(total.StringFixed(2)), helper.BaseCurrencySymbol()))
+
+		graphContainer := container.NewVBox()
+		xLabel, yLabel := convertToGraphData(GetHistoryValues(app, 0, "all", "crypto"))
+		graphItem := helper.DrawLine(xLabel, yLabel, graphSize, "Line graph")
+		topGraphRadio := generateGraphRadio(app, 0, "crypto", graphItem, graphSize, graphContainer)
+		graphContainer.Add(container.NewCenter(topGraphRadio))
+		graphContainer.Add(graphItem)
+
+		topContainer.RemoveAll()
+		if len(symbols) > 0 {
+			topContainer.Add(helper.DrawDoughnut(symbols, valuations, doughnutSize, "Crypto doughnut"))
+		}
+		topContainer.Add(graphContainer)
+		topContainer.Add(widget.NewSeparator())
+		topContainer.Add(container.NewVBox(layout.NewSpacer(), totalItem, layout.NewSpacer()))
+	}
+	fillTop()
+
+	// Reload button reloads data by querying the backend
+	reload := func() {
+		positions, err = getCryptoPositions(app)
+		if err != nil {
+			helper.Logger.Error().Err(err).Msg("Cannot get crypto positions")
+			dialog.ShowError(err, win)
+		}
+		fillTop()
+		positionsTable.Refresh()
+	}
+
+	reloadButton := widget.NewButton("", reload)
+	reloadButton.Icon = theme.ViewRefreshIcon()
+
+	addWalletButton := widget.NewButtonWithIcon(lang.L("Add wallet"), theme.ContentAddIcon(), func() {
+		showWalletDialog(app, win, reload)
+	})
+	addOperationButton := widget.NewButtonWithIcon(lang.L("Add operation"), theme.ContentAddIcon(), func() {
+		showCryptoOperationDialog(app, win, reload)
+	})
+
+	return container.NewBorder(
+		container.NewCenter(topContainer),
+		container.NewBorder(nil, nil, container.NewHBox(addWalletButton, addOperationButton), reloadButton),
+		nil,
+		nil,
+		positionsTable,
+	)
+}
+
+// Display a form to create a crypto wallet
+func showWalletDialog(app fyne.App, win fyne.Window, onCreated func()) {
+
+	nameItem := widget.NewEntry()
+	nameItem.Validator = validation.NewRegexp(`^.{1,255}$`, lang.L("Required"))
+
+	platformItem := widget.NewEntry()
+	platformItem.SetPlaceHolder("Ledger, Kraken...")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Name"), nameItem),
+		widget.NewFormItem(lang.L("Plateform name"), platformItem),
+	}
+
+	d := dialog.NewForm(lang.L("Add wallet"), lang.L("Create"), lang.L("Cancel"), items, func(b bool) {
+		if !b {
+			return
+		}
+
+		wallet := CryptoWallet{Name: nameItem.Text, Platform: platformItem.Text}
+
+		if err := createWallet(app, wallet); err != nil {
+			helper.Logger.Error().Err(err).Msg("Cannot create wallet")
+			dialog.ShowError(err, win)
+			return
+		}
+		onCreated()
+	}, win)
+
+	d.Resize(fyne.NewSize(d.MinSize().Width*1.5, d.MinSize().Height))
+	d.Show()
+}
+
+// Display a form to add a buy or a sell to a wallet
+func showCryptoOperationDialog(app fyne.App, win fyne.Window, onCreated func()) {
+
+	wallets, err := getWallets(app)
+	if err != nil {
+		helper.Logger.Error().Err(err).Msg("Cannot get wallets")
+		dialog.ShowError(err, win)
+		return
+	}
+
+	if len(wallets) == 0 {
+		dialog.ShowInformation(lang.L("Add operation"), lang.L("No wallet"), win)
+		return
+	}
+
+	var walletNames []string
+	for _, wallet := range wallets {
+		walletNames = append(walletNames, wallet.Platform+": "+wallet.Name)
+	}
+	walletItem := widget.NewSelect(walletNames, nil)
+	walletItem.SetSelectedIndex(0)
+
+	operationTypes := []string{"buy", "sell"}
+	typeItem := widget.NewSelect([]string{lang.L("buy"), lang.L("sell")}, nil)
+	typeItem.SetSelectedIndex(0)
+
+	symbolItem := widget.NewEntry()
+	symbolItem.SetPlaceHolder("BTC")
+	symbolItem.Validator = validation.NewRegexp(`^[A-Za-z0-9]{1,20}$`, lang.L("Required"))
+
+	quantityItem := widget.NewEntry()
+	quantityItem.Validator = validation.NewRegexp(quantityRegex, lang.L("Regex amount"))
+
+	priceItem := widget.NewEntry()
+	priceItem.Validator = validation.NewRegexp(amountRegex, lang.L("Regex amount"))
+
+	feesItem := widget.NewEntry()
+	feesItem.SetText("0")
+	feesItem.Validator = validation.NewRegexp(amountRegex, lang.L("Regex amount"))
+
+	dateItem := widget.NewEntry()
+	dateItem.SetText(time.Now().Format("2006-01-02"))
+	dateItem.Validator = validation.NewRegexp(dateRegex, lang.L("Regex date"))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Wallet"), walletItem),
+		widget.NewFormItem(lang.L("Type"), typeItem),
+		widget.NewFormItem(lang.L("Name"), symbolItem),
+		widget.NewFormItem(lang.L("Quantity"), quantityItem),
+		widget.NewFormItem(lang.L("Unit cost"), priceItem),
+		widget.NewFormItem(lang.L("Fees"), feesItem),
+		widget.NewFormItem(lang.L("Date"), dateItem),
+	}
+
+	d := dialog.NewForm(lang.L("Add operation"), lang.L("Create"), lang.L("Cancel"), items, func(b bool) {
+		if !b {
+			return
+		}
+
+		quantity, _ := strconv.ParseFloat(strings.ReplaceAll(quantityItem.Text, ",", "."), 64)
+
+		operation := CryptoOperation{
+			Symbol:         strings.ToUpper(symbolItem.Text),
+			Operation_type: operationTypes[typeItem.SelectedIndex()],
+			Quantity:       quantity,
+			Unit_price:     parseAmount(priceItem.Text),
+			Fees:           parseAmount(feesItem.Text),
+			Date:           dateItem.Text,
+		}
+
+		if err := createCryptoOperation(app, wallets[walletItem.SelectedIndex()].Id, operation); err != nil {
+			helper.Logger.Error().Err(err).Msg("Cannot create crypto operation")
+			dialog.ShowError(err, win)
+			return
+		}
+		onCreated()
+	}, win)
+
+	d.Resize(fyne.NewSize(d.MinSize().Width*1.5, d.MinSize().Height))
+	d.Show()
+}
+
+// Call the backend endpoint GET "/crypto/position/" and retrieve the valued positions
+func getCryptoPositions(app fyne.App) ([]CryptoPosition, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/crypto/position/", backendProtocol, backendIp, backendPort)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []CryptoPosition
+	if err := json.Unmarshal(body, &positions); err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
+
+// Call the backend endpoint GET "/crypto/wallet/" and retrieve wallets
+func getWallets(app fyne.App) ([]CryptoWallet, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/crypto/wallet/", backendProtocol, backendIp, backendPort)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var wallets []CryptoWallet
+	if err := json.Unmarshal(body, &wallets); err != nil {
+		return nil, err
+	}
+
+	return wallets, nil
+}
+
+// Call the backend endpoint POST "/crypto/wallet/" to create a wallet
+func createWallet(app fyne.App, wallet CryptoWallet) error {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/crypto/wallet/", backendProtocol, backendIp, backendPort)
+
+	jsonBody, err := json.Marshal(wallet)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(resp.Status + ": " + string(body))
+	}
+
+	return nil
+}
+
+// Call the backend endpoint POST "/crypto/wallet/{id}/operation/" to add an operation to a wallet
+func createCryptoOperation(app fyne.App, walletId int, operation CryptoOperation) error {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/crypto/wallet/%d/operation/", backendProtocol, backendIp, backendPort, walletId)
+
+	jsonBody, err := json.Marshal(operation)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(resp.Status + ": " + string(body))
+	}
+
+	return nil
+}
@@ -6,8 +6,10 @@ import (
 	"io"
 	"math"
 	"net/http"
+	"net/url"
 	"slices"
 	"sort"
+	"strconv"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -20,36 +22,45 @@ import (
 
 	"freenahiFront/internal/account"
 	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
 	"freenahiFront/internal/settings"
 )
 
 // https://docs.powens.com/api-reference/products/wealth-aggregation/investments#investment-object
 type Investment struct {
-	Invest_id        int     `json:"id"`
-	Account_id       int     `json:"id_account"`
-	Label            string  `json:"label"`
-	Code             string  `json:"code"`
-	Code_type        string  `json:"code_type"`
-	Stock_symbol     string  `json:"stock_symbol"`
-	Quantity         float32 `json:"quantity"`
-	Unit_price       float32 `json:"unitprice"`
-	Unit_value       float32 `json:"unitvalue"`
-	Valuation        float32 `json:"valuation"`
-	Diff             float32 `json:"diff"`
-	Diff_percent     float32 `json:"diff_percent"`
-	Last_update      string  `json:"last_update"`
-	BankOriginalName string  `json:"bank_original_name"`
-	OriginalName     string  `json:"original_name"`
+	Invest_id        int          `json:"id"`
+	Account_id       int          `json:"id_account"`
+	Label            string       `json:"label"`
+	Code             string       `json:"code"`
+	Code_type        string       `json:"code_type"`
+	Stock_symbol     string       `json:"stock_symbol"`
+	Quantity         float32      `json:"quantity"`
+	Unit_price       money.Amount `json:"unitprice"`
+	Unit_value       money.Amount `json:"unitvalue"`
+	Valuation        money.Amount `json:"valuation"`
+	Diff             money.Amount `json:"diff"`
+	Diff_percent     float32      `json:"diff_percent"`
+	Last_update      string       `json:"last_update"`
+	BankOriginalName string       `json:"bank_original_name"`
+	OriginalName     string       `json:"original_name"`
+	Currency         string       `json:"currency"`
+	Valuation_base   money.Amount `json:"valuation_base"` // valuation in the base currency, used for totals across accounts
 }
 
-type HistoryValuePoint struct {
-	Valuation     float32
-	DateValuation time.Time
+// Valuations of a group of accounts or of an account
+type HistorySeries struct {
+	Name   string        `json:"name"`
+	Points []SeriesPoint `json:"points"`
 }
 
-type BankAccountSum struct {
-	Account_type string  `json:"type"`
-	Value        float32 `json:"value"`
+type SeriesPoint struct {
+	Date  string       `json:"date"`
+	Value money.Amount `json:"value"`
+}
+
+type HistoryValuePoint struct {
+	Valuation     money.Amount
+	DateValuation time.Time
 }
 
 const ( // for savings and bank account
@@ -60,9 +71,8 @@ const ( // for savings and bank account
 
 	unselectTime = 200 * time.Millisecond
 
-	// https://docs.powens.com/api-reference/products/data-aggregation/bank-account-types#accounttypename-values
-	// Get every stock and fund possible type
-	stocksType = "article83,capitalisation,crowdlending,lifeinsurance,madelin,market,pea,pee,per,perco,perp,rsp"
+	// History group of every stock and fund account type in the backend
+	stocksGroup = "stocks"
 )
 
 const ( // SF = stocks and funds
@@ -124,19 +134,13 @@ func (t *customTable) Resize(size fyne.Size) {
 // Create the Financial asset tab view
 func NewFinancialAssetsScreen(app fyne.App, win fyne.Window) *container.AppTabs {
 
-	wipItem := widget.NewLabel(lang.L("Work in progress"))
-	wipItem.SizeName = theme.SizeNameHeadingText
-	wipItem.Alignment = fyne.TextAlignCenter
-	wipItem.TextStyle.Bold = true
-	wipItem.TextStyle.Italic = true
-
 	tabs := container.NewAppTabs(
 		container.NewTabItem(lang.L("General"), NewGeneralScreen(app)),
 		container.NewTabItem(lang.L("Bank accounts"), NewCheckingOrSavingsScreen(app, "checking")),
 		container.NewTabItem(lang.L("Savings books"), NewCheckingOrSavingsScreen(app, "savings")),
 		container.NewTabItem(lang.L("Stocks and funds"), NewStocksAndFundsScreen(app)),
-		container.NewTabItem(lang.L("Real estate"), container.NewVBox(layout.NewSpacer(), wipItem, layout.NewSpacer())),
-		container.NewTabItem(lang.L("Crypto"), container.NewVBox(layout.NewSpacer(), wipItem, layout.NewSpacer())),
+		container.NewTabItem(lang.L("Real estate"), NewRealEstateScreen(app, win)),
+		container.NewTabItem(lang.L("Crypto"), NewCryptoScreen(app, win)),
 	)
 	tabs.SetTabLocation(container.TabLocationTop)
 
@@ -145,42 +149,67 @@ func NewFinancialAssetsScreen(app fyne.App, win fyne.Window) *container.AppTabs
 
 func NewGeneralScreen(app fyne.App) *fyne.Container {
 
-	stocksData := GetHistoryValues(app, 0, "all", stocksType)
-	checkingData := GetHistoryValues(app, 0, "all", "savings")
-	savingsData := GetHistoryValues(app, 0, "all", "checking")
+	netWorth, err := getNetWorth(app, "all")
+	if err != nil {
+		helper.Logger.Error().Err(err).Msg("Cannot get net worth")
+	}
+	groups := netWorth.groups()
 
-	labels, xLabel, yLabel := organise(stocksData, checkingData, savingsData)
+	labels, xLabel, yLabel := netWorthGraphData(netWorth)
 	graphSize := fyne.NewSize(800, 450)
 
-	graphItem := helper.DrawStackedLines(
+	graphItem := helper.DrawLines(
 		labels,
 		xLabel,
 		yLabel,
 		graphSize,
-		"Stacked general line graph",
+		"Net worth line graph",
 	)
 
 	// Create the graph container, containing the graph and a radio button which can update it
 	graphContainer := container.NewVBox()
 
-	topGraphRadio := generateMainGraphRadio(app, graphItem, graphSize, graphContainer)
+	topGraphRadio := widget.NewRadioGroup([]string{lang.L("Month"), lang.L("Year"), lang.L("All")}, func(value string) {})
+	topGraphRadio.Horizontal = true
+	topGraphRadio.Selected = lang.L("All")
+	topGraphRadio.OnChanged = func(value string) {
 
-	graphContainer.Add(container.NewCenter(topGraphRadio))
-	graphContainer.Add(graphItem)
+		period := "all"
+		switch value {
+		case "":
+			topGraphRadio.Selected = lang.L("All")
+		case lang.L("Month"):
+			period = "month"
+		case lang.L("Year"):
+			period = "year"
+		}
 
-	total := 0.0
+		periodNetWorth, err := getNetWorth(app, period)
+		if err != nil {
+			helper.Logger.Error().Err(err).Str("period", period).Msg("Cannot get net worth")
+		}
+		labels, xLabel, yLabel := netWorthGraphData(periodNetWorth)
 
-	// Total is the sum of today s value for every type
-	for _, invest := range yLabel {
-		total += invest[len(invest)-1]
+		// Remove the older graph, draw again, then replace
+		graphContainer.Remove(graphItem)
+		graphItem = helper.DrawLines(labels, xLabel, yLabel, graphSize, "Net worth line graph")
+		graphContainer.Add(graphItem)
 	}
 
-	// Create list for sums by type
-	sums := getBankAccountSums(app)
+	graphContainer.Add(container.NewCenter(topGraphRadio))
+	graphContainer.Add(graphItem)
+
+	// Repartition is given as a share of the assets
+	totalAssets := func() float64 {
+		if netWorth == nil {
+			return 0
+		}
+		return netWorth.Total_assets.Float64()
+	}
 
 	sumsTable := newCustomTable(
 		func() (int, int) {
-			return len(sums), numberOfColumns
+			return len(groups), numberOfColumns
 		},
 		func() fyne.CanvasObject {
 			scrollerLabel := widget.NewLabel("Template")
@@ -208,15 +237,19 @@ func NewGeneralScreen(app fyne.App) *fyne.Container {
 			switch id.Col {
 			case nameColumn:
 				accountNameItem.Show()
-				accountNameItem.Content.(*widget.Label).SetText(lang.L(sums[id.Row].Account_type))
+				accountNameItem.Content.(*widget.Label).SetText(lang.L(groups[id.Row].Name))
 
 			case valueColumn:
 				valueItem.Show()
-				valueItem.SetText(helper.ValueSpacer(fmt.Sprintf("%0.2f", sums[id.Row].Value)))
+				valueItem.SetText(helper.ValueSpacer(groups[id.Row].Value.StringFixed(2)))
 
 			case repartitionColumn:
 				repartitionItem.Show()
-				repartitionItem.SetText(fmt.Sprintf("%0.2f %%", float64(sums[id.Row].Value)/total*100))
+				if totalAssets() != 0 {
+					repartitionItem.SetText(fmt.Sprintf("%0.2f %%", groups[id.Row].Value.Float64()/totalAssets()*100))
+				} else {
+					repartitionItem.SetText("")
+				}
 			}
 		},
 	)
@@ -240,11 +273,19 @@ func NewGeneralScreen(app fyne.App) *fyne.Container {
 			b.SetText(lang.L("Repartition"))
 			helper.SetColumnHeaderIcon(columnSort[repartitionColumn], b, sortAsc, sortDesc)
 		default:
-			helper.Logger.Fatal().Msg("Too much column in the bank account sums grid for header")
+			helper.Logger.Fatal().Msg("Too much column in the net worth grid for header")
 		}
 	}
 
-	totalItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Total"), helper.ValueSpacer(fmt.Sprintf("%.2f", total))))
+	netWorthText := func() string {
+		var value money.Amount
+		if netWorth != nil {
+			value = netWorth.Net_worth
+		}
+		return fmt.Sprintf("%s: %s %s", lang.L("Net worth"), helper.ValueSpacer(value.StringFixed(2)), helper.BaseCurrencySymbol())
+	}
+
+	totalItem := widget.NewLabel(netWorthText())
 	totalItem.Alignment = fyne.TextAlignCenter
 	totalItem.SizeName = theme.SizeNameHeadingText
 
@@ -255,26 +296,22 @@ func NewGeneralScreen(app fyne.App) *fyne.Container {
 		container.NewVBox(layout.NewSpacer(), totalItem, layout.NewSpacer()),
 	)
 
+	// Breakdown of the wealth by asset class, region or sector
+	allocationContainer, drawAllocation := newAllocationContainer(app)
+
 	// Reload button reloads data by querying the backend
 	reloadButton := widget.NewButton("", func() {
 
-		stocksData = GetHistoryValues(app, 0, "all", stocksType)
-		checkingData = GetHistoryValues(app, 0, "all", "savings")
-		savingsData = GetHistoryValues(app, 0, "all", "checking")
-
-		sums = getBankAccountSums(app)
-
-		_, _, yLabel := organise(stocksData, checkingData, savingsData)
-
-		total = 0.0 // Recalculate
-
-		for _, invest := range yLabel {
-			total += invest[len(invest)-1]
+		netWorth, err = getNetWorth(app, "all")
+		if err != nil {
+			helper.Logger.Error().Err(err).Msg("Cannot get net worth")
 		}
+		groups = netWorth.groups()
 
-		totalItem.SetText(fmt.Sprintf("%s: %s", lang.L("Total"), helper.ValueSpacer(fmt.Sprintf("%.2f", total))))
+		totalItem.SetText(netWorthText())
 
 		sumsTable.Refresh()
+		drawAllocation()
 
 	})
 
@@ -284,7 +321,7 @@ func NewGeneralScreen(app fyne.App) *fyne.Container {
 		container.NewCenter(container.NewHBox(graphContainer, totalContainer)),
 		container.NewBorder(nil, nil, nil, reloadButton),
 		nil,
-		nil,
+		allocationContainer,
 		sumsTable,
 	)
 }
@@ -296,10 +333,10 @@ func NewCheckingOrSavingsScreen(app fyne.App, accountType string) *fyne.Containe
 	}
 
 	accounts := account.GetBankAccounts(app, accountType) // Fill accounts: backend call
-	total := 0.0                                          // Used later to calculate the repartition of each individual asset
+	var total money.Amount                                // Used later to calculate the repartition of each individual asset
 
 	for _, account := range accounts {
-		total += float64(account.Balance)
+		total += account.Balance_base
 	}
 
 	bankingAccountTable := newCustomTable(
@@ -336,11 +373,11 @@ func NewCheckingOrSavingsScreen(app fyne.App, accountType string) *fyne.Containe
 
 			case valueColumn:
 				valueItem.Show()
-				valueItem.SetText(helper.ValueSpacer(fmt.Sprintf("%0.2f", accounts[id.Row].Balance)))
+				valueItem.SetText(helper.ValueSpacer(accounts[id.Row].Balance.StringFixed(2)) + " " + helper.CurrencySymbol(accounts[id.Row].Currency))
 
 			case repartitionColumn:
 				repartitionItem.Show()
-				repartitionItem.SetText(fmt.Sprintf("%0.2f %%", float64(accounts[id.Row].Balance)/total*100))
+				repartitionItem.SetText(fmt.Sprintf("%0.2f %%", accounts[id.Row].Balance_base.Div(total)*100))
 			}
 		},
 	)
@@ -397,7 +434,7 @@ func NewCheckingOrSavingsScreen(app fyne.App, accountType string) *fyne.Containe
 		w.CenterOnScreen()
 		w.Resize(graphSize)
 
-		currentTotalLabel := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Current total"), helper.ValueSpacer(fmt.Sprintf("%.2f", accounts[id.Row].Balance))))
+		currentTotalLabel := widget.NewLabel(fmt.Sprintf("%s: %s %s", lang.L("Current total"), helper.ValueSpacer(accounts[id.Row].Balance.StringFixed(2)), helper.CurrencySymbol(accounts[id.Row].Currency)))
 		currentTotalLabel.Alignment = fyne.TextAlignCenter
 		currentTotalLabel.SizeName = theme.SizeNameHeadingText
 		currentTotalLabel.TextStyle.Bold = true
@@ -434,7 +471,7 @@ func NewCheckingOrSavingsScreen(app fyne.App, accountType string) *fyne.Containe
 	graphContainer.Add(graphItem)
 
 	// Create the total container, containing the sum of every savings / banking account balance
-	totalItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Total"), helper.ValueSpacer(fmt.Sprintf("%.2f", total))))
+	totalItem := widget.NewLabel(fmt.Sprintf("%s: %s %s", lang.L("Total"), helper.ValueSpacer(total.StringFixed(2)), helper.BaseCurrencySymbol()))
 	totalItem.Alignment = fyne.TextAlignCenter
 	totalItem.SizeName = theme.SizeNameHeadingText
 
@@ -449,12 +486,12 @@ func NewCheckingOrSavingsScreen(app fyne.App, accountType string) *fyne.Containe
 	reloadButton := widget.NewButton("", func() {
 		accounts = account.GetBankAccounts(app, accountType)
 
-		total = 0.0 // Recalculate
+		total = 0 // Recalculate
 
 		for _, account := range accounts {
-			total += float64(account.Balance)
+			total += account.Balance_base
 		}
-		totalItem.SetText(fmt.Sprintf("%s: %s", lang.L("Total"), helper.ValueSpacer(fmt.Sprintf("%.2f", total))))
+		totalItem.SetText(fmt.Sprintf("%s: %s %s", lang.L("Total"), helper.ValueSpacer(total.StringFixed(2)), helper.BaseCurrencySymbol()))
 		bankingAccountTable.Refresh()
 
 		// Reset header sorting if any
@@ -478,13 +515,13 @@ func NewStocksAndFundsScreen(app fyne.App) *fyne.Container {
 	// Fill invests: backend call
 	invests := GetInvests(app)
 
-	total := 0.0
+	var total money.Amount
 
 	for _, invest := range invests {
-		total += float64(invest.Valuation)
+		total += invest.Valuation_base
 	}
 
-	totalItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Total"), helper.ValueSpacer(fmt.Sprintf("%.2f", total))))
+	totalItem := widget.NewLabel(fmt.Sprintf("%s: %s %s", lang.L("Total"), helper.ValueSpacer(total.StringFixed(2)), helper.BaseCurrencySymbol()))
 	totalItem.Alignment = fyne.TextAlignCenter
 	totalItem.SizeName = theme.SizeNameHeadingText
 
@@ -497,7 +534,7 @@ func NewStocksAndFundsScreen(app fyne.App) *fyne.Container {
 
 	// https://docs.powens.com/api-reference/products/data-aggregation/bank-account-types#accounttypename-values
 	// Get every stock and fund possible type
-	accountType := "article83,capitalisation,crowdlending,lifeinsurance,madelin,market,pea,pee,per,perco,perp,rsp"
+	accountType := stocksGroup
 
 	xLabel, yLabel := convertToGraphData(GetHistoryValues(app, 0, "all", accountType))
 	graphSize := fyne.NewSize(600, 150)
@@ -551,12 +588,12 @@ func NewStocksAndFundsScreen(app fyne.App) *fyne.Container {
 			investAssetAccordion.RemoveIndex(0)
 		}
 
-		total = 0.0 // Recalculate
+		total = 0 // Recalculate
 
 		// Refill the map and recalculate total
 		for _, invest := range invests {
 
-			total += float64(invest.Valuation)
+			total += invest.Valuation_base
 			if !slices.Contains(bankAccounts, invest.OriginalName) {
 				bankAccounts = append(bankAccounts, invest.OriginalName)
 			}
@@ -570,14 +607,26 @@ func NewStocksAndFundsScreen(app fyne.App) *fyne.Container {
 			investAssetAccordion.Append(createAssetTable(invests, app))
 		}
 
-		totalItem.SetText(fmt.Sprintf("%s: %s", lang.L("Total"), helper.ValueSpacer(fmt.Sprintf("%.2f", total))))
+		totalItem.SetText(fmt.Sprintf("%s: %s %s", lang.L("Total"), helper.ValueSpacer(total.StringFixed(2)), helper.BaseCurrencySymbol()))
 	})
 
 	reloadButton.Icon = theme.ViewRefreshIcon()
 
+	performanceButton := widget.NewButton(lang.L("Performance"), func() {
+		showPerformanceWindow(app, invests)
+	})
+
+	benchmarkButton := widget.NewButton(lang.L("Benchmark"), func() {
+		showBenchmarkWindow(app)
+	})
+
+	rebalanceButton := widget.NewButton(lang.L("Rebalancing"), func() {
+		showRebalanceWindow(app)
+	})
+
 	return container.NewBorder(
 		container.NewCenter(container.NewHBox(graphContainer, totalContainer)),
-		container.NewBorder(nil, nil, nil, reloadButton),
+		container.NewBorder(nil, nil, nil, container.NewHBox(performanceButton, benchmarkButton, rebalanceButton, reloadButton)),
 		nil,
 		nil,
 		container.NewVScroll(investAssetAccordion),
@@ -587,10 +636,10 @@ func NewStocksAndFundsScreen(app fyne.App) *fyne.Container {
 // Create an asset table for the given invests
 func createAssetTable(invests []Investment, app fyne.App) *widget.AccordionItem {
 
-	total := 0.0
+	var total money.Amount
 
 	for _, invest := range invests {
-		total += float64(invest.Valuation)
+		total += invest.Valuation
 	}
 
 	// These values are used later to set column width sizes, which are the max between the header and an actual value
@@ -723,19 +772,19 @@ func createAssetTable(invests []Investment, app fyne.App) *widget.AccordionItem
 
 			case SFunitCostColumn:
 				unitCostItem.Show()
-				unitCostItem.SetText(helper.ValueSpacer(fmt.Sprintf("%.2f", invests[id.Row].Unit_price)))
+				unitCostItem.SetText(helper.ValueSpacer(invests[id.Row].Unit_price.StringFixed(2)))
 
 			case SFcurrentPriceColumn:
 				currentPriceItem.Show()
-				currentPriceItem.SetText(helper.ValueSpacer(fmt.Sprintf("%.2f", invests[id.Row].Unit_value)))
+				currentPriceItem.SetText(helper.ValueSpacer(invests[id.Row].Unit_value.StringFixed(2)))
 
 			case SFvalueColumn:
 				valueItem.Show()
-				valueItem.SetText(helper.ValueSpacer(fmt.Sprintf("%0.2f", invests[id.Row].Valuation)))
+				valueItem.SetText(helper.ValueSpacer(invests[id.Row].Valuation.StringFixed(2)))
 
 			case SFrepartitionColumn:
 				repartitionItem.Show()
-				repartitionItem.SetText(fmt.Sprintf("%0.2f %%", float64(invests[id.Row].Valuation)/total*100))
+				repartitionItem.SetText(fmt.Sprintf("%0.2f %%", invests[id.Row].Valuation.Div(total)*100))
 
 			case SFprofitColumn:
 				profitItem.Show()
@@ -752,7 +801,7 @@ func createAssetTable(invests []Investment, app fyne.App) *widget.AccordionItem
 					totalProfit.Importance = widget.MediumImportance
 					relativeProfit.Importance = widget.MediumImportance
 				}
-				totalProfit.SetText(fmt.Sprintf("%.2f", invests[id.Row].Diff))
+				totalProfit.SetText(invests[id.Row].Diff.StringFixed(2))
 				relativeProfit.SetText(fmt.Sprintf("%.2f %%", invests[id.Row].Diff_percent*100))
 			}
 		},
@@ -814,6 +863,7 @@ func createAssetTable(invests []Investment, app fyne.App) *widget.AccordionItem
 		b.Refresh()
 	}
 
+	// Display the history of the selected position
 	assetTable.OnSelected = func(id widget.TableCellID) {
 		go func() {
 			time.Sleep(unselectTime)
@@ -822,6 +872,7 @@ func createAssetTable(invests []Investment, app fyne.App) *widget.AccordionItem
 			})
 		}()
 
+		showInvestHistoryWindow(app, invests[id.Row])
 	}
 
 	graphContainer := container.NewVBox()
@@ -843,7 +894,7 @@ func createAssetTable(invests []Investment, app fyne.App) *widget.AccordionItem
 	graphContainer.Add(graphItem)
 
 	return widget.NewAccordionItem(
-		invests[0].BankOriginalName+": "+invests[0].OriginalName+" ("+helper.ValueSpacer(fmt.Sprintf("%.2f", total))+")",
+		invests[0].BankOriginalName+": "+invests[0].OriginalName+" ("+helper.ValueSpacer(total.StringFixed(2))+")",
 		container.NewBorder(graphContainer, nil, nil, nil, assetTable), // ToDo: have table in a container to display more than 1 row if possible
 	)
 }
@@ -856,170 +907,12 @@ func convertToGraphData(data []HistoryValuePoint) ([]string, []float64) {
 
 	for _, point := range data {
 		x = append(x, point.DateValuation.Format("2006-01-02"))
-		y = append(y, float64(point.Valuation))
+		y = append(y, point.Valuation.Float64())
 	}
 
 	return x, y
 }
 
-// This function makes history value data coherent (every type should start at the same date):
-// For example, we can have banking data starting the 01-01-2024 and checking data the 01-02-2024
-// We will add value from 01-01-2024 to 01-02-2024 for checking data so they both start at the 01-01-2024
-// We fill the gap with the value 0
-func organise(stocksData, checkingData, savingsData []HistoryValuePoint) ([]string, []string, [][]float64) {
-
-	// If no data, return nothing
-	if len(stocksData) == 0 && len(checkingData) == 0 && len(savingsData) == 0 {
-		return []string{}, []string{}, [][]float64{}
-	}
-
-	// Get the oldest date between every type
-	var minDate time.Time
-	if len(stocksData) != 0 {
-		minDate = stocksData[0].DateValuation
-	} else if len(checkingData) != 0 {
-		minDate = checkingData[0].DateValuation
-	} else if len(savingsData) != 0 {
-		minDate = savingsData[0].DateValuation
-	}
-
-	if len(savingsData) != 0 && savingsData[0].DateValuation.Before(minDate) {
-		minDate = savingsData[0].DateValuation
-	}
-
-	if len(checkingData) != 0 && checkingData[0].DateValuation.Before(minDate) {
-		minDate = checkingData[0].DateValuation
-	}
-
-	// Get the number of days since the oldest date for each type and add fill missing date with 0 value
-
-	var startDay HistoryValuePoint
-
-	if len(stocksData) != 0 {
-		startDay = stocksData[0]
-		daysDiff := minDate.Sub(stocksData[0].DateValuation).Hours() / 24
-
-		for i := -1; i >= int(daysDiff); i-- {
-			day := startDay.DateValuation.Add(24 * time.Hour * time.Duration(i))
-			stocksData = slices.Insert(stocksData, 0, HistoryValuePoint{Valuation: 0, DateValuation: day})
-		}
-	}
-
-	if len(checkingData) != 0 {
-		startDay = checkingData[0]
-		daysDiff := minDate.Sub(checkingData[0].DateValuation).Hours() / 24
-
-		for i := -1; i >= int(daysDiff); i-- {
-			day := startDay.DateValuation.Add(24 * time.Hour * time.Duration(i))
-			checkingData = slices.Insert(checkingData, 0, HistoryValuePoint{Valuation: 0, DateValuation: day})
-		}
-	}
-
-	if len(savingsData) != 0 {
-		startDay = savingsData[0]
-		daysDiff := minDate.Sub(savingsData[0].DateValuation).Hours() / 24
-
-		for i := -1; i >= int(daysDiff); i-- {
-			day := startDay.DateValuation.Add(24 * time.Hour * time.Duration(i))
-			savingsData = slices.Insert(savingsData, 0, HistoryValuePoint{Valuation: 0, DateValuation: day})
-		}
-	}
-
-	xStock, yStock := convertToGraphData(stocksData)
-	xCheckings, yCheckings := convertToGraphData(checkingData)
-	xSavings, ySavings := convertToGraphData(savingsData)
-
-	// If not empty, x<Type>Label are identicals (same list of date)
-	// We just take the first one which is not empty
-	x := []string{}
-	if len(xStock) != 0 {
-		x = xStock
-	} else if len(xCheckings) != 0 {
-		x = xCheckings
-	} else if len(xSavings) != 0 {
-		x = xSavings
-	}
-
-	labels := []string{} // Name of the series for the line graph
-	y := [][]float64{}   // Ordonate of the series
-
-	if len(stocksData) != 0 {
-		labels = append(labels, lang.L("Stocks and funds"))
-		y = append(y, yStock)
-	}
-
-	if len(checkingData) != 0 {
-		labels = append(labels, lang.L("Bank accounts"))
-		y = append(y, yCheckings)
-	}
-
-	if len(savingsData) != 0 {
-		labels = append(labels, lang.L("Savings books"))
-		y = append(y, ySavings)
-	}
-
-	return labels, x, y
-}
-
-// Create a radio button for the main / general graph which update it when selected
-func generateMainGraphRadio(
-	app fyne.App,
-	graphItem fyne.CanvasObject,
-	size fyne.Size,
-	graphContainer *fyne.Container,
-) *widget.RadioGroup {
-
-	radio := widget.NewRadioGroup([]string{lang.L("Month"), lang.L("Year"), lang.L("All")}, func(value string) {})
-
-	radio.Horizontal = true
-	radio.Selected = lang.L("All")
-	radio.OnChanged = func(value string) {
-
-		var stocksData, checkingData, savingsData []HistoryValuePoint
-		switch value {
-		case "":
-			radio.Selected = lang.L("All")
-			stocksData = GetHistoryValues(app, 0, "all", stocksType)
-			checkingData = GetHistoryValues(app, 0, "all", "savings")
-			savingsData = GetHistoryValues(app, 0, "all", "checking")
-
-		case lang.L("Month"):
-			stocksData = GetHistoryValues(app, 0, "month", stocksType)
-			checkingData = GetHistoryValues(app, 0, "month", "savings")
-			savingsData = GetHistoryValues(app, 0, "month", "checking")
-
-		case lang.L("Year"):
-			stocksData = GetHistoryValues(app, 0, "year", stocksType)
-			checkingData = GetHistoryValues(app, 0, "year", "savings")
-			savingsData = GetHistoryValues(app, 0, "year", "checking")
-
-		case lang.L("All"):
-			stocksData = GetHistoryValues(app, 0, "all", stocksType)
-			checkingData = GetHistoryValues(app, 0, "all", "savings")
-			savingsData = GetHistoryValues(app, 0, "all", "checking")
-
-		}
-
-		labels, xLabel, yLabel := organise(stocksData, checkingData, savingsData)
-
-		// Remove the older graph, draw again, then replace
-		graphContainer.Remove(graphItem)
-
-		graphItem = helper.DrawStackedLines(
-			labels,
-			xLabel,
-			yLabel,
-			size,
-			"Stacked general line graph",
-		)
-
-		graphContainer.Add(graphItem)
-
-	}
-
-	return radio
-}
-
 // Create a radio button for the graph which update it when selected
 func generateGraphRadio(
 	app fyne.App,
@@ -1095,7 +988,7 @@ func applySort(col int, t *widget.Table, data []account.BankAccount) {
 
 		// re-sort with no sort selected
 		if order == sortOff {
-			return a.Balance > b.Balance
+			return a.Balance_base > b.Balance_base
 		}
 
 		switch col {
@@ -1107,9 +1000,9 @@ func applySort(col int, t *widget.Table, data []account.BankAccount) {
 
 		case valueColumn, repartitionColumn:
 			if order == sortAsc {
-				return a.Balance > b.Balance
+				return a.Balance_base > b.Balance_base
 			}
-			return a.Balance < b.Balance
+			return a.Balance_base < b.Balance_base
 
 		default:
 			return false
@@ -1203,31 +1096,33 @@ func GetInvests(app fyne.App) []Investment {
 }
 
 // ToDo: modify the function to return an error and display it if sth went wrong in the backend
-// Call the backend endpoint "/history/" or "/history/{id}" and retrieve value/date pairs for the given account.
-// Data are used later to draw line graphs
-func GetHistoryValues(app fyne.App, account int, period, accountType string) []HistoryValuePoint {
+// Call the backend endpoint "/history/series/" and retrieve value/date pairs for a group of accounts (like "checking" or "stocks"),
+// or for the given account when the group is empty. Period is all, month or year
+func GetHistoryValues(app fyne.App, account int, period, group string) []HistoryValuePoint {
 
 	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
 	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
 	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
 
-	var url string
-
-	if accountType != "" {
-		url = fmt.Sprintf("%s://%s:%s/history/?period=%s&type=%s", backendProtocol, backendIp, backendPort, period, accountType)
+	query := url.Values{}
+	if group != "" {
+		query.Set("group", group)
 	} else {
-		url = fmt.Sprintf("%s://%s:%s/history/%d?period=%s", backendProtocol, backendIp, backendPort, account, period)
+		query.Set("account", strconv.Itoa(account))
+	}
+	switch period {
+	case "month":
+		query.Set("from", time.Now().AddDate(0, -1, 0).Format("2006-01-02"))
+	case "year":
+		query.Set("from", time.Now().AddDate(-1, 0, 0).Format("2006-01-02"))
 	}
 
-	resp, err := http.Get(url)
+	resp, err := http.Get(fmt.Sprintf("%s://%s:%s/history/series/?%s", backendProtocol, backendIp, backendPort, query.Encode()))
 	if err != nil {
 		helper.Logger.Error().Err(err).Msg("Cannot run http get request")
 		return nil
 	}
-
-	if resp.StatusCode == http.StatusNoContent {
-		return nil
-	}
+	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -1235,43 +1130,29 @@ func GetHistoryValues(app fyne.App, account int, period, accountType string) []H
 		return nil
 	}
 
-	var values []HistoryValuePoint
-	if err := json.Unmarshal(body, &values); err != nil {
-		helper.Logger.Error().Err(err).Msg("Cannot unmarshal HistoryValuePoint")
+	if resp.StatusCode != http.StatusOK {
+		helper.Logger.Error().Str("status", resp.Status).Str("body", string(body)).Msg("Cannot get history series")
 		return nil
-
 	}
 
-	return values
-}
-
-// ToDo: modify the function to return an error and display it if sth went wrong in the backend
-// Call the backend endpoint "/bank_account/sum/" and retrieve bank account sums
-func getBankAccountSums(app fyne.App) []BankAccountSum {
-
-	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
-	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
-	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
-
-	url := fmt.Sprintf("%s://%s:%s/bank_account/sum/", backendProtocol, backendIp, backendPort)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		helper.Logger.Error().Err(err).Msg("Cannot run http get request")
+	var series []HistorySeries
+	if err := json.Unmarshal(body, &series); err != nil {
+		helper.Logger.Error().Err(err).Msg("Cannot unmarshal history series")
 		return nil
 	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		helper.Logger.Error().Err(err).Msg("ReadAll error")
+	if len(series) == 0 {
 		return nil
 	}
 
-	var accounts []BankAccountSum
-	if err := json.Unmarshal(body, &accounts); err != nil {
-		helper.Logger.Error().Err(err).Msg("Cannot unmarshal bank account sums")
-		return nil
+	var values []HistoryValuePoint
+	for _, point := range series[0].Points {
+		date, err := time.Parse("2006-01-02", point.Date)
+		if err != nil {
+			helper.Logger.Error().Err(err).Msg("Cannot parse history date")
+			return nil
+		}
+		values = append(values, HistoryValuePoint{Valuation: point.Value, DateValuation: date})
 	}
 
-	return accounts
+	return values
 }
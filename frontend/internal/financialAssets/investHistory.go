@@ -0,0 +1,124 @@
+package financialassets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
+	"freenahiFront/internal/settings"
+)
+
+// State of a position at the end of a day
+type InvestHistoryPoint struct {
+	Invest_id  int          `json:"id_invest"`
+	Account_id int          `json:"id_account"`
+	Date       string       `json:"date"`
+	Quantity   float32      `json:"quantity"`
+	Unit_price money.Amount `json:"unitprice"`
+	Unit_value money.Amount `json:"unitvalue"`
+	Valuation  money.Amount `json:"valuation"`
+}
+
+// Open a window with the valuation and the price of a single position over time
+func showInvestHistoryWindow(app fyne.App, invest Investment) {
+
+	w := app.NewWindow(fmt.Sprintf("%s : %s", lang.L("Position history"), invest.Label))
+	w.CenterOnScreen()
+
+	graphSize := fyne.NewSize(600, 200)
+	graphContainer := container.NewVBox()
+
+	drawGraphs := func(period string) {
+		graphContainer.RemoveAll()
+
+		points, err := getInvestHistory(app, invest.Invest_id, period)
+		if err != nil {
+			helper.Logger.Error().Err(err).Int("invest_id", invest.Invest_id).Msg("Cannot get invest history")
+			graphContainer.Add(widget.NewLabel(lang.L("Backend Error")))
+			return
+		}
+
+		var xLabel []string
+		var valuations, unitValues []float64
+		for _, point := range points {
+			xLabel = append(xLabel, point.Date)
+			valuations = append(valuations, point.Valuation.Float64())
+			unitValues = append(unitValues, point.Unit_value.Float64())
+		}
+
+		valuationTitle := widget.NewLabel(lang.L("Value"))
+		valuationTitle.Alignment = fyne.TextAlignCenter
+		valuationTitle.SizeName = theme.SizeNameSubHeadingText
+
+		unitValueTitle := widget.NewLabel(lang.L("Current price"))
+		unitValueTitle.Alignment = fyne.TextAlignCenter
+		unitValueTitle.SizeName = theme.SizeNameSubHeadingText
+
+		graphContainer.Add(valuationTitle)
+		graphContainer.Add(helper.DrawLine(xLabel, valuations, graphSize, "Position valuation graph"))
+		graphContainer.Add(unitValueTitle)
+		graphContainer.Add(helper.DrawLine(xLabel, unitValues, graphSize, "Position price graph"))
+	}
+
+	radio := widget.NewRadioGroup([]string{lang.L("Month"), lang.L("Year"), lang.L("All")}, func(value string) {
+		switch value {
+		case lang.L("Month"):
+			drawGraphs("month")
+		case lang.L("Year"):
+			drawGraphs("year")
+		default:
+			drawGraphs("all")
+		}
+	})
+	radio.Horizontal = true
+	radio.SetSelected(lang.L("All"))
+
+	w.SetContent(container.NewBorder(container.NewCenter(radio), nil, nil, nil, graphContainer))
+	w.Show()
+}
+
+// Call the backend endpoint "/investment/{id}/history/" and retrieve the daily states of a position
+func getInvestHistory(app fyne.App, investId int, period string) ([]InvestHistoryPoint, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/investment/%d/history/?period=%s", backendProtocol, backendIp, backendPort, investId, period)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status + ": " + string(body))
+	}
+
+	var points []InvestHistoryPoint
+	if err := json.Unmarshal(body, &points); err != nil {
+		return nil, err
+	}
+
+	return points, nil
+}
@@ -0,0 +1,103 @@
+package financialassets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/lang"
+
+	"freenahiFront/internal/money"
+	"freenahiFront/internal/settings"
+)
+
+// Sum of a group of assets or liabilities, in the base currency. Liabilities are positive amounts owed
+type NetWorthGroup struct {
+	Name  string       `json:"name"`
+	Value money.Amount `json:"value"`
+}
+
+type NetWorthPoint struct {
+	Date        string       `json:"date"`
+	Assets      money.Amount `json:"assets"`
+	Liabilities money.Amount `json:"liabilities"`
+	Net_worth   money.Amount `json:"net_worth"`
+}
+
+type NetWorth struct {
+	Currency          string          `json:"currency"`
+	Assets            []NetWorthGroup `json:"assets"`
+	Liabilities       []NetWorthGroup `json:"liabilities"`
+	Total_assets      money.Amount    `json:"total_assets"`
+	Total_liabilities money.Amount    `json:"total_liabilities"`
+	Net_worth         money.Amount    `json:"net_worth"`
+	History           []NetWorthPoint `json:"history"`
+}
+
+// Groups displayed in the table: assets, then liabilities as negative values
+func (n *NetWorth) groups() []NetWorthGroup {
+
+	if n == nil {
+		return nil
+	}
+
+	groups := append([]NetWorthGroup{}, n.Assets...)
+	for _, liability := range n.Liabilities {
+		groups = append(groups, NetWorthGroup{Name: liability.Name, Value: liability.Value.Neg()})
+	}
+	return groups
+}
+
+// Series of the graph: assets, liabilities and net worth
+func netWorthGraphData(netWorth *NetWorth) ([]string, []string, [][]float64) {
+
+	if netWorth == nil || len(netWorth.History) == 0 {
+		return []string{}, []string{}, [][]float64{}
+	}
+
+	var dates []string
+	y := make([][]float64, 3)
+	for _, point := range netWorth.History {
+		dates = append(dates, point.Date)
+		y[0] = append(y[0], point.Assets.Float64())
+		y[1] = append(y[1], point.Liabilities.Float64())
+		y[2] = append(y[2], point.Net_worth.Float64())
+	}
+
+	return []string{lang.L("Assets"), lang.L("Liabilities"), lang.L("Net worth")}, dates, y
+}
+
+// Call the backend endpoint "/networth/" and retrieve the assets, liabilities and net worth, with their history over a period
+func getNetWorth(app fyne.App, period string) (*NetWorth, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/networth/?period=%s", backendProtocol, backendIp, backendPort, period)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status + ": " + string(body))
+	}
+
+	var netWorth NetWorth
+	if err := json.Unmarshal(body, &netWorth); err != nil {
+		return nil, err
+	}
+
+	return &netWorth, nil
+}
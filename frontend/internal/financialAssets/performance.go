@@ -0,0 +1,155 @@
+package financialassets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
+	"freenahiFront/internal/settings"
+)
+
+// Returns over a period, as ratios: 0.05 for 5%
+type PeriodReturn struct {
+	Period string  `json:"period"` // ytd, 1y, 3y, 5y or inception
+	Start  string  `json:"start"`
+	Twr    float64 `json:"twr"` // time-weighted return, cumulative over the period
+	Mwr    float64 `json:"mwr"` // money-weighted return (XIRR), annualized
+}
+
+type Performance struct {
+	Currency  string         `json:"currency"`
+	Valuation money.Amount   `json:"valuation"`
+	Periods   []PeriodReturn `json:"periods"`
+}
+
+// Groups of Powens account types which can be compared together
+var performanceGroups = []struct {
+	label string
+	types string
+}{
+	{label: "PEA", types: "pea"},
+	{label: "Securities account", types: "market"},
+	{label: "Life insurance", types: "lifeinsurance,capitalisation"},
+	{label: "Retirement savings", types: "article83,madelin,pee,per,perco,perp,rsp"},
+}
+
+// Open a window with the returns of the portfolio, of a group of accounts or of a single account
+func showPerformanceWindow(app fyne.App, invests []Investment) {
+
+	w := app.NewWindow(lang.L("Performance"))
+	w.CenterOnScreen()
+
+	// Build the choices: whole portfolio, groups, then accounts holding the invests
+	choices := []string{lang.L("Portfolio")}
+	queries := map[string]string{lang.L("Portfolio"): ""}
+
+	for _, group := range performanceGroups {
+		choices = append(choices, lang.L(group.label))
+		queries[lang.L(group.label)] = "type=" + url.QueryEscape(group.types)
+	}
+	for _, invest := range invests {
+		name := fmt.Sprintf("%s - %s", invest.BankOriginalName, invest.OriginalName)
+		if !slices.Contains(choices, name) {
+			choices = append(choices, name)
+			queries[name] = fmt.Sprintf("account=%d", invest.Account_id)
+		}
+	}
+
+	performanceContainer := container.NewVBox()
+
+	selectScope := widget.NewSelect(choices, func(choice string) {
+		performanceContainer.RemoveAll()
+
+		performance, err := getPerformance(app, queries[choice])
+		if err != nil {
+			helper.Logger.Error().Err(err).Str("scope", choice).Msg("Cannot get performance")
+			performanceContainer.Add(widget.NewLabel(lang.L("Backend Error")))
+			return
+		}
+		if performance == nil || len(performance.Periods) == 0 {
+			performanceContainer.Add(widget.NewLabel(lang.L("Not enough history")))
+			return
+		}
+
+		grid := container.NewGridWithColumns(3,
+			widget.NewLabelWithStyle(lang.L("Period"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(lang.L("Time-weighted return"), fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(lang.L("Money-weighted return (annualized)"), fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}),
+		)
+		for _, period := range performance.Periods {
+			grid.Add(widget.NewLabel(periodLabel(period)))
+			grid.Add(widget.NewLabelWithStyle(fmt.Sprintf("%.2f %%", period.Twr*100), fyne.TextAlignTrailing, fyne.TextStyle{}))
+			grid.Add(widget.NewLabelWithStyle(fmt.Sprintf("%.2f %%", period.Mwr*100), fyne.TextAlignTrailing, fyne.TextStyle{}))
+		}
+
+		performanceContainer.Add(widget.NewLabel(fmt.Sprintf("%s: %s %s", lang.L("Value"), helper.ValueSpacer(performance.Valuation.StringFixed(2)), helper.BaseCurrencySymbol())))
+		performanceContainer.Add(grid)
+	})
+	selectScope.SetSelected(lang.L("Portfolio"))
+
+	w.SetContent(container.NewBorder(selectScope, nil, nil, nil, performanceContainer))
+	w.Resize(fyne.NewSize(600, 300))
+	w.Show()
+}
+
+func periodLabel(period PeriodReturn) string {
+	switch period.Period {
+	case "ytd":
+		return lang.L("Year to date")
+	case "1y":
+		return lang.L("1 year")
+	case "3y":
+		return lang.L("3 years")
+	case "5y":
+		return lang.L("5 years")
+	default:
+		return fmt.Sprintf("%s (%s)", lang.L("Since inception"), period.Start)
+	}
+}
+
+// Call the backend endpoint "/investment/performance/" with a scope query ("account=id", "type=pea,market" or empty for the portfolio)
+func getPerformance(app fyne.App, scope string) (*Performance, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/investment/performance/?%s", backendProtocol, backendIp, backendPort, scope)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status + ": " + string(body))
+	}
+
+	var performance Performance
+	if err := json.Unmarshal(body, &performance); err != nil {
+		return nil, err
+	}
+
+	return &performance, nil
+}
@@ -0,0 +1,433 @@
+package financialassets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/validation"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"freenahiFront/internal/account"
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
+	"freenahiFront/internal/settings"
+)
+
+const (
+	amountRegex     = `^[0-9]+([.,][0-9]{1,2})?$`
+	percentageRegex = `^[0-9]{1,3}([.,][0-9]{1,2})?$`
+	dateRegex       = `^[0-9]{4}-[0-9]{2}-[0-9]{2}$`
+)
+
+// A property owned (fully or partly) by the user
+type Property struct {
+	Property_id         int          `json:"id"`
+	Name                string       `json:"name"`
+	Purchase_price      money.Amount `json:"purchase_price"`
+	Purchase_date       string       `json:"purchase_date"`
+	Fees                money.Amount `json:"fees"`
+	Ownership_share     float32      `json:"ownership_share"`
+	Monthly_rent        money.Amount `json:"monthly_rent"`
+	Loan_account_id     *int         `json:"loan_account_id"`
+	Value               money.Amount `json:"value"`
+	Outstanding_capital money.Amount `json:"outstanding_capital"`
+	Equity              money.Amount `json:"equity"`
+}
+
+type PropertyValue struct {
+	Valuation      money.Amount `json:"valuation"`
+	Date_valuation string       `json:"date_valuation"`
+}
+
+// Create the real estate tab: one item per property with its details and its value graph
+func NewRealEstateScreen(app fyne.App, win fyne.Window) *fyne.Container {
+
+	propertiesContainer := container.NewVBox()
+
+	var reload func()
+	reload = func() {
+		propertiesContainer.RemoveAll()
+
+		properties, err := getProperties(app)
+		if err != nil {
+			helper.Logger.Error().Err(err).Msg("Cannot get properties")
+			propertiesContainer.Add(widget.NewLabel(lang.L("Backend Error")))
+			return
+		}
+
+		if len(properties) == 0 {
+			noPropertyItem := widget.NewLabel(lang.L("No property"))
+			noPropertyItem.Alignment = fyne.TextAlignCenter
+			propertiesContainer.Add(noPropertyItem)
+			return
+		}
+
+		var total money.Amount
+		accordion := widget.NewAccordion()
+		for _, property := range properties {
+			total += property.Equity
+			accordion.Append(createPropertyItem(app, win, property, reload))
+		}
+
+		totalItem := widget.NewLabel(fmt.Sprintf("%s: %s %s", lang.L("Equity"), helper.ValueSpacer(total.StringFixed(2)), helper.BaseCurrencySymbol()))
+		totalItem.Alignment = fyne.TextAlignCenter
+		totalItem.SizeName = theme.SizeNameHeadingText
+
+		propertiesContainer.Add(totalItem)
+		propertiesContainer.Add(accordion)
+	}
+	reload()
+
+	addButton := widget.NewButtonWithIcon(lang.L("Add property"), theme.ContentAddIcon(), func() {
+		showPropertyDialog(app, win, reload)
+	})
+
+	// Reload button reloads data by querying the backend
+	reloadButton := widget.NewButton("", reload)
+	reloadButton.Icon = theme.ViewRefreshIcon()
+
+	return container.NewBorder(
+		nil,
+		container.NewBorder(nil, nil, addButton, reloadButton),
+		nil,
+		nil,
+		container.NewVScroll(propertiesContainer),
+	)
+}
+
+// Create the accordion item of a property, containing its details and the graph of its estimated values
+func createPropertyItem(app fyne.App, win fyne.Window, property Property, reload func()) *widget.AccordionItem {
+
+	valueLabel := func(value money.Amount) *widget.Label {
+		return widget.NewLabel(helper.ValueSpacer(value.StringFixed(2)))
+	}
+
+	detailsItem := container.NewGridWithColumns(2,
+		widget.NewLabel(lang.L("Purchase price")), valueLabel(property.Purchase_price),
+		widget.NewLabel(lang.L("Purchase date")), widget.NewLabel(property.Purchase_date),
+		widget.NewLabel(lang.L("Fees")), valueLabel(property.Fees),
+		widget.NewLabel(lang.L("Ownership share")), widget.NewLabel(fmt.Sprintf("%.2f %%", property.Ownership_share)),
+		widget.NewLabel(lang.L("Monthly rent")), valueLabel(property.Monthly_rent),
+		widget.NewLabel(lang.L("Estimated value")), valueLabel(property.Value),
+		widget.NewLabel(lang.L("Outstanding capital")), valueLabel(property.Outstanding_capital),
+		widget.NewLabel(lang.L("Equity")), valueLabel(property.Equity),
+	)
+
+	values, err := getPropertyValues(app, property.Property_id)
+	if err != nil {
+		helper.Logger.Error().Err(err).Int("property_id", property.Property_id).Msg("Cannot get property values")
+	}
+
+	var xLabel []string
+	var yLabel []float64
+	for _, value := range values {
+		xLabel = append(xLabel, value.Date_valuation)
+		yLabel = append(yLabel, value.Valuation.Float64())
+	}
+
+	graphItem := helper.DrawLine(xLabel, yLabel, fyne.NewSize(600, 250), "Line graph")
+
+	addValueButton := widget.NewButtonWithIcon(lang.L("Add estimated value"), theme.ContentAddIcon(), func() {
+		showPropertyValueDialog(app, win, property.Property_id, reload)
+	})
+
+	deleteButton := widget.NewButtonWithIcon(lang.L("Delete"), theme.DeleteIcon(), func() {
+		cnf := dialog.NewConfirm(lang.L("Delete"), lang.L("Delete property confirmation"), func(b bool) {
+			if !b {
+				return
+			}
+			if err := deleteProperty(app, property.Property_id); err != nil {
+				helper.Logger.Error().Err(err).Msg("Cannot delete property")
+				dialog.ShowError(err, win)
+				return
+			}
+			reload()
+		}, win)
+		cnf.SetDismissText(lang.L("Cancel"))
+		cnf.SetConfirmText(lang.L("Delete"))
+		cnf.Show()
+	})
+
+	content := container.NewBorder(
+		nil,
+		container.NewHBox(addValueButton, deleteButton),
+		detailsItem,
+		nil,
+		graphItem,
+	)
+
+	title := fmt.Sprintf("%s: %s", property.Name, helper.ValueSpacer(property.Equity.StringFixed(2)))
+	return widget.NewAccordionItem(title, content)
+}
+
+// Display a form to create a property
+func showPropertyDialog(app fyne.App, win fyne.Window, onCreated func()) {
+
+	nameItem := widget.NewEntry()
+	nameItem.Validator = validation.NewRegexp(`^.{1,255}$`, lang.L("Required"))
+
+	priceItem := widget.NewEntry()
+	priceItem.Validator = validation.NewRegexp(amountRegex, lang.L("Regex amount"))
+
+	dateItem := widget.NewEntry()
+	dateItem.SetText(time.Now().Format("2006-01-02"))
+	dateItem.Validator = validation.NewRegexp(dateRegex, lang.L("Regex date"))
+
+	feesItem := widget.NewEntry()
+	feesItem.SetText("0")
+	feesItem.Validator = validation.NewRegexp(amountRegex, lang.L("Regex amount"))
+
+	shareItem := widget.NewEntry()
+	shareItem.SetText("100")
+	shareItem.Validator = validation.NewRegexp(percentageRegex, lang.L("Regex amount"))
+
+	rentItem := widget.NewEntry()
+	rentItem.SetText("0")
+	rentItem.Validator = validation.NewRegexp(amountRegex, lang.L("Regex amount"))
+
+	// The first choice is "no loan", then every loan account
+	loans := account.GetBankAccounts(app, "loan")
+	loanLabels := []string{lang.L("None")}
+	for _, loan := range loans {
+		loanLabels = append(loanLabels, loan.Bank_Original_name+": "+loan.Original_name)
+	}
+	loanItem := widget.NewSelect(loanLabels, nil)
+	loanItem.SetSelectedIndex(0)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Name"), nameItem),
+		widget.NewFormItem(lang.L("Purchase price"), priceItem),
+		widget.NewFormItem(lang.L("Purchase date"), dateItem),
+		widget.NewFormItem(lang.L("Fees"), feesItem),
+		widget.NewFormItem(lang.L("Ownership share"), shareItem),
+		widget.NewFormItem(lang.L("Monthly rent"), rentItem),
+		widget.NewFormItem(lang.L("Linked loan"), loanItem),
+	}
+
+	d := dialog.NewForm(lang.L("Add property"), lang.L("Create"), lang.L("Cancel"), items, func(b bool) {
+		if !b {
+			return
+		}
+
+		property := Property{
+			Name:            nameItem.Text,
+			Purchase_price:  parseAmount(priceItem.Text),
+			Purchase_date:   dateItem.Text,
+			Fees:            parseAmount(feesItem.Text),
+			Ownership_share: parsePercentage(shareItem.Text),
+			Monthly_rent:    parseAmount(rentItem.Text),
+		}
+		if index := loanItem.SelectedIndex(); index > 0 {
+			property.Loan_account_id = &loans[index-1].Id
+		}
+
+		if err := createProperty(app, property); err != nil {
+			helper.Logger.Error().Err(err).Msg("Cannot create property")
+			dialog.ShowError(err, win)
+			return
+		}
+		onCreated()
+	}, win)
+
+	d.Resize(fyne.NewSize(d.MinSize().Width*1.5, d.MinSize().Height))
+	d.Show()
+}
+
+// Display a form to add an estimated value to a property
+func showPropertyValueDialog(app fyne.App, win fyne.Window, propertyId int, onCreated func()) {
+
+	valueItem := widget.NewEntry()
+	valueItem.Validator = validation.NewRegexp(amountRegex, lang.L("Regex amount"))
+
+	dateItem := widget.NewEntry()
+	dateItem.SetText(time.Now().Format("2006-01-02"))
+	dateItem.Validator = validation.NewRegexp(dateRegex, lang.L("Regex date"))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Estimated value"), valueItem),
+		widget.NewFormItem(lang.L("Date"), dateItem),
+	}
+
+	d := dialog.NewForm(lang.L("Add estimated value"), lang.L("Create"), lang.L("Cancel"), items, func(b bool) {
+		if !b {
+			return
+		}
+
+		value := PropertyValue{
+			Valuation:      parseAmount(valueItem.Text),
+			Date_valuation: dateItem.Text,
+		}
+
+		if err := createPropertyValue(app, propertyId, value); err != nil {
+			helper.Logger.Error().Err(err).Msg("Cannot create property value")
+			dialog.ShowError(err, win)
+			return
+		}
+		onCreated()
+	}, win)
+
+	d.Resize(fyne.NewSize(d.MinSize().Width*1.5, d.MinSize().Height))
+	d.Show()
+}
+
+// Parse an amount already checked by a validator. Accept both "12.5" and "12,5"
+func parseAmount(value string) money.Amount {
+	amount, _ := money.Parse(strings.ReplaceAll(value, ",", "."))
+	return amount
+}
+
+// Parse a percentage already checked by a validator. Accept both "12.5" and "12,5"
+func parsePercentage(value string) float32 {
+	percentage, _ := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 32)
+	return float32(percentage)
+}
+
+// Call the backend endpoint GET "/real_estate/" and retrieve properties
+func getProperties(app fyne.App) ([]Property, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/real_estate/", backendProtocol, backendIp, backendPort)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var properties []Property
+	if err := json.Unmarshal(body, &properties); err != nil {
+		return nil, err
+	}
+
+	return properties, nil
+}
+
+// Call the backend endpoint GET "/real_estate/{id}/value/" and retrieve the estimated values of a property
+func getPropertyValues(app fyne.App, propertyId int) ([]PropertyValue, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/real_estate/%d/value/", backendProtocol, backendIp, backendPort, propertyId)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []PropertyValue
+	if err := json.Unmarshal(body, &values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Call the backend endpoint POST "/real_estate/" to create a property
+func createProperty(app fyne.App, property Property) error {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/real_estate/", backendProtocol, backendIp, backendPort)
+
+	jsonBody, err := json.Marshal(property)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(resp.Status + ": " + string(body))
+	}
+
+	return nil
+}
+
+// Call the backend endpoint POST "/real_estate/{id}/value/" to add an estimated value to a property
+func createPropertyValue(app fyne.App, propertyId int, value PropertyValue) error {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/real_estate/%d/value/", backendProtocol, backendIp, backendPort, propertyId)
+
+	jsonBody, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(resp.Status + ": " + string(body))
+	}
+
+	return nil
+}
+
+// Call the backend endpoint DELETE "/real_estate/{id}" to delete a property and its values
+func deleteProperty(app fyne.App, propertyId int) error {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/real_estate/%d", backendProtocol, backendIp, backendPort, propertyId)
+
+	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(resp.Status + ": " + string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package financialassets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
+	"freenahiFront/internal/settings"
+)
+
+// Drift of a bucket and the order bringing it back to its target. Weights are ratios
+type RebalanceLine struct {
+	Key    string       `json:"key"`
+	Name   string       `json:"name"`
+	Value  money.Amount `json:"value"`
+	Weight float64      `json:"weight"`
+	Target float64      `json:"target"`
+	Drift  float64      `json:"drift"`
+	Order  money.Amount `json:"order"` // amount to buy if positive, to sell if negative
+}
+
+type Rebalance struct {
+	Scope    string          `json:"scope"`
+	Cash     money.Amount    `json:"cash"`
+	Buy_only bool            `json:"buy_only"`
+	Total    money.Amount    `json:"total"`
+	Lines    []RebalanceLine `json:"lines"`
+}
+
+// Open a window with the drift of the invests from their targets and the orders to rebalance them
+func showRebalanceWindow(app fyne.App) {
+
+	w := app.NewWindow(lang.L("Rebalancing"))
+	w.CenterOnScreen()
+
+	scopes := map[string]string{
+		lang.L("Position"):     "position",
+		lang.L("Asset class"):  "asset_class",
+		lang.L("Account type"): "account_type",
+	}
+	selectScope := widget.NewSelect([]string{lang.L("Position"), lang.L("Asset class"), lang.L("Account type")}, nil)
+	selectScope.SetSelected(lang.L("Asset class"))
+
+	cashEntry := widget.NewEntry()
+	cashEntry.SetPlaceHolder(lang.L("New cash"))
+
+	buyOnlyCheck := widget.NewCheck(lang.L("Buy only"), nil)
+
+	linesContainer := container.NewVBox()
+
+	computeButton := widget.NewButton(lang.L("Compute"), func() {
+		linesContainer.RemoveAll()
+
+		rebalance, err := getRebalance(app, scopes[selectScope.Selected], cashEntry.Text, buyOnlyCheck.Checked)
+		if err != nil {
+			helper.Logger.Error().Err(err).Msg("Cannot get rebalance")
+			linesContainer.Add(widget.NewLabel(lang.L("Backend Error")))
+			return
+		}
+		if rebalance == nil {
+			linesContainer.Add(widget.NewLabel(lang.L("No target defined")))
+			return
+		}
+
+		grid := container.NewGridWithColumns(6)
+		for _, header := range []string{"Name", "Value", "Repartition", "Target", "Drift", "Order"} {
+			grid.Add(widget.NewLabelWithStyle(lang.L(header), fyne.TextAlignCenter, fyne.TextStyle{Bold: true}))
+		}
+		for _, line := range rebalance.Lines {
+			grid.Add(widget.NewLabel(lang.L(line.Name)))
+			grid.Add(widget.NewLabelWithStyle(helper.ValueSpacer(line.Value.StringFixed(2)), fyne.TextAlignTrailing, fyne.TextStyle{}))
+			grid.Add(widget.NewLabelWithStyle(fmt.Sprintf("%.2f %%", line.Weight*100), fyne.TextAlignTrailing, fyne.TextStyle{}))
+			grid.Add(widget.NewLabelWithStyle(fmt.Sprintf("%.2f %%", line.Target*100), fyne.TextAlignTrailing, fyne.TextStyle{}))
+			grid.Add(widget.NewLabelWithStyle(fmt.Sprintf("%+.2f %%", line.Drift*100), fyne.TextAlignTrailing, fyne.TextStyle{}))
+
+			order := lang.L("Hold")
+			switch line.Order.Sign() {
+			case 1:
+				order = fmt.Sprintf("%s %s", lang.L("buy"), helper.ValueSpacer(line.Order.StringFixed(2)))
+			case -1:
+				order = fmt.Sprintf("%s %s", lang.L("sell"), helper.ValueSpacer(line.Order.Abs().StringFixed(2)))
+			}
+			grid.Add(widget.NewLabelWithStyle(order, fyne.TextAlignTrailing, fyne.TextStyle{}))
+		}
+
+		linesContainer.Add(widget.NewLabel(fmt.Sprintf("%s: %s %s", lang.L("Total"), helper.ValueSpacer(rebalance.Total.StringFixed(2)), helper.BaseCurrencySymbol())))
+		linesContainer.Add(grid)
+	})
+
+	w.SetContent(container.NewBorder(
+		container.NewHBox(selectScope, cashEntry, buyOnlyCheck, computeButton),
+		nil,
+		nil,
+		nil,
+		container.NewVScroll(linesContainer),
+	))
+	w.Resize(fyne.NewSize(800, 400))
+	w.Show()
+}
+
+// Call the backend endpoint "/rebalance/" and retrieve the orders to reach the targets of a scope
+func getRebalance(app fyne.App, scope, cash string, buyOnly bool) (*Rebalance, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	query := url.Values{}
+	query.Set("scope", scope)
+	query.Set("buy_only", strconv.FormatBool(buyOnly))
+	if cash != "" {
+		query.Set("cash", cash)
+	}
+
+	resp, err := http.Get(fmt.Sprintf("%s://%s:%s/rebalance/?%s", backendProtocol, backendIp, backendPort, query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status + ": " + string(body))
+	}
+
+	var rebalance Rebalance
+	if err := json.Unmarshal(body, &rebalance); err != nil {
+		return nil, err
+	}
+
+	return &rebalance, nil
+}
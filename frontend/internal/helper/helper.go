@@ -158,6 +158,39 @@ func ValueSpacer(value string) string {
 	return reverse(modifiedValue)
 }
 
+// Currency in which the backend gives every aggregated value. Set at startup from the backend
+var baseCurrency = "EUR"
+
+func SetBaseCurrency(currency string) {
+	if currency != "" {
+		baseCurrency = currency
+	}
+}
+
+// Symbol of the given currency, or its ISO code if no symbol is known
+// Ex: from EUR to €
+func CurrencySymbol(currency string) string {
+	switch currency {
+	case "EUR":
+		return "€"
+	case "USD":
+		return "$"
+	case "GBP":
+		return "£"
+	case "JPY":
+		return "¥"
+	case "CHF":
+		return "CHF"
+	default:
+		return currency
+	}
+}
+
+// Symbol of the base currency, used to display aggregated values
+func BaseCurrencySymbol() string {
+	return CurrencySymbol(baseCurrency)
+}
+
 // reverse a string: from "abc" to "cba"
 func reverse(s string) string {
 	rns := []rune(s)
@@ -261,6 +294,16 @@ func DrawLine(xData []string, yData []float64, minSize fyne.Size, name string) *
 // This function creates a stacked line graph image from the specified data
 // If the input data is empty, return a No Data text image
 func DrawStackedLines(labels, xData []string, yData [][]float64, minSize fyne.Size, name string) fyne.CanvasObject {
+	return drawLines(labels, xData, yData, minSize, name, true)
+}
+
+// This function creates a graph image with one line per serie, to compare them
+// If the input data is empty, return a No Data text image
+func DrawLines(labels, xData []string, yData [][]float64, minSize fyne.Size, name string) fyne.CanvasObject {
+	return drawLines(labels, xData, yData, minSize, name, false)
+}
+
+func drawLines(labels, xData []string, yData [][]float64, minSize fyne.Size, name string, stacked bool) fyne.CanvasObject {
 
 	if len(labels) == 0 || len(xData) == 0 || len(yData) == 0 {
 		text := canvas.NewText(lang.L("No data"), color.White)
@@ -282,7 +325,7 @@ func DrawStackedLines(labels, xData []string, yData [][]float64, minSize fyne.Si
 			Bottom: 10,
 		},
 		SeriesList:             seriesList,
-		StackSeries:            charts.Ptr(true),
+		StackSeries:            charts.Ptr(stacked),
 		XAxis:                  charts.XAxisOption{Labels: xData},
 		Legend:                 charts.LegendOption{SeriesNames: labels},
 		LineStrokeWidth:        5.0,
@@ -297,13 +340,13 @@ func DrawStackedLines(labels, xData []string, yData [][]float64, minSize fyne.Si
 
 	err := p.LineChart(opt)
 	if err != nil {
-		Logger.Error().Err(err).Msg("Cannot create stacked line chart")
+		Logger.Error().Err(err).Msg("Cannot create lines chart")
 		return nil
 	}
 
 	buf, err := p.Bytes()
 	if err != nil {
-		Logger.Error().Err(err).Msg("Cannot convert stacked line chart to bytes")
+		Logger.Error().Err(err).Msg("Cannot convert lines chart to bytes")
 		return nil
 	}
 
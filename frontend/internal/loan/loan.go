@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
 	"freenahiFront/internal/settings"
 
 	"fyne.io/fyne/v2"
@@ -39,28 +40,28 @@ const (
 var columnSort = [numberOfColumns]int{}
 
 type Loan struct {
-	Loan_account_id      int     `json:"-"` // absent in base data, field added for simplicity
-	Total_amount         float64 `json:"total_amount"`
-	Available_amount     float64 `json:"available_amount"`
-	Used_amount          float64 `json:"used_amount"`
-	Subscription_date    string  `json:"subscription_date"`
-	Maturity_date        string  `json:"maturity_date"`
-	Start_repayment_date string  `json:"start_repayment_date"`
-	Deferred             bool    `json:"deferred"`
-	Next_payment_amount  float64 `json:"next_payment_amount"`
-	Next_payment_date    string  `json:"next_payment_date"`
-	Rate                 float64 `json:"rate"`
-	Nb_payments_left     uint    `json:"nb_payments_left"`
-	Nb_payments_done     uint    `json:"nb_payments_done"`
-	Nb_payments_total    uint    `json:"nb_payments_total"`
-	Last_payment_amount  float64 `json:"last_payment_amount"`
-	Last_payment_date    string  `json:"last_payment_date"`
-	Account_label        string  `json:"account_label"`
-	Insurance_label      string  `json:"insurance_label"`
-	Insurance_amount     float64 `json:"insurance_amount"`
-	Insurance_rate       float64 `json:"insurance_rate"`
-	Duration             uint    `json:"duration"`
-	Loan_type            string  `json:"type"`
+	Loan_account_id      int          `json:"loan_account_id"`
+	Total_amount         money.Amount `json:"total_amount"`
+	Available_amount     money.Amount `json:"available_amount"`
+	Used_amount          money.Amount `json:"used_amount"`
+	Subscription_date    string       `json:"subscription_date"`
+	Maturity_date        string       `json:"maturity_date"`
+	Start_repayment_date string       `json:"start_repayment_date"`
+	Deferred             bool         `json:"deferred"`
+	Next_payment_amount  money.Amount `json:"next_payment_amount"`
+	Next_payment_date    string       `json:"next_payment_date"`
+	Rate                 float64      `json:"rate"`
+	Nb_payments_left     uint         `json:"nb_payments_left"`
+	Nb_payments_done     uint         `json:"nb_payments_done"`
+	Nb_payments_total    uint         `json:"nb_payments_total"`
+	Last_payment_amount  money.Amount `json:"last_payment_amount"`
+	Last_payment_date    string       `json:"last_payment_date"`
+	Account_label        string       `json:"account_label"`
+	Insurance_label      string       `json:"insurance_label"`
+	Insurance_amount     money.Amount `json:"insurance_amount"`
+	Insurance_rate       float64      `json:"insurance_rate"`
+	Duration             uint         `json:"duration"`
+	Loan_type            string       `json:"type"`
 }
 
 // A standard table, but which has resizabled column width
@@ -137,10 +138,14 @@ func createLoanTable(app fyne.App) *fyne.Container {
 				}
 
 			case valueColumn:
-				item.SetText(helper.ValueSpacer(fmt.Sprintf("%0.2f", loans[id.Row].Total_amount)))
+				item.SetText(helper.ValueSpacer(loans[id.Row].Total_amount.StringFixed(2)))
 
 			case durationColumn:
-				item.SetText(fmt.Sprintf("%d", loans[id.Row].Duration))
+				if loans[id.Row].Loan_type == revolvingCredit { // Used and refunded freely
+					item.SetText(lang.L("Irrelevant"))
+				} else {
+					item.SetText(fmt.Sprintf("%d", loans[id.Row].Duration))
+				}
 			default:
 				helper.Logger.Fatal().Msg("Too much column in the account grid")
 			}
@@ -191,16 +196,22 @@ func createLoanTable(app fyne.App) *fyne.Container {
 		w := app.NewWindow(fmt.Sprintf("%s : %d", lang.L("Loan"), id.Row))
 		w.CenterOnScreen()
 
+		if loans[id.Row].Loan_type == revolvingCredit {
+			w.SetContent(createRevolvingCreditDetails(app, loans[id.Row]))
+			w.Show()
+			return
+		}
+
 		// Calculate the interest and capital reimbursed for the current (n+1) mensuality
 		remainingCapital := loans[id.Row].Total_amount
 
-		var periodInterest float64    // The interest amount for the current (n+1) mensuality
-		var sumPeriodInterest float64 // The sum of interests paid for this loan at the moment
-		var periodCapital float64
+		var periodInterest money.Amount    // The interest amount for the current (n+1) mensuality
+		var sumPeriodInterest money.Amount // The sum of interests paid for this loan at the moment
+		var periodCapital money.Amount
 
 		for range loans[id.Row].Nb_payments_done {
 
-			periodInterest = loans[id.Row].Rate / 100 * float64(remainingCapital) / 12
+			periodInterest = remainingCapital.Mul(loans[id.Row].Rate / 100 / 12)
 			periodCapital = loans[id.Row].Next_payment_amount - loans[id.Row].Insurance_amount - periodInterest
 
 			sumPeriodInterest += periodInterest
@@ -213,7 +224,7 @@ func createLoanTable(app fyne.App) *fyne.Container {
 		loanTypeItem.Alignment = fyne.TextAlignCenter
 		loanTypeItem.SizeName = theme.SizeNameSubHeadingText
 
-		amountItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Amount"), helper.ValueSpacer(fmt.Sprintf("%0.2f", loans[id.Row].Total_amount))))
+		amountItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Amount"), helper.ValueSpacer(loans[id.Row].Total_amount.StringFixed(2))))
 		amountItem.Alignment = fyne.TextAlignCenter
 		amountItem.SizeName = theme.SizeNameSubHeadingText
 
@@ -270,25 +281,25 @@ func createLoanTable(app fyne.App) *fyne.Container {
 		// Top right box
 		nextPeriodPaymentGraph := helper.DrawDoughnut(
 			[]string{lang.L("Capital"), lang.L("Insurance"), lang.L("Interests")},
-			[]float64{periodCapital, loans[id.Row].Insurance_amount, periodInterest},
+			[]float64{periodCapital.Float64(), loans[id.Row].Insurance_amount.Float64(), periodInterest.Float64()},
 			fyne.NewSize(120, 120),
 			"Next period payment",
 		)
 
-		mensualityItem := widget.NewLabel(fmt.Sprintf("%s: %0.2f", lang.L("Next mensuality"), loans[id.Row].Next_payment_amount))
+		mensualityItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Next mensuality"), loans[id.Row].Next_payment_amount.StringFixed(2)))
 		mensualityItem.Alignment = fyne.TextAlignCenter
 		mensualityItem.SizeName = theme.SizeNameSubHeadingText
 
-		periodCapitalItem := widget.NewLabel(fmt.Sprintf("%s: %0.2f", lang.L("Capital"), periodCapital))
+		periodCapitalItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Capital"), periodCapital.StringFixed(2)))
 		periodCapitalItem.Alignment = fyne.TextAlignCenter
 		periodCapitalItem.SizeName = theme.SizeNameCaptionText
 
-		periodInterestItem := widget.NewLabel(fmt.Sprintf("%s: %0.2f", lang.L("Interests"), periodInterest))
+		periodInterestItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Interests"), periodInterest.StringFixed(2)))
 		periodInterestItem.Alignment = fyne.TextAlignCenter
 
 		periodInterestItem.SizeName = theme.SizeNameCaptionText
 
-		periodInsuranceItem := widget.NewLabel(fmt.Sprintf("%s: %0.2f", lang.L("Insurance"), loans[id.Row].Insurance_amount))
+		periodInsuranceItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Insurance"), loans[id.Row].Insurance_amount.StringFixed(2)))
 		periodInsuranceItem.Alignment = fyne.TextAlignCenter
 
 		periodInsuranceItem.SizeName = theme.SizeNameCaptionText
@@ -321,25 +332,25 @@ func createLoanTable(app fyne.App) *fyne.Container {
 
 		// =======================================================================================
 		// Bottom left box
-		totalToRefund := (loans[id.Row].Next_payment_amount - loans[id.Row].Insurance_amount) * float64(loans[id.Row].Nb_payments_total)
+		totalToRefund := (loans[id.Row].Next_payment_amount - loans[id.Row].Insurance_amount).Mul(float64(loans[id.Row].Nb_payments_total))
 		paidInterest := totalToRefund - loans[id.Row].Total_amount
 
 		totalPaymentGraph := helper.DrawDoughnut(
 			[]string{lang.L("Capital"), lang.L("Interests")},
-			[]float64{loans[id.Row].Total_amount, paidInterest},
+			[]float64{loans[id.Row].Total_amount.Float64(), paidInterest.Float64()},
 			fyne.NewSize(120, 120),
 			"Next period payment",
 		)
 
-		totalToRefundItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Total loan cost"), helper.ValueSpacer(fmt.Sprintf("%0.2f", totalToRefund))))
+		totalToRefundItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Total loan cost"), helper.ValueSpacer(totalToRefund.StringFixed(2))))
 		totalToRefundItem.Alignment = fyne.TextAlignCenter
 		totalToRefundItem.SizeName = theme.SizeNameSubHeadingText
 
-		totalCapitalItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Capital"), helper.ValueSpacer(fmt.Sprintf("%0.2f", loans[id.Row].Total_amount))))
+		totalCapitalItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Capital"), helper.ValueSpacer(loans[id.Row].Total_amount.StringFixed(2))))
 		totalCapitalItem.Alignment = fyne.TextAlignCenter
 		totalCapitalItem.SizeName = theme.SizeNameCaptionText
 
-		totalInterestItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Total interest to pay"), helper.ValueSpacer(fmt.Sprintf("%0.2f", paidInterest))))
+		totalInterestItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Total interest to pay"), helper.ValueSpacer(paidInterest.StringFixed(2))))
 		totalInterestItem.Alignment = fyne.TextAlignCenter
 		totalInterestItem.SizeName = theme.SizeNameCaptionText
 
@@ -369,7 +380,7 @@ func createLoanTable(app fyne.App) *fyne.Container {
 
 		// =======================================================================================
 		// Bottom right box
-		remainingCapitalItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Outstanding capital"), helper.ValueSpacer(fmt.Sprintf("%0.2f", remainingCapital))))
+		remainingCapitalItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Outstanding capital"), helper.ValueSpacer(remainingCapital.StringFixed(2))))
 		remainingCapitalItem.Alignment = fyne.TextAlignCenter
 		remainingCapitalItem.SizeName = theme.SizeNameSubHeadingText
 
@@ -381,7 +392,7 @@ func createLoanTable(app fyne.App) *fyne.Container {
 				remainingCapitalProgressItem.Value*100, lang.L("Of the capital"),
 			)
 		}
-		remainingCapitalProgressItem.SetValue(1 - remainingCapital/loans[id.Row].Total_amount)
+		remainingCapitalProgressItem.SetValue(1 - remainingCapital.Div(loans[id.Row].Total_amount))
 
 		bottomRightBox := container.NewBorder(
 			widget.NewSeparator(),
@@ -401,20 +412,20 @@ func createLoanTable(app fyne.App) *fyne.Container {
 
 		currentPaymentGraph := helper.DrawDoughnut(
 			[]string{lang.L("Capital"), lang.L("Interests")},
-			[]float64{loans[id.Row].Total_amount - remainingCapital, sumPeriodInterest},
+			[]float64{(loans[id.Row].Total_amount - remainingCapital).Float64(), sumPeriodInterest.Float64()},
 			fyne.NewSize(120, 120),
 			"Current period payment",
 		)
 
-		totalRefundedItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Total refunded"), helper.ValueSpacer(fmt.Sprintf("%0.2f", float64(loans[id.Row].Nb_payments_done)*(loans[id.Row].Next_payment_amount-loans[id.Row].Insurance_amount)))))
+		totalRefundedItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Total refunded"), helper.ValueSpacer((loans[id.Row].Next_payment_amount - loans[id.Row].Insurance_amount).Mul(float64(loans[id.Row].Nb_payments_done)).StringFixed(2))))
 		totalRefundedItem.Alignment = fyne.TextAlignCenter
 		totalRefundedItem.SizeName = theme.SizeNameSubHeadingText
 
-		capitalRefundedItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Capital"), helper.ValueSpacer(fmt.Sprintf("%0.2f", loans[id.Row].Total_amount-remainingCapital))))
+		capitalRefundedItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Capital"), helper.ValueSpacer((loans[id.Row].Total_amount - remainingCapital).StringFixed(2))))
 		capitalRefundedItem.Alignment = fyne.TextAlignCenter
 		capitalRefundedItem.SizeName = theme.SizeNameCaptionText
 
-		interestRefundedItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Interests"), helper.ValueSpacer(fmt.Sprintf("%0.2f", sumPeriodInterest))))
+		interestRefundedItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Interests"), helper.ValueSpacer(sumPeriodInterest.StringFixed(2))))
 		interestRefundedItem.Alignment = fyne.TextAlignCenter
 		interestRefundedItem.SizeName = theme.SizeNameCaptionText
 
@@ -442,18 +453,41 @@ func createLoanTable(app fyne.App) *fyne.Container {
 			),
 		)
 		// =======================================================================================
+		// Schedule, computed by the backend
+		var scheduleItem fyne.CanvasObject
+		schedule, err := getSchedule(app, loans[id.Row].Loan_account_id)
+		if err != nil {
+			helper.Logger.Error().Err(err).Int("loan_account_id", loans[id.Row].Loan_account_id).Msg("Cannot get loan schedule")
+			scheduleItem = widget.NewLabel(lang.L("Unknown"))
+		} else {
+			scheduleItem = createScheduleTable(schedule)
+		}
 
-		w.SetContent(container.NewVBox(
-			container.NewGridWithColumns(2,
-				topLeftBox,
-				topRightBox,
+		w.SetContent(container.NewBorder(
+			container.NewVBox(
+				container.NewGridWithColumns(2,
+					topLeftBox,
+					topRightBox,
+				),
+				container.NewGridWithColumns(3,
+					bottomLeftBox,
+					bottomMidBox,
+					bottomRightBox,
+				),
 			),
-			container.NewGridWithColumns(3,
-				bottomLeftBox,
-				bottomMidBox,
-				bottomRightBox,
+			container.NewGridWithColumns(2,
+				widget.NewButton(lang.L("Tranches"), func() {
+					showTranchesWindow(app, loans[id.Row])
+				}),
+				widget.NewButton(lang.L("Simulate an early repayment or a refinancing"), func() {
+					showSimulationDialog(app, w, loans[id.Row])
+				}),
 			),
+			nil,
+			nil,
+			scheduleItem,
 		))
+		w.Resize(fyne.NewSize(900, 800))
 		w.Show()
 	}
 
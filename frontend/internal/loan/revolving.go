@@ -0,0 +1,136 @@
+package loan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
+	"freenahiFront/internal/settings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Loan type sent by Powens for a revolving credit, which has a limit instead of a schedule
+const revolvingCredit = "revolvingcredit"
+
+// Use of a revolving credit at a date
+type Usage struct {
+	Date             string       `json:"date"`
+	Available_amount money.Amount `json:"available_amount"`
+	Used_amount      money.Amount `json:"used_amount"`
+	Utilization      float64      `json:"utilization"`
+}
+
+// Details of a revolving credit: its limit, the amount used and available, and their history
+func createRevolvingCreditDetails(app fyne.App, loan Loan) fyne.CanvasObject {
+
+	loanTypeItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Type"), lang.L(loan.Loan_type)))
+	loanTypeItem.Alignment = fyne.TextAlignCenter
+	loanTypeItem.SizeName = theme.SizeNameSubHeadingText
+
+	limitItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Credit limit"), helper.ValueSpacer(loan.Total_amount.StringFixed(2))))
+	limitItem.Alignment = fyne.TextAlignCenter
+	limitItem.SizeName = theme.SizeNameSubHeadingText
+
+	usedItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Used amount"), helper.ValueSpacer(loan.Used_amount.StringFixed(2))))
+	usedItem.Alignment = fyne.TextAlignCenter
+
+	availableItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Available amount"), helper.ValueSpacer(loan.Available_amount.StringFixed(2))))
+	availableItem.Alignment = fyne.TextAlignCenter
+
+	rateItem := widget.NewLabel(fmt.Sprintf("%s: %0.2f %%", lang.L("Rate"), loan.Rate))
+	rateItem.Alignment = fyne.TextAlignCenter
+
+	nextPaymentItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Next mensuality"), helper.ValueSpacer(loan.Next_payment_amount.StringFixed(2))))
+	nextPaymentItem.Alignment = fyne.TextAlignCenter
+
+	limit := loan.Total_amount
+	if limit <= 0 {
+		limit = loan.Used_amount + loan.Available_amount
+	}
+	utilizationItem := widget.NewProgressBar()
+	utilizationItem.TextFormatter = func() string {
+		return fmt.Sprintf("%.2f%% %s", utilizationItem.Value*100, lang.L("Of the limit used"))
+	}
+	if limit > 0 {
+		utilizationItem.SetValue(loan.Used_amount.Div(limit))
+	}
+
+	var usageGraph fyne.CanvasObject
+	usages, err := getUsage(app, loan.Loan_account_id)
+	if err != nil {
+		helper.Logger.Error().Err(err).Int("loan_account_id", loan.Loan_account_id).Msg("Cannot get revolving credit usage")
+		usageGraph = widget.NewLabel(lang.L("Unknown"))
+	} else {
+		dates := []string{}
+		y := make([][]float64, 2)
+		for _, usage := range usages {
+			dates = append(dates, usage.Date)
+			y[0] = append(y[0], usage.Used_amount.Float64())
+			y[1] = append(y[1], usage.Available_amount.Float64())
+		}
+		usageGraph = helper.DrawLines([]string{lang.L("Used amount"), lang.L("Available amount")}, dates, y, fyne.NewSize(600, 250), "Revolving credit usage")
+	}
+
+	return container.NewVBox(
+		container.NewGridWithColumns(2,
+			loanTypeItem,
+			limitItem),
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2,
+			usedItem,
+			availableItem),
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2,
+			rateItem,
+			nextPaymentItem),
+		widget.NewSeparator(),
+		utilizationItem,
+		widget.NewSeparator(),
+		usageGraph,
+	)
+}
+
+// Call the backend endpoint "/loan/{id}/usage" and retrieve the use of a revolving credit over time
+func getUsage(app fyne.App, loanAccountId int) ([]Usage, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/loan/%d/usage", backendProtocol, backendIp, backendPort, loanAccountId)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status + ": " + string(body))
+	}
+
+	var usages []Usage
+	if err := json.Unmarshal(body, &usages); err != nil {
+		return nil, err
+	}
+
+	return usages, nil
+}
@@ -0,0 +1,161 @@
+package loan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
+	"freenahiFront/internal/settings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	installmentNumberColumn int = iota
+	installmentDateColumn
+	installmentCapitalColumn
+	installmentInterestColumn
+	installmentInsuranceColumn
+	installmentOutstandingColumn
+	numberOfInstallmentColumns
+)
+
+type Installment struct {
+	Number              int          `json:"number"`
+	Date                string       `json:"date"`
+	Payment             money.Amount `json:"payment"`
+	Capital             money.Amount `json:"capital"`
+	Interest            money.Amount `json:"interest"`
+	Insurance           money.Amount `json:"insurance"`
+	Outstanding_capital money.Amount `json:"outstanding_capital"`
+	Deferred            bool         `json:"deferred"`
+	Paid                bool         `json:"paid"`
+}
+
+type Schedule struct {
+	Loan_account_id int           `json:"loan_account_id"`
+	Name            string        `json:"name,omitempty"`     // name of the tranche
+	Tranches        []Schedule    `json:"tranches,omitempty"` // schedule of each tranche of a mortgage made of tranches
+	Installments    []Installment `json:"installments"`
+	Total_capital   money.Amount  `json:"total_capital"`
+	Total_interest  money.Amount  `json:"total_interest"`
+	Total_insurance money.Amount  `json:"total_insurance"`
+	Total_paid      money.Amount  `json:"total_paid"`
+}
+
+// Table of every installment of an amortizing loan. Paid installments are displayed in bold
+func createScheduleTable(schedule *Schedule) *widget.Table {
+
+	scheduleTable := widget.NewTable(
+		func() (int, int) {
+			return len(schedule.Installments), numberOfInstallmentColumns
+		},
+		func() fyne.CanvasObject {
+			item := widget.NewLabel("Template")
+			item.Alignment = fyne.TextAlignCenter
+			return item
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+
+			item := o.(*widget.Label)
+			installment := schedule.Installments[id.Row]
+			item.TextStyle.Bold = installment.Paid
+
+			switch id.Col {
+			case installmentNumberColumn:
+				item.SetText(strconv.Itoa(installment.Number))
+			case installmentDateColumn:
+				item.SetText(installment.Date)
+			case installmentCapitalColumn:
+				if installment.Deferred {
+					item.SetText(lang.L("Deferred"))
+				} else {
+					item.SetText(helper.ValueSpacer(installment.Capital.StringFixed(2)))
+				}
+			case installmentInterestColumn:
+				item.SetText(helper.ValueSpacer(installment.Interest.StringFixed(2)))
+			case installmentInsuranceColumn:
+				item.SetText(helper.ValueSpacer(installment.Insurance.StringFixed(2)))
+			case installmentOutstandingColumn:
+				item.SetText(helper.ValueSpacer(installment.Outstanding_capital.StringFixed(2)))
+			default:
+				helper.Logger.Fatal().Msg("Too much column in the schedule grid")
+			}
+		},
+	)
+
+	scheduleTable.ShowHeaderRow = true
+	scheduleTable.CreateHeader = func() fyne.CanvasObject {
+		label := widget.NewLabel("000")
+		label.Alignment = fyne.TextAlignCenter
+		label.TextStyle.Bold = true
+		return label
+	}
+	scheduleTable.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+
+		label := o.(*widget.Label)
+
+		switch id.Col {
+		case installmentNumberColumn:
+			label.SetText("#")
+		case installmentDateColumn:
+			label.SetText(lang.L("Due date"))
+		case installmentCapitalColumn:
+			label.SetText(lang.L("Capital"))
+		case installmentInterestColumn:
+			label.SetText(lang.L("Interests"))
+		case installmentInsuranceColumn:
+			label.SetText(lang.L("Insurance"))
+		case installmentOutstandingColumn:
+			label.SetText(lang.L("Outstanding capital"))
+		default:
+			helper.Logger.Fatal().Msg("Too much column in the schedule grid for header")
+		}
+	}
+
+	for col := range numberOfInstallmentColumns {
+		scheduleTable.SetColumnWidth(col, 130)
+	}
+	scheduleTable.SetColumnWidth(installmentNumberColumn, 50)
+
+	return scheduleTable
+}
+
+// Call the backend endpoint "/loan/{id}/schedule" and retrieve the installments of a loan
+func getSchedule(app fyne.App, loanAccountId int) (*Schedule, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/loan/%d/schedule", backendProtocol, backendIp, backendPort, loanAccountId)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status + ": " + string(body))
+	}
+
+	var schedule Schedule
+	if err := json.Unmarshal(body, &schedule); err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
@@ -0,0 +1,228 @@
+package loan
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
+	"freenahiFront/internal/settings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/validation"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	amountRegex     = `^[0-9]+([.,][0-9]{1,2})?$`
+	percentageRegex = `^[0-9]{1,3}([.,][0-9]{1,3})?$`
+	durationRegex   = `^[0-9]{1,4}$`
+)
+
+// Values sent to the backend for each choice of the form
+var (
+	scenarios       = []string{"repayment", "refinancing"}
+	repaymentModes  = []string{"reduce_duration", "reduce_installment"}
+	indemnityChoice = []string{"legal", "none", "rate"}
+)
+
+// Early repayment or refinancing simulated from the next installment of a loan
+type SimulationRequest struct {
+	Scenario       string       `json:"scenario"`
+	Amount         money.Amount `json:"amount"`
+	Mode           string       `json:"mode"`
+	Indemnity      string       `json:"indemnity"`
+	Indemnity_rate float64      `json:"indemnity_rate"`
+	Rate           float64      `json:"rate"`
+	Duration       uint         `json:"duration"`
+	Fees           money.Amount `json:"fees"`
+}
+
+type Simulation struct {
+	Outstanding_capital money.Amount `json:"outstanding_capital"`
+	Repaid_capital      money.Amount `json:"repaid_capital"`
+	Indemnity           money.Amount `json:"indemnity"`
+	Fees                money.Amount `json:"fees"`
+	Current             Schedule     `json:"current"`
+	New                 Schedule     `json:"new"`
+	Interest_saved      money.Amount `json:"interest_saved"`
+	Net_saving          money.Amount `json:"net_saving"`
+	Break_even_month    int          `json:"break_even_month"`
+}
+
+// Display a form to simulate an early repayment or a refinancing of a loan, then its result
+func showSimulationDialog(app fyne.App, win fyne.Window, loan Loan) {
+
+	scenarioItem := widget.NewSelect([]string{lang.L("Early repayment"), lang.L("Refinancing")}, nil)
+	scenarioItem.SetSelectedIndex(0)
+
+	amountItem := widget.NewEntry()
+	amountItem.SetText("0")
+	amountItem.Validator = validation.NewRegexp(amountRegex, lang.L("Regex amount"))
+
+	modeItem := widget.NewSelect([]string{lang.L("Reduce duration"), lang.L("Reduce installment")}, nil)
+	modeItem.SetSelectedIndex(0)
+
+	indemnityItem := widget.NewSelect([]string{lang.L("Legal indemnity"), lang.L("None"), lang.L("Indemnity rate")}, nil)
+	indemnityItem.SetSelectedIndex(0)
+
+	indemnityRateItem := widget.NewEntry()
+	indemnityRateItem.SetText("0")
+	indemnityRateItem.Validator = validation.NewRegexp(percentageRegex, lang.L("Regex amount"))
+
+	rateItem := widget.NewEntry()
+	rateItem.SetText(fmt.Sprintf("%.2f", loan.Rate))
+	rateItem.Validator = validation.NewRegexp(percentageRegex, lang.L("Regex amount"))
+
+	durationItem := widget.NewEntry()
+	durationItem.SetText(strconv.Itoa(int(loan.Nb_payments_left)))
+	durationItem.Validator = validation.NewRegexp(durationRegex, lang.L("Regex amount"))
+
+	feesItem := widget.NewEntry()
+	feesItem.SetText("0")
+	feesItem.Validator = validation.NewRegexp(amountRegex, lang.L("Regex amount"))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Scenario"), scenarioItem),
+		widget.NewFormItem(lang.L("Repaid amount"), amountItem),
+		widget.NewFormItem(lang.L("After the repayment"), modeItem),
+		widget.NewFormItem(lang.L("Indemnity"), indemnityItem),
+		widget.NewFormItem(lang.L("Indemnity rate"), indemnityRateItem),
+		widget.NewFormItem(lang.L("New rate"), rateItem),
+		widget.NewFormItem(lang.L("New duration"), durationItem),
+		widget.NewFormItem(lang.L("Fees"), feesItem),
+	}
+	items[1].HintText = lang.L("Repaid amount hint")
+
+	d := dialog.NewForm(lang.L("Simulate"), lang.L("Simulate"), lang.L("Cancel"), items, func(b bool) {
+		if !b {
+			return
+		}
+
+		duration, _ := strconv.Atoi(durationItem.Text)
+		request := SimulationRequest{
+			Scenario:       scenarios[scenarioItem.SelectedIndex()],
+			Amount:         parseAmount(amountItem.Text),
+			Mode:           repaymentModes[modeItem.SelectedIndex()],
+			Indemnity:      indemnityChoice[indemnityItem.SelectedIndex()],
+			Indemnity_rate: parsePercentage(indemnityRateItem.Text),
+			Rate:           parsePercentage(rateItem.Text),
+			Duration:       uint(duration),
+			Fees:           parseAmount(feesItem.Text),
+		}
+
+		simulation, err := simulateLoan(app, loan.Loan_account_id, request)
+		if err != nil {
+			helper.Logger.Error().Err(err).Msg("Cannot simulate loan")
+			dialog.ShowError(err, win)
+			return
+		}
+		showSimulation(app, simulation)
+	}, win)
+
+	d.Resize(fyne.NewSize(d.MinSize().Width*1.5, d.MinSize().Height))
+	d.Show()
+}
+
+// Display the savings of a simulation and the new schedule
+func showSimulation(app fyne.App, simulation *Simulation) {
+
+	w := app.NewWindow(lang.L("Simulation"))
+	w.CenterOnScreen()
+
+	newLabel := func(key string, value money.Amount) *widget.Label {
+		item := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L(key), helper.ValueSpacer(value.StringFixed(2))))
+		item.Alignment = fyne.TextAlignCenter
+		return item
+	}
+
+	breakEven := lang.L("Never")
+	if simulation.Break_even_month > 0 {
+		breakEven = fmt.Sprintf("%d %s", simulation.Break_even_month, lang.L("Months"))
+	}
+	breakEvenItem := widget.NewLabel(fmt.Sprintf("%s: %s", lang.L("Break-even"), breakEven))
+	breakEvenItem.Alignment = fyne.TextAlignCenter
+
+	netSavingItem := newLabel("Net saving", simulation.Net_saving)
+	netSavingItem.SizeName = theme.SizeNameSubHeadingText
+
+	summary := container.NewVBox(
+		container.NewGridWithColumns(2,
+			newLabel("Outstanding capital", simulation.Outstanding_capital),
+			newLabel("Repaid capital", simulation.Repaid_capital)),
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2,
+			newLabel("Indemnity", simulation.Indemnity),
+			newLabel("Fees", simulation.Fees)),
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2,
+			newLabel("Interest saved", simulation.Interest_saved),
+			breakEvenItem),
+		widget.NewSeparator(),
+		netSavingItem,
+		widget.NewSeparator(),
+	)
+
+	w.SetContent(container.NewBorder(summary, nil, nil, nil, createScheduleTable(&simulation.New)))
+	w.Resize(fyne.NewSize(800, 600))
+	w.Show()
+}
+
+// Parse an amount already checked by a validator. Accept both "12.5" and "12,5"
+func parseAmount(value string) money.Amount {
+	amount, _ := money.Parse(strings.ReplaceAll(value, ",", "."))
+	return amount
+}
+
+// Parse a percentage already checked by a validator. Accept both "12.5" and "12,5"
+func parsePercentage(value string) float64 {
+	percentage, _ := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	return percentage
+}
+
+// Call the backend endpoint POST "/loan/{id}/simulation" and retrieve the simulated repayment or refinancing
+func simulateLoan(app fyne.App, loanAccountId int, request SimulationRequest) (*Simulation, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/loan/%d/simulation", backendProtocol, backendIp, backendPort, loanAccountId)
+
+	jsonBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status + ": " + string(body))
+	}
+
+	var simulation Simulation
+	if err := json.Unmarshal(body, &simulation); err != nil {
+		return nil, err
+	}
+
+	return &simulation, nil
+}
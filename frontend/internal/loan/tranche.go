@@ -0,0 +1,378 @@
+package loan
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
+	"freenahiFront/internal/settings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/validation"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const dateRegex = `^[0-9]{4}-[0-9]{2}-[0-9]{2}$`
+
+// Part of a mortgage with its own amount, rate and duration, such as a zero-rate or an employer loan
+type Tranche struct {
+	Tranche_id         int          `json:"id"`
+	Loan_account_id    int          `json:"loan_account_id"`
+	Name               string       `json:"name"`
+	Amount             money.Amount `json:"amount"`
+	Rate               float64      `json:"rate"`
+	Rate_cap           float64      `json:"rate_cap"`
+	Payments           uint         `json:"payments"`
+	Deferred_payments  uint         `json:"deferred_payments"`
+	First_payment_date string       `json:"first_payment_date"`
+	Insurance_amount   money.Amount `json:"insurance_amount"`
+	Rate_changes       []RateChange `json:"rate_changes"`
+}
+
+// New rate of a variable rate tranche, from a date
+type RateChange struct {
+	Change_id  int     `json:"id"`
+	Tranche_id int     `json:"tranche_id"`
+	Date       string  `json:"date"`
+	Rate       float64 `json:"rate"`
+}
+
+// Display the tranches of a loan with their rate changes. Every change is sent to the backend,
+// then the list is reloaded
+func showTranchesWindow(app fyne.App, loan Loan) {
+
+	w := app.NewWindow(lang.L("Tranches"))
+	w.CenterOnScreen()
+
+	var reload func()
+	reload = func() {
+		tranches, err := getTranches(app, loan.Loan_account_id)
+		if err != nil {
+			helper.Logger.Error().Err(err).Int("loan_account_id", loan.Loan_account_id).Msg("Cannot get tranches")
+			dialog.ShowError(err, w)
+		}
+
+		list := container.NewVBox()
+		for _, tranche := range tranches {
+			list.Add(createTrancheItem(app, w, tranche, reload))
+			list.Add(widget.NewSeparator())
+		}
+		if len(tranches) == 0 {
+			list.Add(widget.NewLabel(lang.L("No tranche")))
+		}
+
+		addButton := widget.NewButtonWithIcon(lang.L("Add a tranche"), theme.ContentAddIcon(), func() {
+			showTrancheDialog(app, w, loan, reload)
+		})
+
+		w.SetContent(container.NewBorder(nil, addButton, nil, nil, container.NewVScroll(list)))
+	}
+	reload()
+
+	w.Resize(fyne.NewSize(700, 500))
+	w.Show()
+}
+
+// Summary of a tranche with its rate changes, and buttons to add a rate change or to delete them
+func createTrancheItem(app fyne.App, win fyne.Window, tranche Tranche, reload func()) fyne.CanvasObject {
+
+	rate := fmt.Sprintf("%.2f %%", tranche.Rate)
+	if tranche.Rate_cap > 0 {
+		rate += fmt.Sprintf(" (%s %.2f %%)", lang.L("Cap"), tranche.Rate_cap)
+	}
+
+	title := widget.NewLabel(fmt.Sprintf("%s: %s - %s - %d %s", tranche.Name, helper.ValueSpacer(tranche.Amount.StringFixed(2)), rate, tranche.Payments, lang.L("Months")))
+	title.TextStyle = fyne.TextStyle{Bold: true}
+
+	details := widget.NewLabel(fmt.Sprintf("%s: %s - %s: %d", lang.L("First payment"), tranche.First_payment_date, lang.L("Deferred payments"), tranche.Deferred_payments))
+	details.SizeName = theme.SizeNameCaptionText
+
+	deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		cnf := dialog.NewConfirm(lang.L("Delete"), lang.L("Delete tranche confirmation"), func(b bool) {
+			if !b {
+				return
+			}
+			if err := deleteTranche(app, tranche.Tranche_id); err != nil {
+				helper.Logger.Error().Err(err).Int("tranche_id", tranche.Tranche_id).Msg("Cannot delete tranche")
+				dialog.ShowError(err, win)
+				return
+			}
+			reload()
+		}, win)
+		cnf.SetDismissText(lang.L("Cancel"))
+		cnf.SetConfirmText(lang.L("Delete"))
+		cnf.Show()
+	})
+
+	rateButton := widget.NewButtonWithIcon(lang.L("Rate change"), theme.ContentAddIcon(), func() {
+		showRateChangeDialog(app, win, tranche, reload)
+	})
+
+	changes := container.NewVBox()
+	for _, change := range tranche.Rate_changes {
+		changeId := change.Change_id
+		changes.Add(container.NewBorder(nil, nil, nil,
+			widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				if err := deleteRateChange(app, changeId); err != nil {
+					helper.Logger.Error().Err(err).Int("change_id", changeId).Msg("Cannot delete rate change")
+					dialog.ShowError(err, win)
+					return
+				}
+				reload()
+			}),
+			widget.NewLabel(fmt.Sprintf("%s %s: %.2f %%", lang.L("From"), change.Date, change.Rate)),
+		))
+	}
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, nil, container.NewHBox(rateButton, deleteButton), title),
+		details,
+		changes,
+	)
+}
+
+// Display a form to add a tranche to a loan
+func showTrancheDialog(app fyne.App, win fyne.Window, loan Loan, reload func()) {
+
+	nameItem := widget.NewEntry()
+	nameItem.Validator = validation.NewRegexp(`^.+$`, lang.L("Name required"))
+
+	amountItem := widget.NewEntry()
+	amountItem.Validator = validation.NewRegexp(amountRegex, lang.L("Regex amount"))
+
+	rateItem := widget.NewEntry()
+	rateItem.SetText("0")
+	rateItem.Validator = validation.NewRegexp(percentageRegex, lang.L("Regex amount"))
+
+	rateCapItem := widget.NewEntry()
+	rateCapItem.SetText("0")
+	rateCapItem.Validator = validation.NewRegexp(percentageRegex, lang.L("Regex amount"))
+
+	paymentsItem := widget.NewEntry()
+	paymentsItem.Validator = validation.NewRegexp(durationRegex, lang.L("Regex amount"))
+
+	deferredItem := widget.NewEntry()
+	deferredItem.SetText("0")
+	deferredItem.Validator = validation.NewRegexp(durationRegex, lang.L("Regex amount"))
+
+	firstPaymentItem := widget.NewEntry()
+	firstPaymentItem.SetPlaceHolder("YYYY-MM-DD")
+	firstPaymentItem.Validator = validation.NewRegexp(dateRegex, lang.L("Regex date"))
+
+	insuranceItem := widget.NewEntry()
+	insuranceItem.SetText("0")
+	insuranceItem.Validator = validation.NewRegexp(amountRegex, lang.L("Regex amount"))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Name"), nameItem),
+		widget.NewFormItem(lang.L("Amount"), amountItem),
+		widget.NewFormItem(lang.L("Rate"), rateItem),
+		widget.NewFormItem(lang.L("Rate cap"), rateCapItem),
+		widget.NewFormItem(lang.L("Payments"), paymentsItem),
+		widget.NewFormItem(lang.L("Deferred payments"), deferredItem),
+		widget.NewFormItem(lang.L("First payment"), firstPaymentItem),
+		widget.NewFormItem(lang.L("Insurance"), insuranceItem),
+	}
+	items[3].HintText = lang.L("Rate cap hint")
+
+	d := dialog.NewForm(lang.L("Add a tranche"), lang.L("Add"), lang.L("Cancel"), items, func(b bool) {
+		if !b {
+			return
+		}
+
+		payments, _ := strconv.Atoi(paymentsItem.Text)
+		deferred, _ := strconv.Atoi(deferredItem.Text)
+		tranche := Tranche{
+			Name:               nameItem.Text,
+			Amount:             parseAmount(amountItem.Text),
+			Rate:               parsePercentage(rateItem.Text),
+			Rate_cap:           parsePercentage(rateCapItem.Text),
+			Payments:           uint(payments),
+			Deferred_payments:  uint(deferred),
+			First_payment_date: firstPaymentItem.Text,
+			Insurance_amount:   parseAmount(insuranceItem.Text),
+		}
+
+		if err := createTranche(app, loan.Loan_account_id, tranche); err != nil {
+			helper.Logger.Error().Err(err).Int("loan_account_id", loan.Loan_account_id).Msg("Cannot create tranche")
+			dialog.ShowError(err, win)
+			return
+		}
+		reload()
+	}, win)
+
+	d.Resize(fyne.NewSize(d.MinSize().Width*1.5, d.MinSize().Height))
+	d.Show()
+}
+
+// Display a form to add a new rate to a variable rate tranche
+func showRateChangeDialog(app fyne.App, win fyne.Window, tranche Tranche, reload func()) {
+
+	dateItem := widget.NewEntry()
+	dateItem.SetPlaceHolder("YYYY-MM-DD")
+	dateItem.Validator = validation.NewRegexp(dateRegex, lang.L("Regex date"))
+
+	rateItem := widget.NewEntry()
+	rateItem.SetText(fmt.Sprintf("%.2f", tranche.Rate))
+	rateItem.Validator = validation.NewRegexp(percentageRegex, lang.L("Regex amount"))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(lang.L("Date"), dateItem),
+		widget.NewFormItem(lang.L("Rate"), rateItem),
+	}
+
+	d := dialog.NewForm(lang.L("Rate change"), lang.L("Add"), lang.L("Cancel"), items, func(b bool) {
+		if !b {
+			return
+		}
+
+		change := RateChange{Date: dateItem.Text, Rate: parsePercentage(rateItem.Text)}
+		if err := createRateChange(app, tranche.Tranche_id, change); err != nil {
+			helper.Logger.Error().Err(err).Int("tranche_id", tranche.Tranche_id).Msg("Cannot create rate change")
+			dialog.ShowError(err, win)
+			return
+		}
+		reload()
+	}, win)
+
+	d.Show()
+}
+
+// Call the backend endpoint GET "/loan/{id}/tranche" and retrieve the tranches of a loan
+func getTranches(app fyne.App, loanAccountId int) ([]Tranche, error) {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/loan/%d/tranche", backendProtocol, backendIp, backendPort, loanAccountId)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// No tranche
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status + ": " + string(body))
+	}
+
+	var tranches []Tranche
+	if err := json.Unmarshal(body, &tranches); err != nil {
+		return nil, err
+	}
+
+	return tranches, nil
+}
+
+// Call the backend endpoint POST "/loan/{id}/tranche"
+func createTranche(app fyne.App, loanAccountId int, tranche Tranche) error {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/loan/%d/tranche", backendProtocol, backendIp, backendPort, loanAccountId)
+
+	return post(url, tranche)
+}
+
+// Call the backend endpoint POST "/loan/tranche/{id}/rate"
+func createRateChange(app fyne.App, trancheId int, change RateChange) error {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/loan/tranche/%d/rate", backendProtocol, backendIp, backendPort, trancheId)
+
+	return post(url, change)
+}
+
+// Call the backend endpoint DELETE "/loan/tranche/{id}"
+func deleteTranche(app fyne.App, trancheId int) error {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/loan/tranche/%d", backendProtocol, backendIp, backendPort, trancheId)
+
+	return remove(url)
+}
+
+// Call the backend endpoint DELETE "/loan/rate/{id}"
+func deleteRateChange(app fyne.App, changeId int) error {
+
+	backendIp := app.Preferences().StringWithFallback(settings.PreferenceBackendIP, settings.BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(settings.PreferenceBackendProtocol, settings.BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(settings.PreferenceBackendPort, settings.BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/loan/rate/%d", backendProtocol, backendIp, backendPort, changeId)
+
+	return remove(url)
+}
+
+// Send an object to a creation endpoint, which answers 201
+func post(url string, object any) error {
+
+	jsonBody, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(resp.Status + ": " + string(body))
+	}
+
+	return nil
+}
+
+// Call a deletion endpoint, which answers 204
+func remove(url string) error {
+
+	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(resp.Status + ": " + string(body))
+	}
+
+	return nil
+}
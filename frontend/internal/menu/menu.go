@@ -9,6 +9,7 @@ import (
 	"fyne.io/fyne/v2/lang"
 
 	"freenahiFront/internal/account"
+	"freenahiFront/internal/events"
 	financialassets "freenahiFront/internal/financialAssets"
 	"freenahiFront/internal/loan"
 	"freenahiFront/internal/settings"
@@ -68,14 +69,76 @@ func NewTopMenu(app fyne.App, win fyne.Window) *fyne.MainMenu {
 }
 
 func NewLeftMenu(app fyne.App, win fyne.Window) *container.AppTabs {
-	tabs := container.NewAppTabs(
-		container.NewTabItem(lang.L("Financial assets"), financialassets.NewFinancialAssetsScreen(app, win)),
-		container.NewTabItem(lang.L("Accounts"), account.NewAccountScreen(app)),
-		container.NewTabItem(lang.L("Transactions"), transactions.NewTransactionScreen(app, win)),
-		container.NewTabItem(lang.L("Loans"), loan.NewLoanScreen(app, win)),
-		container.NewTabItem(lang.L("Tools"), tools.NewToolsScreen(app, win)),
-	)
+
+	// Each tab is reloaded when the backend sends one of its events
+	screens := []struct {
+		title  string
+		create func() fyne.CanvasObject
+		events []string
+	}{
+		{
+			lang.L("Financial assets"),
+			func() fyne.CanvasObject { return financialassets.NewFinancialAssetsScreen(app, win) },
+			[]string{events.TypeSyncCompleted, events.TypeAccountChanged, events.TypeTransactionChanged},
+		},
+		{
+			lang.L("Accounts"),
+			func() fyne.CanvasObject { return account.NewAccountScreen(app, win) },
+			[]string{events.TypeSyncCompleted, events.TypeAccountChanged, events.TypeTransactionChanged},
+		},
+		{
+			lang.L("Transactions"),
+			func() fyne.CanvasObject { return transactions.NewTransactionScreen(app, win) },
+			[]string{events.TypeSyncCompleted, events.TypeAccountChanged, events.TypeTransactionChanged},
+		},
+		{
+			lang.L("Loans"),
+			func() fyne.CanvasObject { return loan.NewLoanScreen(app, win) },
+			[]string{events.TypeSyncCompleted, events.TypeAccountChanged},
+		},
+		{
+			lang.L("Tools"),
+			func() fyne.CanvasObject { return tools.NewToolsScreen(app, win) },
+			nil,
+		},
+	}
+
+	tabs := container.NewAppTabs()
 	tabs.SetTabLocation(container.TabLocationLeading)
 
+	// Tabs which are not displayed are only reloaded when selected
+	stale := make(map[*container.TabItem]func())
+
+	for _, screen := range screens {
+		content := container.NewStack(screen.create())
+		item := container.NewTabItem(screen.title, content)
+		tabs.Append(item)
+
+		if len(screen.events) == 0 {
+			continue
+		}
+
+		reload := func() {
+			content.Objects = []fyne.CanvasObject{screen.create()}
+			content.Refresh()
+		}
+		events.On(func(events.Event) {
+			fyne.Do(func() {
+				if tabs.Selected() == item {
+					reload()
+				} else {
+					stale[item] = reload
+				}
+			})
+		}, append(screen.events, events.TypeReconnected)...)
+	}
+
+	tabs.OnSelected = func(item *container.TabItem) {
+		if reload, ok := stale[item]; ok {
+			delete(stale, item)
+			reload()
+		}
+	}
+
 	return tabs
 }
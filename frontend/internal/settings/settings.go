@@ -1,7 +1,9 @@
 package settings
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -469,6 +471,35 @@ func SetBackendProtocol(value string, app fyne.App) {
 	helper.Logger.Info().Msgf("Backend protocol set to %s", value)
 }
 
+// Call the backend endpoint "/fx/base/" and set the currency used to display aggregated values.
+// Keep the default currency if the backend is unreachable
+func LoadBaseCurrency(app fyne.App) {
+
+	backendIp := app.Preferences().StringWithFallback(PreferenceBackendIP, BackendIPDefault)
+	backendProtocol := app.Preferences().StringWithFallback(PreferenceBackendProtocol, BackendProtocolDefault)
+	backendPort := app.Preferences().StringWithFallback(PreferenceBackendPort, BackendPortDefault)
+
+	url := fmt.Sprintf("%s://%s:%s/fx/base/", backendProtocol, backendIp, backendPort)
+
+	client := http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		helper.Logger.Warn().Err(err).Msg("Cannot get base currency, using the default one")
+		return
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Base_currency string `json:"base_currency"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		helper.Logger.Warn().Err(err).Msg("Cannot unmarshal base currency")
+		return
+	}
+
+	helper.SetBaseCurrency(body.Base_currency)
+}
+
 func NewSettings(app fyne.App, topWindow fyne.Window) {
 
 	win := app.NewWindow(lang.L("General Settings"))
@@ -219,10 +219,10 @@ func createViewContainer(toolType int) *fyne.Container {
 	var capitalLabel *widget.Label
 	switch toolType {
 	case simpleInterestType, compoundInterestType:
-		capitalLabel = widget.NewLabelWithData(binding.IntToStringWithFormat(capitalData, lang.L("Capital")+": %d €"))
+		capitalLabel = widget.NewLabelWithData(binding.IntToStringWithFormat(capitalData, lang.L("Capital")+": %d "+helper.BaseCurrencySymbol()))
 
 	case loanType:
-		capitalLabel = widget.NewLabelWithData(binding.IntToStringWithFormat(capitalData, lang.L("Borrowed capital")+": %d €"))
+		capitalLabel = widget.NewLabelWithData(binding.IntToStringWithFormat(capitalData, lang.L("Borrowed capital")+": %d "+helper.BaseCurrencySymbol()))
 	}
 
 	capitalLabel.Alignment = fyne.TextAlignCenter
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"freenahiFront/internal/helper"
+	"freenahiFront/internal/money"
 	"freenahiFront/internal/settings"
 
 	"fyne.io/fyne/v2"
@@ -39,12 +40,12 @@ const (
 
 // The struct which is returned by the backend
 type Transaction struct {
-	Id               int     `json:"id"`
-	Pinned           bool    `json:"pinned"`
-	Date             string  `json:"date"`
-	Value            float32 `json:"value"`
-	Transaction_type string  `json:"type"`
-	Original_wording string  `json:"original_wording"`
+	Id               int          `json:"id"`
+	Pinned           bool         `json:"pinned"`
+	Date             string       `json:"date"`
+	Value            money.Amount `json:"value"`
+	Transaction_type string       `json:"type"`
+	Original_wording string       `json:"original_wording"`
 }
 
 // A standard table, but which has resizabled column width
@@ -204,7 +205,7 @@ func NewTransactionScreen(app fyne.App, win fyne.Window) *fyne.Container {
 					valueItem.Importance = widget.MediumImportance
 				}
 				valueItem.Show()
-				valueItem.SetText(helper.ValueSpacer(fmt.Sprintf("%.2f", txs[id.Row].Value)))
+				valueItem.SetText(helper.ValueSpacer(txs[id.Row].Value.StringFixed(2)))
 
 			case typeColumn:
 				// ToDo: display an icon instead of a text ? More user friendly
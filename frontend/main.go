@@ -1,6 +1,7 @@
 package main
 
 import (
+	"freenahiFront/internal/events"
 	"freenahiFront/internal/helper"
 	"freenahiFront/internal/menu"
 	"freenahiFront/internal/settings"
@@ -49,6 +50,9 @@ func main() {
 
 	helper.LogLifecycle(fyneApp)
 
+	// Aggregated values are converted by the backend in its base currency
+	settings.LoadBaseCurrency(fyneApp)
+
 	w := fyneApp.NewWindow(appName)
 	w.CenterOnScreen()
 	w.SetFullScreen(settings.GetFullscreen(fyneApp))
@@ -67,6 +71,9 @@ func main() {
 		menu.NewLeftMenu(fyneApp, w),
 	))
 
+	// Reload the tabs when the backend data changes
+	go events.Listen(fyneApp)
+
 	// When clicking exit on the window (reduce, fullscreen and exit icons)
 	w.SetCloseIntercept(func() {
 		exitOnTray := fyneApp.Preferences().BoolWithFallback(settings.PreferenceSystemTray, settings.SystemTrayDefault)